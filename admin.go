@@ -0,0 +1,210 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// featureFlags holds the features that can be toggled at runtime from the admin page
+type featureFlags struct {
+	mu    sync.RWMutex
+	flags map[string]bool
+}
+
+type FeatureFlag struct {
+	Name    string
+	Enabled bool
+}
+
+type ConfigEntry struct {
+	Name    string
+	Value   string
+	Default string
+	Usage   string
+}
+
+// libraryFlags are the flags registered by imported packages, they're left out of the effective configuration
+var libraryFlags = make(map[string]bool)
+
+func init() {
+	flag.VisitAll(func(f *flag.Flag) {
+		libraryFlags[f.Name] = true
+	})
+}
+
+func newFeatureFlags(flags map[string]bool) *featureFlags {
+	return &featureFlags{
+		flags: flags,
+	}
+}
+
+func (f *featureFlags) enabled(name string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.flags[name]
+}
+
+func (f *featureFlags) set(name string, enabled bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.flags[name]; !ok {
+		return fmt.Errorf("unknown feature flag %s", name)
+	}
+	f.flags[name] = enabled
+	return nil
+}
+
+func (f *featureFlags) list() []FeatureFlag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	var flags []FeatureFlag
+	for name, enabled := range f.flags {
+		flags = append(flags, FeatureFlag{Name: name, Enabled: enabled})
+	}
+	slices.SortFunc(flags, func(a, b FeatureFlag) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return flags
+}
+
+// isAdmin returns true when the client of the request is listed as admin in the policy, or was granted the admin
+// role when authenticated by the dashboard. The admin page is only available when a policy file is configured
+func (c *Config) isAdmin(r *http.Request) bool {
+	if c.policy == nil {
+		return false
+	}
+	if session, ok := requestSession(r); ok && session.Role == roleAdmin {
+		return true
+	}
+	id := c.policy.identity(r)
+	if id.User == "" {
+		return false
+	}
+	return slices.Contains(c.policy.Admins.Users, id.User) ||
+		slices.ContainsFunc(id.Groups, func(group string) bool { return slices.Contains(c.policy.Admins.Groups, group) })
+}
+
+// adminMiddleware restricts access to admins, and rejects form submissions coming from other origins
+func (c *Config) adminMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !c.isAdmin(r) {
+			http.Error(w, "admin access required", http.StatusForbidden)
+			return
+		}
+		if r.Method == http.MethodPost && !sameOrigin(r) {
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *Config) adminRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /admin", c.adminHandler)
+	mux.HandleFunc("POST /admin/cache/refresh", c.adminCacheRefreshHandler)
+	mux.HandleFunc("POST /admin/rescan", c.adminRescanHandler)
+	mux.HandleFunc("POST /admin/features/{name}", c.adminFeatureHandler)
+	mux.HandleFunc("POST /admin/tokens", c.adminTokenCreateHandler)
+	mux.HandleFunc("POST /admin/tokens/{name}/revoke", c.adminTokenRevokeHandler)
+	return c.adminMiddleware(mux)
+}
+
+func (c *Config) adminHandler(w http.ResponseWriter, r *http.Request) {
+	c.renderAdmin(w, r, r.URL.Query().Get("msg"), "")
+}
+
+// renderAdmin renders the admin page with the message, and the API token just created if any
+func (c *Config) renderAdmin(w http.ResponseWriter, r *http.Request, message, newToken string) {
+	type TemplateData struct {
+		Config      []ConfigEntry
+		Features    []FeatureFlag
+		CachedRuns  int
+		WatchedDirs int
+		LiveClients int
+		ReadOnly    bool
+		Tokens      []APIToken
+		TokenScopes []string
+		NewToken    string
+		Message     string
+	}
+	var config []ConfigEntry
+	flag.VisitAll(func(f *flag.Flag) {
+		if libraryFlags[f.Name] {
+			return
+		}
+		config = append(config, ConfigEntry{
+			Name:    f.Name,
+			Value:   f.Value.String(),
+			Default: f.DefValue,
+			Usage:   f.Usage,
+		})
+	})
+	tokens, err := c.tokens.list()
+	if err != nil {
+		message = cmp.Or(message, fmt.Sprintf("Error listing tokens: %v", err))
+	}
+	data := TemplateData{
+		Config:      config,
+		Features:    c.features.list(),
+		CachedRuns:  runsCache.len(),
+		WatchedDirs: c.watchedDirs(),
+		LiveClients: c.hub.len(),
+		ReadOnly:    c.readOnly,
+		Tokens:      tokens,
+		TokenScopes: tokenScopes,
+		NewToken:    newToken,
+		Message:     message,
+	}
+	c.renderTemplate(w, r, "admin.html", data)
+}
+
+func (c *Config) adminCacheRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	entries := runsCache.len()
+	runsCache.purge()
+	if c.analytics != nil {
+		c.analytics.invalidate()
+	}
+	fmt.Printf("Run cache purged by admin, %d entries dropped\n", entries)
+	adminRedirect(w, r, fmt.Sprintf("Cache refreshed, %d cached runs dropped", entries))
+}
+
+func (c *Config) adminRescanHandler(w http.ResponseWriter, r *http.Request) {
+	if c.rescan != nil {
+		c.requestRescan()
+		adminRedirect(w, r, "Results directory rescan requested")
+		return
+	}
+	if err := c.addWatches(); err != nil {
+		adminRedirect(w, r, fmt.Sprintf("Error rescanning results directory: %v", err))
+		return
+	}
+	runsCache.purge()
+	adminRedirect(w, r, fmt.Sprintf("Results directory rescanned, %d directories watched", c.watchedDirs()))
+}
+
+func (c *Config) adminFeatureHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	enabled, err := strconv.ParseBool(r.FormValue("enabled"))
+	if err != nil {
+		http.Error(w, "invalid enabled value", http.StatusBadRequest)
+		return
+	}
+	if err := c.features.set(name, enabled); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	fmt.Printf("Feature %s set to %v by admin\n", name, enabled)
+	adminRedirect(w, r, fmt.Sprintf("Feature %s %s", name, map[bool]string{true: "enabled", false: "disabled"}[enabled]))
+}
+
+func adminRedirect(w http.ResponseWriter, r *http.Request, message string) {
+	http.Redirect(w, r, "/admin?msg="+url.QueryEscape(message), http.StatusSeeOther)
+}
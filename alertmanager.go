@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	regressionAlertName = "PerformanceRegression"
+	// Firing alerts are sent again at this interval, Alertmanager resolves them when they stop being sent
+	alertResendInterval = time.Minute
+)
+
+var alertmanagerClient = &http.Client{Timeout: httpTimeout}
+
+// AlertmanagerConfig pushes the sustained regressions as alerts to Alertmanager, so they go through its routing,
+// grouping and silences like any other alert. Alerts are resolved once the regression recovers
+type AlertmanagerConfig struct {
+	URL string `yaml:"url"`
+	// Token is sent as a bearer token when set, it expands environment variables
+	Token string `yaml:"token"`
+	// Labels are added to every alert, e.g. to route them to a team or set their severity
+	Labels map[string]string `yaml:"labels"`
+
+	mu sync.Mutex
+	// firing holds the alerts of the ongoing regressions, keyed by workload metric quantile
+	firing map[string]Alert
+}
+
+// Alert is an alert in the format of the Alertmanager v2 API
+type Alert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+func (a *AlertmanagerConfig) validate() error {
+	if a.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	a.Token = os.ExpandEnv(a.Token)
+	a.firing = make(map[string]Alert)
+	return nil
+}
+
+// regressionAlert builds the alert of the regression
+func (a *AlertmanagerConfig) regressionAlert(r Regression, dashboardURL string, loc *time.Location) Alert {
+	alert := Alert{
+		Labels: map[string]string{
+			"alertname":  regressionAlertName,
+			"ci_job":     r.Job,
+			"workload":   r.Workload,
+			"metric":     r.MetricName,
+			"quantile":   r.QuantileName,
+			"percentile": r.Percentile,
+		},
+		Annotations: map[string]string{
+			"summary":             "Performance regression: " + r.String(),
+			"description":         r.description(loc),
+			"first_regressed_run": r.FirstRegressed.Name,
+			"delta":               fmt.Sprintf("%+.1f%%", r.FirstRegressed.Delta),
+		},
+		StartsAt: r.FirstRegressed.Timestamp,
+	}
+	if owner := metricOwners.owner(r.MetricName, r.QuantileName); owner != "" {
+		alert.Labels["owner"] = owner
+	}
+	maps.Copy(alert.Labels, a.Labels)
+	if dashboardURL != "" {
+		alert.GeneratorURL = regressionURL(r, dashboardURL)
+	}
+	return alert
+}
+
+// update fires the alert of an ongoing regression, or resolves the alert of the watch when it recovered
+func (a *AlertmanagerConfig) update(r Regression, dashboardURL string, loc *time.Location) error {
+	key := strings.Join([]string{r.Job, r.Workload, r.MetricName, r.QuantileName, r.Percentile}, "/")
+	a.mu.Lock()
+	alert, firing := a.firing[key]
+	switch {
+	case r.FirstRegressed != nil:
+		alert = a.regressionAlert(r, dashboardURL, loc)
+		alert.EndsAt = time.Now().Add(3 * alertResendInterval)
+		a.firing[key] = alert
+	case firing:
+		alert.EndsAt = time.Now()
+		delete(a.firing, key)
+	default:
+		a.mu.Unlock()
+		return nil
+	}
+	a.mu.Unlock()
+	if err := a.send([]Alert{alert}); err != nil {
+		return err
+	}
+	if r.FirstRegressed == nil {
+		fmt.Printf("Resolved alert of %s/%s %s %s\n", r.Job, r.Workload, r.MetricName, r.QuantileName)
+	} else if !firing {
+		fmt.Printf("Fired alert of regression %s\n", r)
+	}
+	return nil
+}
+
+// resendAlerts sends the firing alerts again periodically, extending their end time
+func (a *AlertmanagerConfig) resendAlerts() {
+	go func() {
+		ticker := time.NewTicker(alertResendInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			a.mu.Lock()
+			var alerts []Alert
+			for key, alert := range a.firing {
+				alert.EndsAt = time.Now().Add(3 * alertResendInterval)
+				a.firing[key] = alert
+				alerts = append(alerts, alert)
+			}
+			a.mu.Unlock()
+			if len(alerts) == 0 {
+				continue
+			}
+			if err := a.send(alerts); err != nil {
+				fmt.Println("Error sending alerts to Alertmanager:", err)
+			}
+		}
+	}()
+}
+
+// send posts the alerts to the Alertmanager v2 API
+func (a *AlertmanagerConfig) send(alerts []Alert) error {
+	payload, err := json.Marshal(alerts)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(a.URL, "/")+"/api/v2/alerts", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	}
+	resp, err := alertmanagerClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("error sending alerts to %s: %s: %s", a.URL, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxAliasLength is the longest alias of a run, in characters
+const maxAliasLength = 64
+
+// aliasStore keeps the human names of the runs by UUID, such as "rc.3 candidate", persisted to a JSON file when
+// configured, and in memory otherwise. The file maps the UUIDs to their alias and can be edited by hand
+type aliasStore struct {
+	mu      sync.Mutex
+	path    string
+	aliases map[string]string
+}
+
+func loadAliases(path string) (*aliasStore, error) {
+	store := &aliasStore{
+		path:    path,
+		aliases: make(map[string]string),
+	}
+	if path == "" {
+		return store, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.aliases); err != nil {
+		return nil, fmt.Errorf("error parsing aliases file %s: %v", path, err)
+	}
+	return store, nil
+}
+
+// get returns the alias of the run of the UUID, empty when it has none
+func (s *aliasStore) get(uuid string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.aliases[uuid]
+}
+
+// list returns the aliases by run UUID
+func (s *aliasStore) list() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return maps.Clone(s.aliases)
+}
+
+// set names the run of the UUID, or removes its alias when empty
+func (s *aliasStore) set(uuid, alias string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if alias == "" {
+		delete(s.aliases, uuid)
+	} else {
+		s.aliases[uuid] = alias
+	}
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.aliases, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// validAlias checks the alias fits on the run tables and selectors it's shown in
+func validAlias(alias string) error {
+	if utf8.RuneCountInString(alias) > maxAliasLength {
+		return fmt.Errorf("invalid alias: must be at most %d characters", maxAliasLength)
+	}
+	if strings.ContainsFunc(alias, func(r rune) bool { return r < ' ' }) {
+		return errors.New("invalid alias: must be a single line")
+	}
+	return nil
+}
+
+// runAliases returns the aliases of the runs by UUID, for the run details of the workload charts
+func (c *Config) runAliases(runs []Run) map[string]string {
+	aliases := make(map[string]string)
+	for _, run := range runs {
+		if alias := c.aliases.get(run.UUID()); alias != "" {
+			aliases[run.UUID()] = alias
+		}
+	}
+	return aliases
+}
+
+// apiAliasesHandler lists the aliases of the runs by UUID
+func (c *Config) apiAliasesHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, c.aliases.list())
+}
+
+// apiSetAliasHandler names the run of the UUID after the alias of the JSON body, {"alias": "rc.3 candidate"}, an
+// empty alias removing it
+func (c *Config) apiSetAliasHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Alias string `json:"alias"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	alias := strings.TrimSpace(body.Alias)
+	if err := validAlias(alias); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.saveAlias(w, r, alias)
+}
+
+// apiDeleteAliasHandler removes the alias of the run of the UUID
+func (c *Config) apiDeleteAliasHandler(w http.ResponseWriter, r *http.Request) {
+	c.saveAlias(w, r, "")
+}
+
+// canSetAlias reports whether the client can name runs. Aliases are shown to everyone, so read-only users and upload
+// tokens can't, only admins and the users the dashboard didn't authenticate itself, or anyone when no policy is
+// configured
+func (c *Config) canSetAlias(r *http.Request) bool {
+	_, authenticated := requestSession(r)
+	return !authenticated || c.isAdmin(r)
+}
+
+// saveAlias sets the alias of the run of the UUID path value, which must be one the client can access, and responds
+// with the run
+func (c *Config) saveAlias(w http.ResponseWriter, r *http.Request, alias string) {
+	if !c.canSetAlias(r) {
+		http.Error(w, "access denied: aliases can only be set by admins", http.StatusForbidden)
+		return
+	}
+	jobs, err := c.visibleJobs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	uuid := r.PathValue("uuid")
+	location, ok := findRun(jobs, uuid)
+	if !ok {
+		http.Error(w, fmt.Sprintf("run %s not found", uuid), http.StatusNotFound)
+		return
+	}
+	if err := c.aliases.set(location.Run.UUID(), alias); err != nil {
+		http.Error(w, fmt.Sprintf("error saving alias: %v", err), http.StatusInternalServerError)
+		return
+	}
+	location.Alias = alias
+	writeJSON(w, location)
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// Limits of the SQL queries, results are truncated to sqlMaxRows rows
+const (
+	sqlMaxRows = 10000
+	sqlTimeout = 10 * time.Second
+)
+
+// sqlSchema is the table holding the measurements, with the columns of the exports
+const sqlSchema = `CREATE TABLE measurements (
+	job TEXT, workload TEXT, run TEXT, uuid TEXT, seq INTEGER, timestamp TEXT, phase TEXT, metric TEXT, quantile TEXT,
+	p99 REAL, p95 REAL, p50 REAL, min REAL, max REAL, avg REAL, passed INTEGER, metadata TEXT
+)`
+
+// sqlReadOnly matches the queries allowed, a single SELECT statement, possibly with common table expressions
+var sqlReadOnly = regexp.MustCompile(`(?is)^\s*(select|with)\b[^;]*;?\s*$`)
+
+// analyticsStore holds the in-memory SQLite databases the SQL queries run against, one per set of jobs visible to
+// the clients so the policy applies to queries as well. They're built on first use and dropped whenever the results
+// change
+type analyticsStore struct {
+	mu  sync.Mutex
+	dbs map[string]*sql.DB
+}
+
+func newAnalyticsStore() *analyticsStore {
+	return &analyticsStore{dbs: make(map[string]*sql.DB)}
+}
+
+// invalidate drops the databases, they're built again with the current results by the next queries
+func (s *analyticsStore) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, db := range s.dbs {
+		// Close waits for the queries in progress
+		go db.Close()
+		delete(s.dbs, key)
+	}
+}
+
+// db returns the database holding the measurements of the jobs, building it when needed
+func (s *analyticsStore) db(jobs []Job) (*sql.DB, error) {
+	var names []string
+	for _, job := range jobs {
+		names = append(names, job.Name)
+	}
+	slices.Sort(names)
+	key := strings.Join(names, "\n")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if db, ok := s.dbs[key]; ok {
+		return db, nil
+	}
+	db, err := loadAnalyticsDB(jobs)
+	if err != nil {
+		return nil, err
+	}
+	s.dbs[key] = db
+	return db, nil
+}
+
+// loadAnalyticsDB loads the measurements of the jobs into a new in-memory database, which only accepts queries once
+// loaded
+func loadAnalyticsDB(jobs []Job) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	// Every connection to :memory: opens a database of its own
+	db.SetMaxOpenConns(1)
+	if err := fillAnalyticsDB(db, jobs); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("error loading the measurements: %v", err)
+	}
+	return db, nil
+}
+
+func fillAnalyticsDB(db *sql.DB, jobs []Job) error {
+	if _, err := db.Exec(sqlSchema); err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	insert, err := tx.Prepare("INSERT INTO measurements VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		for _, workload := range job.Workloads {
+			runs, err := loadRuns(workload.Path)
+			if err != nil {
+				continue
+			}
+			for _, row := range measurementRows(job.Name, workload.Name, runs, ChartOptions{}) {
+				_, err := insert.Exec(row.Job, row.Workload, row.Run, row.UUID, row.Seq,
+					row.Timestamp.UTC().Format("2006-01-02T15:04:05.000Z"), row.Phase, row.Metric, row.Quantile,
+					row.P99, row.P95, row.P50, row.Min, row.Max, row.Avg, row.Passed, row.Metadata)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	_, err = db.Exec("PRAGMA query_only = ON")
+	return err
+}
+
+// SQLResult is the result of a SQL query
+type SQLResult struct {
+	Columns []string
+	Rows    [][]any
+	// Truncated is set when the query returned more than sqlMaxRows rows
+	Truncated bool
+}
+
+// runSQL runs the read-only query against the measurements of the jobs
+func (c *Config) runSQL(ctx context.Context, jobs []Job, query string) (SQLResult, int, error) {
+	var result SQLResult
+	if !sqlReadOnly.MatchString(query) {
+		return result, http.StatusBadRequest, fmt.Errorf("only single SELECT statements are allowed")
+	}
+	db, err := c.analytics.db(jobs)
+	if err != nil {
+		return result, http.StatusInternalServerError, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, sqlTimeout)
+	defer cancel()
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return result, http.StatusBadRequest, err
+	}
+	defer rows.Close()
+	if result.Columns, err = rows.Columns(); err != nil {
+		return result, http.StatusBadRequest, err
+	}
+	for rows.Next() {
+		if len(result.Rows) == sqlMaxRows {
+			result.Truncated = true
+			break
+		}
+		values := make([]any, len(result.Columns))
+		pointers := make([]any, len(values))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return result, http.StatusBadRequest, err
+		}
+		for i, value := range values {
+			if b, ok := value.([]byte); ok {
+				values[i] = string(b)
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return result, http.StatusBadRequest, err
+	}
+	return result, http.StatusOK, nil
+}
+
+// apiSQLHandler runs the read-only SQL query given with query= against the measurements of the jobs visible to the
+// client, rows are returned as objects, one per line, when the client accepts NDJSON
+func (c *Config) apiSQLHandler(w http.ResponseWriter, r *http.Request) {
+	if c.analytics == nil {
+		http.Error(w, "SQL queries are disabled, enable them with -sql", http.StatusNotFound)
+		return
+	}
+	jobs, err := c.visibleJobs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	result, status, err := c.runSQL(r.Context(), jobs, r.URL.Query().Get("query"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if wantsNDJSON(r) {
+		enc := newNDJSONEncoder(w)
+		for _, row := range result.Rows {
+			doc := make(map[string]any, len(row))
+			for i, column := range result.Columns {
+				doc[column] = row[i]
+			}
+			if err := enc.encode(doc); err != nil {
+				return
+			}
+		}
+		return
+	}
+	writeJSON(w, result)
+}
+
+// sqlHandler renders the SQL console, running the query of the form when given
+func (c *Config) sqlHandler(w http.ResponseWriter, r *http.Request) {
+	if c.analytics == nil {
+		http.Error(w, "SQL queries are disabled, enable them with -sql", http.StatusNotFound)
+		return
+	}
+	type TemplateData struct {
+		Query  string
+		Result SQLResult
+		Error  string
+	}
+	data := TemplateData{
+		Query: r.URL.Query().Get("query"),
+	}
+	if data.Query == "" {
+		data.Query = "SELECT workload, quantile, count(*) AS runs, avg(p99) AS mean_p99\nFROM measurements\nGROUP BY workload, quantile\nORDER BY workload, quantile"
+	} else {
+		jobs, err := c.visibleJobs(r)
+		if err == nil {
+			data.Result, _, err = c.runSQL(r.Context(), jobs, data.Query)
+		}
+		if err != nil {
+			data.Error = err.Error()
+		}
+	}
+	c.renderTemplate(w, r, "sql.html", data)
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultAnalyzerRuns    = 20
+	defaultAnalyzerTimeout = time.Minute
+)
+
+// Severities of the findings of the analyzers
+var findingSeverities = []string{"info", "warning", "critical"}
+
+// AnalyzerConfig declares an analyzer, a command run over the latest runs of the workloads of the matching jobs.
+// It's written the workload and its runs as JSON on stdin, and writes its findings as JSON on stdout
+type AnalyzerConfig struct {
+	Name    string   `yaml:"name"`
+	Command []string `yaml:"command"`
+	// Jobs are the patterns of the jobs analyzed, every job when empty
+	Jobs []string `yaml:"jobs"`
+	// Runs is the number of latest runs of the workload passed to the analyzer
+	Runs    int           `yaml:"runs"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// AnalyzerInput is written to the stdin of the analyzers
+type AnalyzerInput struct {
+	Job      string        `json:"job"`
+	Workload string        `json:"workload"`
+	Runs     []AnalyzerRun `json:"runs"`
+}
+
+// AnalyzerRun is a run as passed to the analyzers, in order of start time
+type AnalyzerRun struct {
+	Name         string         `json:"name"`
+	UUID         string         `json:"uuid"`
+	Seq          int            `json:"seq"`
+	Started      time.Time      `json:"started"`
+	Passed       bool           `json:"passed"`
+	Metadata     map[string]any `json:"metadata"`
+	Measurements []Measurement  `json:"measurements"`
+}
+
+// AnalyzerOutput is read from the stdout of the analyzers
+type AnalyzerOutput struct {
+	Findings []Finding `json:"findings"`
+}
+
+// Finding is something an analyzer found in the runs of a workload, such as a failed gate
+type Finding struct {
+	// Severity is info, warning or critical
+	Severity string `json:"severity"`
+	Title    string `json:"title"`
+	Text     string `json:"text,omitempty"`
+	// Run, Metric and Quantile locate the finding, when it's about a run or a metric
+	Run      string `json:"run,omitempty"`
+	Metric   string `json:"metric,omitempty"`
+	Quantile string `json:"quantile,omitempty"`
+}
+
+// AnalyzerResult holds the findings of the latest analysis of a workload by an analyzer
+type AnalyzerResult struct {
+	Analyzer string    `json:"analyzer"`
+	Ran      time.Time `json:"ran"`
+	Findings []Finding `json:"findings"`
+	// Error is set when the analyzer failed or its output couldn't be read
+	Error string `json:"error,omitempty"`
+}
+
+// analyzers runs the configured analyzers and keeps their latest results in memory, by job and workload
+type analyzers struct {
+	configs []AnalyzerConfig
+
+	mu      sync.Mutex
+	results map[string][]AnalyzerResult
+}
+
+func loadAnalyzers(configPath string) (*analyzers, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var configs []AnalyzerConfig
+	if err := yaml.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("error parsing analyzers config %s: %v", configPath, err)
+	}
+	for i, ac := range configs {
+		if ac.Name == "" || len(ac.Command) == 0 {
+			return nil, fmt.Errorf("invalid analyzer %q in %s: name and command are required", ac.Name, configPath)
+		}
+		if slices.ContainsFunc(configs[:i], func(other AnalyzerConfig) bool { return other.Name == ac.Name }) {
+			return nil, fmt.Errorf("duplicate analyzer %s in %s", ac.Name, configPath)
+		}
+		if err := validJobPatterns(ac.Jobs); err != nil {
+			return nil, fmt.Errorf("invalid analyzer %s: %v", ac.Name, err)
+		}
+		if ac.Runs < 0 || ac.Timeout < 0 {
+			return nil, fmt.Errorf("invalid analyzer %s: runs and timeout must be positive", ac.Name)
+		}
+		configs[i].Runs = cmp.Or(ac.Runs, defaultAnalyzerRuns)
+		configs[i].Timeout = cmp.Or(ac.Timeout, defaultAnalyzerTimeout)
+	}
+	return &analyzers{configs: configs, results: make(map[string][]AnalyzerResult)}, nil
+}
+
+// run runs the command with the input on stdin, decoding the findings on its stdout
+func (ac AnalyzerConfig) run(input AnalyzerInput) ([]Finding, error) {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ac.Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, ac.Command[0], ac.Command[1:]...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = bytes.NewReader(data), &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, msg)
+		}
+		return nil, err
+	}
+	var output AnalyzerOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, fmt.Errorf("error parsing the output: %v", err)
+	}
+	for i, f := range output.Findings {
+		if f.Title == "" {
+			return nil, fmt.Errorf("finding %d has no title", i)
+		}
+		if f.Severity == "" {
+			output.Findings[i].Severity = "info"
+		} else if !slices.Contains(findingSeverities, f.Severity) {
+			return nil, fmt.Errorf("finding %q has an invalid severity %q, must be one of %s", f.Title, f.Severity, strings.Join(findingSeverities, ", "))
+		}
+	}
+	return output.Findings, nil
+}
+
+// analyze runs the analyzers matching the job over the latest runs of the workload, replacing their previous results
+func (a *analyzers) analyze(resultsDir, job, workload string) {
+	var matching []AnalyzerConfig
+	for _, ac := range a.configs {
+		if matchJob(ac.Jobs, job) {
+			matching = append(matching, ac)
+		}
+	}
+	if len(matching) == 0 {
+		return
+	}
+	runs, err := loadRuns(filepath.Join(resultsDir, job, workload))
+	if err != nil {
+		fmt.Printf("Error loading runs for %s/%s: %v\n", job, workload, err)
+		return
+	}
+	var results []AnalyzerResult
+	for _, ac := range matching {
+		input := AnalyzerInput{Job: job, Workload: workload, Runs: []AnalyzerRun{}}
+		for _, run := range runs[max(len(runs)-ac.Runs, 0):] {
+			input.Runs = append(input.Runs, AnalyzerRun{
+				Name:         run.Name(),
+				UUID:         run.UUID(),
+				Seq:          run.Seq,
+				Started:      run.Started(),
+				Passed:       run.Summary.Passed,
+				Metadata:     run.metadataFields(),
+				Measurements: run.Measurements,
+			})
+		}
+		result := AnalyzerResult{Analyzer: ac.Name, Ran: time.Now().UTC(), Findings: []Finding{}}
+		findings, err := ac.run(input)
+		if err != nil {
+			result.Error = err.Error()
+			fmt.Printf("Error running analyzer %s over %s/%s: %v\n", ac.Name, job, workload, err)
+		} else {
+			result.Findings = append(result.Findings, findings...)
+		}
+		results = append(results, result)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.results[job+"/"+workload] = results
+}
+
+// workload returns the latest results of the analyzers of the workload
+func (a *analyzers) workload(job, workload string) []AnalyzerResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.results[job+"/"+workload])
+}
+
+// analyzeAll runs the analyzers over every workload at startup, so their findings show without waiting for the next
+// run of the workloads
+func (c *Config) analyzeAll() {
+	if c.analyzers == nil {
+		return
+	}
+	go func() {
+		jobs, err := loadJobs(c.resultsDir)
+		if err != nil {
+			fmt.Println("Error loading jobs:", err)
+			return
+		}
+		for _, job := range jobs {
+			for _, workload := range job.Workloads {
+				c.analyzers.analyze(c.resultsDir, job.Name, workload.Name)
+			}
+		}
+	}()
+}
+
+// findings returns the results of the analyzers of the workload, none when no analyzer is configured
+func (c *Config) findings(job, workload string) []AnalyzerResult {
+	if c.analyzers == nil {
+		return nil
+	}
+	return c.analyzers.workload(job, workload)
+}
+
+func (c *Config) apiFindingsHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	writeJSON(w, append([]AnalyzerResult{}, c.findings(jobName, workloadName)...))
+}
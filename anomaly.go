@@ -0,0 +1,223 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// anomalyWindow is the number of runs before an ingested run its values are compared against
+	anomalyWindow = 10
+	// anomalyZScore is the number of standard deviations from the trailing mean a value is anomalous from
+	anomalyZScore = 3
+	// anomalyPercentile is the percentile of the quantiles checked for anomalies
+	anomalyPercentile = "P99"
+)
+
+// MetricAnomaly is the verdict of the anomaly detector on a metric quantile of a run
+type MetricAnomaly struct {
+	MetricName   string
+	QuantileName string
+	// JobName is the kube-burner job of the measurements, when the workload runs several
+	JobName string `json:",omitempty"`
+	Value   float64
+	// Mean and StdDev describe the values of the trailing runs
+	Mean      float64
+	StdDev    float64
+	ZScore    float64
+	Anomalous bool
+}
+
+// RunAnomalies holds the verdicts on the metrics of a run, computed when it was ingested
+type RunAnomalies struct {
+	Job      string
+	Workload string
+	Run      string
+	UUID     string
+	Started  time.Time
+	Checked  time.Time
+	// TrailingRuns is the number of runs the values were compared against
+	TrailingRuns int
+	Metrics      []MetricAnomaly
+}
+
+// Count returns the number of anomalous metrics of the run
+func (ra RunAnomalies) Count() int {
+	count := 0
+	for _, m := range ra.Metrics {
+		if m.Anomalous {
+			count++
+		}
+	}
+	return count
+}
+
+// detectAnomalies computes the z-score of every metric quantile of the run against the distribution of the runs
+// before it, false when there are fewer than minRuns of them
+func detectAnomalies(runs []Run, run Run, minRuns int) (RunAnomalies, bool) {
+	i := slices.IndexFunc(runs, func(r Run) bool { return r.Path == run.Path })
+	if i < 0 || i < max(minRuns, 2) {
+		return RunAnomalies{}, false
+	}
+	trailing := runs[max(i-anomalyWindow, 0):i]
+	result := RunAnomalies{Run: run.Name(), UUID: run.UUID(), Started: run.Started(), Checked: time.Now().UTC(), TrailingRuns: len(trailing)}
+	job := Job{Runs: append(slices.Clone(trailing), run)}
+	for _, group := range prepareChartData(&job, ChartOptions{GroupByJob: true}) {
+		for _, chart := range group.Charts {
+			var values []float64
+			var value float64
+			found := false
+			for _, dp := range chart.Datapoints {
+				if dp.Seq == run.Seq {
+					value, found = dp.value(anomalyPercentile), true
+				} else {
+					values = append(values, dp.value(anomalyPercentile))
+				}
+			}
+			if !found || len(values) < max(minRuns, 2) {
+				continue
+			}
+			m := MetricAnomaly{
+				MetricName:   group.MetricName,
+				QuantileName: chart.QuantileName,
+				JobName:      group.JobName,
+				Value:        value,
+				Mean:         mean(values),
+				StdDev:       stddev(values),
+			}
+			// Constant values have no spread to measure the deviation against
+			if m.StdDev > 0 {
+				m.ZScore = (m.Value - m.Mean) / m.StdDev
+				m.Anomalous = math.Abs(m.ZScore) >= anomalyZScore
+			}
+			result.Metrics = append(result.Metrics, m)
+		}
+	}
+	slices.SortFunc(result.Metrics, func(a, b MetricAnomaly) int {
+		return cmp.Or(strings.Compare(a.MetricName, b.MetricName), strings.Compare(a.JobName, b.JobName), strings.Compare(a.QuantileName, b.QuantileName))
+	})
+	return result, true
+}
+
+// anomalyStore keeps the verdicts of the runs ingested, by job, workload and run, persisted to a JSON file when
+// configured and kept in memory otherwise
+type anomalyStore struct {
+	mu   sync.Mutex
+	path string
+	runs map[string]RunAnomalies
+}
+
+func loadAnomalies(path string) (*anomalyStore, error) {
+	store := &anomalyStore{path: path, runs: make(map[string]RunAnomalies)}
+	if path == "" {
+		return store, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.runs); err != nil {
+		return nil, fmt.Errorf("error parsing anomalies file %s: %v", path, err)
+	}
+	return store, nil
+}
+
+func anomalyKey(job, workload, run string) string {
+	return job + "/" + workload + "/" + run
+}
+
+func (s *anomalyStore) set(ra RunAnomalies) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[anomalyKey(ra.Job, ra.Workload, ra.Run)] = ra
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.runs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// workload returns the verdicts of the runs of the workload, in order of start time
+func (s *anomalyStore) workload(job, workload string) []RunAnomalies {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := anomalyKey(job, workload, "")
+	var runs []RunAnomalies
+	for key, ra := range s.runs {
+		if strings.HasPrefix(key, prefix) {
+			runs = append(runs, ra)
+		}
+	}
+	slices.SortFunc(runs, func(a, b RunAnomalies) int {
+		return cmp.Or(a.Started.Compare(b.Started), strings.Compare(a.Run, b.Run))
+	})
+	return runs
+}
+
+// checkAnomalies runs the detector on the run ingested, storing its verdicts
+func (c *Config) checkAnomalies(event Event) {
+	workloadPath := filepath.Join(c.resultsDir, event.Job, event.Workload)
+	run, ok := loadEventRun(filepath.Join(workloadPath, event.Run))
+	if !ok {
+		return
+	}
+	runs, err := loadRuns(workloadPath)
+	if err != nil {
+		return
+	}
+	// The ingested run is looked up in the ordered runs, so it carries its sequence number
+	i := slices.IndexFunc(runs, func(r Run) bool { return r.Path == run.Path })
+	if i < 0 {
+		return
+	}
+	ra, ok := detectAnomalies(runs, runs[i], c.minSamples)
+	if !ok {
+		return
+	}
+	ra.Job, ra.Workload = event.Job, event.Workload
+	if err := c.anomalies.set(ra); err != nil {
+		fmt.Println("Error saving anomalies:", err)
+	}
+	if count := ra.Count(); count > 0 {
+		fmt.Printf("Run %s/%s/%s has %d anomalous metrics\n", event.Job, event.Workload, event.Run, count)
+	}
+}
+
+// addAnomalies sets the number of anomalous metrics of the latest run checked of the workloads, for the listings
+func (c *Config) addAnomalies(workloads []Workload) {
+	for i, workload := range workloads {
+		if runs := c.anomalies.workload(workload.Job, workload.Name); len(runs) > 0 {
+			workloads[i].Anomalies = runs[len(runs)-1].Count()
+		}
+	}
+}
+
+func (c *Config) apiAnomaliesHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	runs := c.anomalies.workload(jobName, workloadName)
+	if r.URL.Query().Get("anomalous") == "true" {
+		runs = slices.DeleteFunc(runs, func(ra RunAnomalies) bool { return ra.Count() == 0 })
+	}
+	writeJSON(w, append([]RunAnomalies{}, runs...))
+}
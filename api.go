@@ -0,0 +1,179 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+)
+
+// apiRoutes returns the mux serving the JSON API under /api/v1/
+func (c *Config) apiRoutes() *http.ServeMux {
+	mux := http.NewServeMux()
+	// Job, workload and run path values are checked before any handler joins them to the results directory
+	handle := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, c.checkPathNames(handler))
+	}
+	handle("GET /api/v1/jobs", c.apiJobsHandler)
+	handle("GET /api/v1/matrix", c.apiMatrixHandler)
+	handle("GET /api/v1/metrics-docs", c.apiMetricDocsHandler)
+	handle("GET /api/v1/diagnostics", c.apiDiagnosticsHandler)
+	handle("GET /api/v1/stats", c.apiStatsHandler)
+	handle("GET /api/v1/runs/{uuid}", c.apiRunHandler)
+	handle("POST /api/v1/runs/{uuid}/promote", c.apiPromoteHandler)
+	handle("PUT /api/v1/runs/{uuid}/alias", c.apiSetAliasHandler)
+	handle("DELETE /api/v1/runs/{uuid}/alias", c.apiDeleteAliasHandler)
+	handle("GET /api/v1/aliases", c.apiAliasesHandler)
+	handle("GET /api/v1/slos", c.apiSLOsHandler)
+	handle("GET /api/v1/sql", c.apiSQLHandler)
+	handle("GET /api/v1/views", c.apiViewsHandler)
+	handle("GET /api/v1/views/{name}", c.apiViewHandler)
+	handle("GET /api/v1/jobs/{job}/workloads", c.apiWorkloadsHandler)
+	handle("GET /api/v1/jobs/{job}/metrics/{metric}", c.apiTrendsHandler)
+	handle("GET /api/v1/jobs/{job}/scalability", c.apiScalabilityHandler)
+	handle("GET /api/v1/jobs/{job}/wins", c.apiWinsHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/charts", c.apiChartsHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/overlay", c.apiOverlayHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/distributions", c.apiDistributionsHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/boxplot", c.apiBoxPlotHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/heatmap", c.apiHeatmapHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/correlations", c.apiCorrelationsHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/compare", c.apiCompareHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/baseline", c.apiBaselineHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/bisect", c.apiBisectHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/timeline", c.apiTimelineHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/logs", c.apiLogsHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/cost", c.apiCostHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/slos", c.apiWorkloadSLOsHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/latest", c.apiLatestHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/anomalies", c.apiAnomaliesHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/findings", c.apiFindingsHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/runs/{run}/raw", c.apiRawHandler)
+	handle("GET /api/v1/jobs/{job}/workloads/{workload}/export", c.apiExportHandler)
+	return mux
+}
+
+func (c *Config) apiJobsHandler(w http.ResponseWriter, r *http.Request) {
+	jobs, err := c.visibleJobs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !showRetired(r) {
+		jobs = activeJobs(jobs)
+	}
+	writeJSON(w, jobs)
+}
+
+func (c *Config) apiWorkloadsHandler(w http.ResponseWriter, r *http.Request) {
+	jobName := r.PathValue("job")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	workloads, err := loadWorkloads(filepath.Join(c.resultsDir, jobName), jobName)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("job %s not found", jobName), http.StatusNotFound)
+		return
+	}
+	if !showRetired(r) {
+		workloads = activeWorkloads(workloads, "")
+	}
+	c.addStability(workloads)
+	c.addAnomalies(workloads)
+	addStaleness(loadDescriptor(filepath.Join(c.resultsDir, jobName)), workloads)
+	writeJSON(w, workloads)
+}
+
+// RunLocation is a run found by its UUID, along with the job and workload holding it
+type RunLocation struct {
+	Job      string
+	Workload string
+	Run      Run
+	// Alias is the human name of the run, if any
+	Alias string `json:",omitempty"`
+	// Links of the run into related systems
+	Links []RunLink `json:",omitempty"`
+}
+
+// findRun looks the run of the UUID up in every workload of the jobs, retired ones included
+func findRun(jobs []Job, uuid string) (RunLocation, bool) {
+	for _, job := range jobs {
+		for _, workload := range job.Workloads {
+			runs, err := loadRuns(workload.Path)
+			if err != nil {
+				continue
+			}
+			for _, run := range runs {
+				if run.UUID() == uuid {
+					return RunLocation{Job: job.Name, Workload: workload.Name, Run: run, Links: run.Links()}, true
+				}
+			}
+		}
+	}
+	return RunLocation{}, false
+}
+
+func (c *Config) apiRunHandler(w http.ResponseWriter, r *http.Request) {
+	jobs, err := c.visibleJobs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	uuid := r.PathValue("uuid")
+	location, ok := findRun(jobs, uuid)
+	if !ok {
+		http.Error(w, fmt.Sprintf("run %s not found", uuid), http.StatusNotFound)
+		return
+	}
+	location.Alias = c.aliases.get(uuid)
+	writeJSON(w, location)
+}
+
+func (c *Config) apiChartsHandler(w http.ResponseWriter, r *http.Request) {
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Workload charts split the kube-burner jobs unless told otherwise, the other views look their groups up by metric
+	opts.GroupByJob = opts.GroupByJob || r.URL.Query().Get("group") == ""
+	job := Job{
+		Name: r.PathValue("job"),
+	}
+	if !c.jobAllowed(r, job.Name) {
+		jobForbidden(w, job.Name)
+		return
+	}
+	workloadName := r.PathValue("workload")
+	runs, err := loadRuns(filepath.Join(c.resultsDir, job.Name, workloadName))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("workload %s/%s not found", job.Name, workloadName), http.StatusNotFound)
+		return
+	}
+	job.Runs = runs
+	metricGroups := prepareChartData(&job, opts)
+	if wantsNDJSON(r) {
+		// A line per datapoint, labeled with its metric and quantile
+		enc := newNDJSONEncoder(w)
+		for _, group := range metricGroups {
+			for _, chart := range group.Charts {
+				for _, dp := range chart.Datapoints {
+					labels := map[string]string{"MetricName": group.MetricName, "JobName": group.JobName, "QuantileName": chart.QuantileName}
+					if err := enc.encodeDatapoint(labels, dp); err != nil {
+						return
+					}
+				}
+			}
+		}
+		return
+	}
+	writeJSON(w, metricGroups)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		fmt.Println("Error encoding JSON response:", err)
+	}
+}
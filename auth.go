@@ -0,0 +1,168 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// authCookie holds the session of the users logged in through LDAP
+const authCookie = "auth"
+
+// Roles granted to the users authenticated by the dashboard, by their groups
+const (
+	// roleAdmin has full access, including the admin page
+	roleAdmin = "admin"
+	// roleReadOnly can browse the results, but can't save anything
+	roleReadOnly = "read-only"
+	// roleUpload can only send data to the API, granted to upload API tokens
+	roleUpload = "upload"
+)
+
+// Roles map the groups of the users to their role, users in none of them are denied access. A "*" read-only group
+// grants read-only access to every user
+type Roles struct {
+	Admin    []string `yaml:"admin"`
+	ReadOnly []string `yaml:"readOnly"`
+}
+
+// role returns the role granted by the groups, the admin one winning, empty when none is
+func (roles Roles) role(groups []string) string {
+	member := func(roleGroups []string) bool {
+		return slices.ContainsFunc(groups, func(group string) bool { return slices.Contains(roleGroups, group) })
+	}
+	switch {
+	case member(roles.Admin):
+		return roleAdmin
+	case member(roles.ReadOnly) || slices.Contains(roles.ReadOnly, "*"):
+		return roleReadOnly
+	}
+	return ""
+}
+
+// Session is the identity and role of a user authenticated by the dashboard, signed in the auth cookie of the users
+// logged in through LDAP
+type Session struct {
+	User    string   `json:"u"`
+	Groups  []string `json:"g,omitempty"`
+	Role    string   `json:"r"`
+	Expires int64    `json:"e"`
+}
+
+// sessionContextKey holds the session of the requests of the users authenticated by the dashboard
+type sessionContextKey struct{}
+
+// requestSession returns the session of the user authenticated by the dashboard, if any
+func requestSession(r *http.Request) (Session, bool) {
+	session, ok := r.Context().Value(sessionContextKey{}).(Session)
+	return session, ok
+}
+
+// sessionKey derives the key signing the auth cookies from the share key, so share links and sessions can't be
+// swapped for one another
+func (c *Config) sessionKey() []byte {
+	mac := hmac.New(sha256.New, c.shareKey)
+	mac.Write([]byte("ldap-session"))
+	return mac.Sum(nil)
+}
+
+// signSession returns the value of the auth cookie of the session
+func (c *Config) signSession(session Session) string {
+	payload, _ := json.Marshal(session)
+	mac := hmac.New(sha256.New, c.sessionKey())
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifySession returns the session of the auth cookie, failing when its signature is invalid or it expired
+func (c *Config) verifySession(value string) (Session, bool) {
+	var session Session
+	encodedPayload, encodedSignature, _ := strings.Cut(value, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return session, false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return session, false
+	}
+	mac := hmac.New(sha256.New, c.sessionKey())
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) || json.Unmarshal(payload, &session) != nil {
+		return session, false
+	}
+	return session, time.Now().Unix() <= session.Expires
+}
+
+// authMiddleware authenticates the clients presenting an API token or a client certificate, and requires the other
+// ones to log in when LDAP is configured. It sets the identity headers of the policy from their session, discarding
+// the ones sent by the clients, and limits read-only users and upload tokens to the requests they're allowed. Other
+// clients are left to the authenticating proxy without LDAP, and it's a no-op without a policy
+func (c *Config) authMiddleware(next http.Handler) http.Handler {
+	if c.policy == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		session, ok, err := c.tokenSession(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !ok {
+			session, ok = c.clientCertSession(r)
+		}
+		if !ok && c.policy.LDAP == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		r.Header.Del(c.policy.UserHeader)
+		r.Header.Del(c.policy.GroupsHeader)
+		if !ok {
+			switch {
+			case r.URL.Path == "/login", r.URL.Path == "/logout", strings.HasPrefix(r.URL.Path, "/static/"),
+				r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/share/"), strings.HasPrefix(r.URL.Path, "/slack/"):
+				next.ServeHTTP(w, r)
+				return
+			}
+			if session, ok = c.ldapSession(r); !ok {
+				if strings.HasPrefix(r.URL.Path, "/api/") || r.Method != http.MethodGet {
+					w.Header().Set("WWW-Authenticate", `Basic realm="ocp-perf-dash"`)
+					http.Error(w, "authentication required", http.StatusUnauthorized)
+					return
+				}
+				http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+				return
+			}
+		}
+		switch {
+		case session.Role == "":
+			http.Error(w, "access denied to "+session.User, http.StatusForbidden)
+			return
+		case session.Role == roleReadOnly && !readOnlyRoleAllowed(r):
+			http.Error(w, "read-only access", http.StatusForbidden)
+			return
+		case session.Role == roleUpload && !uploadAllowed(r):
+			http.Error(w, "upload-only access", http.StatusForbidden)
+			return
+		}
+		r.Header.Set(c.policy.UserHeader, session.User)
+		if len(session.Groups) > 0 {
+			r.Header.Set(c.policy.GroupsHeader, strings.Join(session.Groups, ","))
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionContextKey{}, session)))
+	})
+}
+
+// readOnlyRoleAllowed reports whether the request is allowed to read-only users and tokens: browsing, and logging in
+// and out. Unlike read-only mode, which only protects the results, it denies saving pins, preferences, views, share
+// links and aliases too
+func readOnlyRoleAllowed(r *http.Request) bool {
+	return safeMethod(r) || r.URL.Path == "/login" || r.URL.Path == "/logout"
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// backupManifestFile is the first entry of the backup archives, listing every file of the results tree at the time
+// of the backup
+const backupManifestFile = "backup-manifest.json"
+
+// BackupManifest lists the files of the results tree when the backup was taken. Incremental backups only archive
+// the files added or modified since the backup they're based on, but still list every file, so restores know which
+// ones were deleted
+type BackupManifest struct {
+	CreatedAt time.Time `json:"createdAt"`
+	// Base is the creation time of the backup the archive is incremental to, nil for full backups
+	Base  *time.Time   `json:"base,omitempty"`
+	Files []BackupFile `json:"files"`
+}
+
+type BackupFile struct {
+	// Path is the slash separated path of the file, relative to the results root
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	SHA256  string    `json:"sha256"`
+	// Archived is false for the files of incremental backups left unchanged since their base
+	Archived bool `json:"archived"`
+}
+
+// newBackupManifest lists and hashes the files of the results tree, only archiving the ones that changed since the
+// given base manifest, when there's one
+func newBackupManifest(root string, base *BackupManifest) (BackupManifest, error) {
+	manifest := BackupManifest{CreatedAt: time.Now().UTC()}
+	previous := make(map[string]BackupFile)
+	if base != nil {
+		manifest.Base = &base.CreatedAt
+		for _, f := range base.Files {
+			previous[f.Path] = f
+		}
+	}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		sum, err := fileSHA256(p)
+		if err != nil {
+			return err
+		}
+		f := BackupFile{Path: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime().UTC(), SHA256: sum, Archived: true}
+		if prev, ok := previous[f.Path]; ok && prev.SHA256 == f.SHA256 {
+			f.Archived = false
+		}
+		manifest.Files = append(manifest.Files, f)
+		return nil
+	})
+	return manifest, err
+}
+
+func fileSHA256(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// writeBackup writes the gzipped tar archive of the manifest followed by the files it archives
+func writeBackup(w io.Writer, root string, manifest BackupManifest) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: backupManifestFile, Mode: 0o644, Size: int64(len(data)), ModTime: manifest.CreatedAt}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(data); err != nil {
+		return err
+	}
+	for _, f := range manifest.Files {
+		if !f.Archived {
+			continue
+		}
+		if err := archiveFile(tw, root, f); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func archiveFile(tw *tar.Writer, root string, f BackupFile) error {
+	file, err := os.Open(filepath.Join(root, filepath.FromSlash(f.Path)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := tw.WriteHeader(&tar.Header{Name: path.Join("results", f.Path), Mode: 0o644, Size: f.Size, ModTime: f.ModTime}); err != nil {
+		return err
+	}
+	// Files growing while they're archived are cut at the size they were hashed at
+	if _, err := io.CopyN(tw, file, f.Size); err != nil {
+		return fmt.Errorf("error archiving %s: %v", f.Path, err)
+	}
+	return nil
+}
+
+// openBackup opens the backup archive and reads its manifest, the archive reader is positioned at its first file
+func openBackup(name string) (*BackupManifest, *tar.Reader, io.Closer, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, fmt.Errorf("error reading backup %s: %v", name, err)
+	}
+	tr := tar.NewReader(gz)
+	hdr, err := tr.Next()
+	if err == nil && hdr.Name != backupManifestFile {
+		err = fmt.Errorf("%s isn't its first entry", backupManifestFile)
+	}
+	var manifest BackupManifest
+	if err == nil {
+		err = json.NewDecoder(tr).Decode(&manifest)
+	}
+	for _, f := range manifest.Files {
+		// Paths are joined to the restore target, they must stay within it
+		if err == nil && !fs.ValidPath(f.Path) {
+			err = fmt.Errorf("invalid path %s", f.Path)
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, nil, fmt.Errorf("error reading the manifest of backup %s: %v", name, err)
+	}
+	return &manifest, tr, file, nil
+}
+
+// restoreBackup extracts the files of the archive to the target directory, checking them against the manifest.
+// Files of the previous manifest missing from this one were deleted since, and are removed
+func restoreBackup(tr *tar.Reader, target string, manifest, previous *BackupManifest) (int, error) {
+	files := make(map[string]BackupFile, len(manifest.Files))
+	for _, f := range manifest.Files {
+		files[f.Path] = f
+	}
+	restored := 0
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return restored, err
+		}
+		rel, ok := strings.CutPrefix(hdr.Name, "results/")
+		f, listed := files[rel]
+		if !ok || !listed || !f.Archived {
+			return restored, fmt.Errorf("unexpected entry %s", hdr.Name)
+		}
+		if err := extractFile(tr, target, f); err != nil {
+			return restored, err
+		}
+		restored++
+	}
+	// Unchanged files come from the previous archives
+	for _, f := range manifest.Files {
+		if f.Archived {
+			continue
+		}
+		if sum, err := fileSHA256(filepath.Join(target, filepath.FromSlash(f.Path))); err != nil || sum != f.SHA256 {
+			return restored, fmt.Errorf("%s is missing from the restored backups, or doesn't match its checksum", f.Path)
+		}
+	}
+	if previous != nil {
+		for _, f := range previous.Files {
+			if _, ok := files[f.Path]; ok {
+				continue
+			}
+			if err := os.Remove(filepath.Join(target, filepath.FromSlash(f.Path))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return restored, err
+			}
+		}
+	}
+	return restored, nil
+}
+
+// extractFile writes the file to the target directory, through a temporary file renamed once it matches its checksum
+func extractFile(r io.Reader, target string, f BackupFile) error {
+	dest := filepath.Join(target, filepath.FromSlash(f.Path))
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".restore-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	_, err = io.Copy(io.MultiWriter(tmp, h), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(h.Sum(nil)) != f.SHA256 {
+		return fmt.Errorf("%s doesn't match its checksum", f.Path)
+	}
+	if err := os.Chtimes(tmp.Name(), f.ModTime, f.ModTime); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// backupCommand implements the backup subcommand, which archives the local results tree, or the files changed since
+// a previous backup with -incremental
+func backupCommand(args []string) int {
+	flags := flag.NewFlagSet("backup", flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path to the directory holding results")
+	output := flags.String("output", "", "File to write the gzipped tar archive to, such as results-20250101.tar.gz")
+	incremental := flags.String("incremental", "", "Previous backup the archive is incremental to, only the files added or modified since are archived")
+	flags.Parse(args)
+	if *output == "" {
+		fmt.Fprintln(os.Stderr, "-output is required")
+		return 2
+	}
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !localResults() {
+		fmt.Fprintln(os.Stderr, errRemoteResults)
+		return 1
+	}
+	var base *BackupManifest
+	if *incremental != "" {
+		var closer io.Closer
+		base, _, closer, err = openBackup(*incremental)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		closer.Close()
+	}
+	manifest, err := newBackupManifest(root, base)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error listing results:", err)
+		return 1
+	}
+	// Write to a temporary file first, so an interrupted backup doesn't leave a truncated archive behind
+	tmp := *output + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	err = writeBackup(f, root, manifest)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, *output)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		fmt.Fprintln(os.Stderr, "Error writing backup:", err)
+		return 1
+	}
+	archived := slices.DeleteFunc(slices.Clone(manifest.Files), func(f BackupFile) bool { return !f.Archived })
+	fmt.Printf("Archived %d of the %d files of %s to %s\n", len(archived), len(manifest.Files), root, *output)
+	return 0
+}
+
+// restoreCommand implements the restore subcommand, which extracts a full backup and the incremental backups taken
+// after it, in order, to the target directory
+func restoreCommand(args []string) int {
+	flags := flag.NewFlagSet("restore", flag.ExitOnError)
+	target := flags.String("target", "results", "Directory to restore the results to")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ocp-perf-dash restore [-target dir] full.tar.gz [incremental.tar.gz...]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+	var previous *BackupManifest
+	for i, name := range flags.Args() {
+		manifest, tr, closer, err := openBackup(name)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		switch {
+		case i == 0 && manifest.Base != nil:
+			err = fmt.Errorf("%s is an incremental backup, the full backup it's based on must be restored first", name)
+		case i > 0 && (manifest.Base == nil || !manifest.Base.Equal(previous.CreatedAt)):
+			err = fmt.Errorf("%s isn't incremental to %s", name, flags.Arg(i-1))
+		}
+		if err == nil {
+			var restored int
+			restored, err = restoreBackup(tr, *target, manifest, previous)
+			if err == nil {
+				fmt.Printf("Restored %d files from %s\n", restored, name)
+			}
+		}
+		closer.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error restoring %s: %v\n", name, err)
+			return 1
+		}
+		previous = manifest
+	}
+	return 0
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// baselineFile is the name of the baseline snapshot within a workload directory, it isn't mistaken for a run as
+// runs are directories
+const baselineFile = "baseline.json"
+
+// recordedBaseline selects the baseline snapshot of the workload instead of a group of runs
+const recordedBaseline = "recorded"
+
+// BaselineSnapshot is the aggregated baseline of a workload, recorded from a group of runs so comparisons don't
+// depend on any single run directory
+type BaselineSnapshot struct {
+	Job       string
+	Workload  string
+	CreatedAt time.Time
+	Runs      []string
+	// Values holds the value of every percentile in every baseline run, keyed by metric and quantile name
+	Values map[string]map[string][]float64
+}
+
+func newBaselineSnapshot(jobName, workloadName string, runs []Run) BaselineSnapshot {
+	snapshot := BaselineSnapshot{
+		Job:       jobName,
+		Workload:  workloadName,
+		CreatedAt: time.Now().UTC(),
+		Values:    make(map[string]map[string][]float64),
+	}
+	for _, run := range runs {
+		snapshot.Runs = append(snapshot.Runs, run.Name())
+	}
+	for _, p := range percentiles {
+		for key, values := range quantileValues(runs, p) {
+			if snapshot.Values[key] == nil {
+				snapshot.Values[key] = make(map[string][]float64)
+			}
+			snapshot.Values[key][p] = values
+		}
+	}
+	return snapshot
+}
+
+// values returns the values of the percentile in every baseline run, keyed by metric and quantile name
+func (b *BaselineSnapshot) values(percentile string) map[string][]float64 {
+	values := make(map[string][]float64, len(b.Values))
+	for key, byPercentile := range b.Values {
+		if v, ok := byPercentile[percentile]; ok {
+			values[key] = v
+		}
+	}
+	return values
+}
+
+// loadBaseline returns the baseline snapshot of the workload, nil when none has been recorded
+func loadBaseline(workloadPath string) (*BaselineSnapshot, error) {
+	data, err := storage.ReadFile(filepath.Join(workloadPath, baselineFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var snapshot BaselineSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("error parsing baseline %s: %v", filepath.Join(workloadPath, baselineFile), err)
+	}
+	return &snapshot, nil
+}
+
+func saveBaseline(workloadPath string, snapshot BaselineSnapshot) error {
+	if !localResults() {
+		return errRemoteResults
+	}
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so readers never see a partial snapshot
+	tmp := filepath.Join(workloadPath, "."+baselineFile)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(workloadPath, baselineFile))
+}
+
+func (c *Config) apiBaselineHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	snapshot, err := loadBaseline(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if snapshot == nil {
+		http.Error(w, fmt.Sprintf("no baseline recorded for %s/%s", jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, snapshot)
+}
+
+// baselineCommand implements the baseline subcommand, which records, shows and removes baseline snapshots
+func baselineCommand(args []string) int {
+	if len(args) == 0 || !slices.Contains([]string{"set", "show", "unset"}, args[0]) {
+		fmt.Fprintln(os.Stderr, "Usage: ocp-perf-dash baseline set|show|unset -job <job> -workload <workload> [flags]")
+		return 2
+	}
+	action := args[0]
+	flags := flag.NewFlagSet("baseline "+action, flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path or URL of the directory holding results")
+	jobName := flags.String("job", "", "Job of the baseline")
+	workloadName := flags.String("workload", "", "Workload of the baseline")
+	selector := flags.String("runs", defaultBaselineSelector, "Runs recorded in the baseline, last:N or a comma separated list of run directories")
+	flags.Parse(args[1:])
+	if *jobName == "" || *workloadName == "" {
+		fmt.Fprintln(os.Stderr, "Both -job and -workload are required")
+		return 2
+	}
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	workloadPath := filepath.Join(root, *jobName, *workloadName)
+	loadLog = os.Stderr
+	switch action {
+	case "set":
+		runs, err := loadRuns(workloadPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading runs:", err)
+			return 1
+		}
+		selected, err := selectRuns(ChartOptions{}.filterRuns(runs), *selector)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(selected) == 0 {
+			fmt.Fprintln(os.Stderr, "No runs to record in the baseline")
+			return 1
+		}
+		if err := saveBaseline(workloadPath, newBaselineSnapshot(*jobName, *workloadName, selected)); err != nil {
+			fmt.Fprintln(os.Stderr, "Error saving baseline:", err)
+			return 1
+		}
+		fmt.Printf("Recorded baseline of %s/%s from %d runs\n", *jobName, *workloadName, len(selected))
+	case "show":
+		snapshot, err := loadBaseline(workloadPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if snapshot == nil {
+			fmt.Fprintf(os.Stderr, "No baseline recorded for %s/%s\n", *jobName, *workloadName)
+			return 1
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(snapshot); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	case "unset":
+		if !localResults() {
+			fmt.Fprintln(os.Stderr, "Error removing baseline:", errRemoteResults)
+			return 1
+		}
+		if err := os.Remove(filepath.Join(workloadPath, baselineFile)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			fmt.Fprintln(os.Stderr, "Error removing baseline:", err)
+			return 1
+		}
+		fmt.Printf("Removed baseline of %s/%s\n", *jobName, *workloadName)
+	}
+	return 0
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/iterator"
+)
+
+// bigquerySchema is the schema of the BigQuery tables the measurements are appended to, the columns of the exports.
+// Columns are only ever added, and added to the existing tables as well
+var bigquerySchema = bigquery.Schema{
+	{Name: "job", Type: bigquery.StringFieldType, Required: true},
+	{Name: "workload", Type: bigquery.StringFieldType, Required: true},
+	{Name: "run", Type: bigquery.StringFieldType, Required: true},
+	{Name: "uuid", Type: bigquery.StringFieldType},
+	{Name: "seq", Type: bigquery.IntegerFieldType},
+	{Name: "timestamp", Type: bigquery.TimestampFieldType},
+	{Name: "phase", Type: bigquery.StringFieldType, Description: "kube-burner job the measurement was taken in"},
+	{Name: "metric", Type: bigquery.StringFieldType},
+	{Name: "quantile", Type: bigquery.StringFieldType},
+	{Name: "p99", Type: bigquery.FloatFieldType},
+	{Name: "p95", Type: bigquery.FloatFieldType},
+	{Name: "p50", Type: bigquery.FloatFieldType},
+	{Name: "min", Type: bigquery.FloatFieldType},
+	{Name: "max", Type: bigquery.FloatFieldType},
+	{Name: "avg", Type: bigquery.FloatFieldType},
+	{Name: "passed", Type: bigquery.BooleanFieldType},
+	{Name: "metadata", Type: bigquery.StringFieldType, Description: "Metadata fields of the run as a JSON object"},
+}
+
+// exportBigQuery appends the rows to the BigQuery table, given as dataset.table or project.dataset.table, creating
+// it or adding the missing columns first. Rows of the runs already in the table are skipped, so the export can be
+// run again as runs are added. It returns the number of rows appended
+func exportBigQuery(ctx context.Context, project, target string, rows []MeasurementRow) (int, error) {
+	parts := strings.Split(target, ".")
+	switch len(parts) {
+	case 2:
+		if project == "" {
+			project = bigquery.DetectProjectID
+		}
+	case 3:
+		project, parts = parts[0], parts[1:]
+	default:
+		return 0, fmt.Errorf("invalid BigQuery table %s, must be dataset.table or project.dataset.table", target)
+	}
+	client, err := bigquery.NewClient(ctx, project)
+	if err != nil {
+		return 0, fmt.Errorf("error creating BigQuery client: %v", err)
+	}
+	defer client.Close()
+	table := client.Dataset(parts[0]).Table(parts[1])
+	created, err := ensureBigQueryTable(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+	if !created {
+		existing, err := bigqueryRuns(ctx, client, table)
+		if err != nil {
+			return 0, err
+		}
+		rows = slices.DeleteFunc(rows, func(row MeasurementRow) bool {
+			return existing[row.Job+"/"+row.Workload+"/"+row.Run]
+		})
+	}
+	if len(rows) == 0 {
+		return 0, nil
+	}
+	for i := range rows {
+		// TIMESTAMP columns are precise to the microsecond
+		rows[i].Timestamp = rows[i].Timestamp.Truncate(time.Microsecond).UTC()
+	}
+	var data bytes.Buffer
+	if err := writeExport(&data, rows, exportFormatNDJSON, defaultLocale); err != nil {
+		return 0, err
+	}
+	source := bigquery.NewReaderSource(&data)
+	source.SourceFormat = bigquery.JSON
+	loader := table.LoaderFrom(source)
+	loader.WriteDisposition = bigquery.WriteAppend
+	job, err := loader.Run(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("error loading rows into %s: %v", table.FullyQualifiedName(), err)
+	}
+	status, err := job.Wait(ctx)
+	if err == nil {
+		err = status.Err()
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error loading rows into %s: %v", table.FullyQualifiedName(), err)
+	}
+	return len(rows), nil
+}
+
+// ensureBigQueryTable creates the table, partitioned by day of the measurements, or adds the columns it misses. It
+// reports whether the table was created
+func ensureBigQueryTable(ctx context.Context, table *bigquery.Table) (bool, error) {
+	meta, err := table.Metadata(ctx)
+	if e := (*googleapi.Error)(nil); errors.As(err, &e) && e.Code == http.StatusNotFound {
+		err = table.Create(ctx, &bigquery.TableMetadata{
+			Schema:           bigquerySchema,
+			TimePartitioning: &bigquery.TimePartitioning{Field: "timestamp"},
+			Clustering:       &bigquery.Clustering{Fields: []string{"job", "workload", "metric", "quantile"}},
+		})
+		if err != nil {
+			return false, fmt.Errorf("error creating table %s: %v", table.FullyQualifiedName(), err)
+		}
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error reading table %s: %v", table.FullyQualifiedName(), err)
+	}
+	schema := slices.Clone(meta.Schema)
+	for _, field := range bigquerySchema {
+		i := slices.IndexFunc(meta.Schema, func(f *bigquery.FieldSchema) bool { return f.Name == field.Name })
+		if i < 0 {
+			// Columns added to existing tables must be nullable
+			added := *field
+			added.Required = false
+			schema = append(schema, &added)
+			continue
+		}
+		if meta.Schema[i].Type != field.Type {
+			return false, fmt.Errorf("column %s of table %s is %s, expected %s", field.Name, table.FullyQualifiedName(), meta.Schema[i].Type, field.Type)
+		}
+	}
+	if len(schema) == len(meta.Schema) {
+		return false, nil
+	}
+	if _, err := table.Update(ctx, bigquery.TableMetadataToUpdate{Schema: schema}, meta.ETag); err != nil {
+		return false, fmt.Errorf("error adding columns to table %s: %v", table.FullyQualifiedName(), err)
+	}
+	return false, nil
+}
+
+// bigqueryRuns returns the runs already in the table, keyed by job, workload and run directory
+func bigqueryRuns(ctx context.Context, client *bigquery.Client, table *bigquery.Table) (map[string]bool, error) {
+	q := client.Query(fmt.Sprintf("SELECT DISTINCT job, workload, run FROM `%s.%s.%s`", table.ProjectID, table.DatasetID, table.TableID))
+	it, err := q.Read(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error listing the runs of %s: %v", table.FullyQualifiedName(), err)
+	}
+	runs := make(map[string]bool)
+	for {
+		var row []bigquery.Value
+		err := it.Next(&row)
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error listing the runs of %s: %v", table.FullyQualifiedName(), err)
+		}
+		runs[fmt.Sprintf("%v/%v/%v", row[0], row[1], row[2])] = true
+	}
+	return runs, nil
+}
@@ -0,0 +1,378 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultBisectThreshold   = 10
+	defaultBisectConsecutive = 2
+	// Without a recorded baseline, the median of the oldest runs is the reference
+	bisectReferenceRuns = 3
+)
+
+// bisectOptions holds the parameters of a search for the first regressing run
+type bisectOptions struct {
+	MetricName   string
+	QuantileName string
+	Percentile   string
+	// Threshold is the percent increase over the reference considered a regression
+	Threshold float64
+	// Consecutive is the number of runs in a row that must exceed the threshold, so isolated spikes are skipped
+	Consecutive int
+}
+
+// BisectResult reports the first run where a regression appears, along with the last run before it
+type BisectResult struct {
+	MetricName   string
+	QuantileName string
+	Percentile   string
+	Threshold    float64
+	Consecutive  int
+	Reference    float64
+	// ReferenceSource describes where the reference value comes from
+	ReferenceSource string
+	// Checked is the number of runs compared against the reference
+	Checked int
+	// Sustained is the number of latest runs in a row over the threshold, only set by sustainedRegression
+	Sustained      int `json:",omitempty"`
+	LastGood       *BisectRun
+	FirstRegressed *BisectRun
+}
+
+type BisectRun struct {
+	Name      string
+	Seq       int
+	UUID      string
+	Timestamp time.Time
+	Value     float64
+	// Delta is the percent change over the reference
+	Delta    float64
+	Metadata map[string]any
+}
+
+// measurementValue returns the value of the percentile of a metric quantile in the run
+func measurementValue(run Run, metricName, quantileName, percentile string) (float64, bool) {
+	for _, m := range run.Measurements {
+		if m.MetricName == metricName && m.QuantileName == quantileName {
+			dp := DataPoint{P99: m.P99, P95: m.P95, P50: m.P50, Min: m.Min, Max: m.Max, Avg: m.Avg}
+			return dp.value(percentile), true
+		}
+	}
+	return 0, false
+}
+
+// bisect walks the runs chronologically and returns the first one where the metric exceeds the reference by more
+// than the threshold, for the given number of consecutive runs
+func bisect(runs []Run, snapshot *BaselineSnapshot, opts bisectOptions) (BisectResult, error) {
+	result, candidates, err := bisectCandidates(runs, snapshot, opts)
+	if err != nil {
+		return result, err
+	}
+	limit := result.Reference * (1 + opts.Threshold/100)
+	streak := 0
+	for i, run := range candidates {
+		v, _ := measurementValue(run, opts.MetricName, opts.QuantileName, opts.Percentile)
+		if v <= limit {
+			streak = 0
+			result.LastGood = newBisectRun(run, opts, result.Reference)
+			continue
+		}
+		streak++
+		if streak == opts.Consecutive {
+			result.FirstRegressed = newBisectRun(candidates[i-streak+1], opts, result.Reference)
+			return result, nil
+		}
+	}
+	return result, nil
+}
+
+// sustainedRegression returns the run the ongoing regression started at when the latest runs exceed the reference
+// by more than the threshold, for at least the given number of consecutive runs. Regressions that recovered are
+// ignored
+func sustainedRegression(runs []Run, snapshot *BaselineSnapshot, opts bisectOptions) (BisectResult, error) {
+	result, candidates, err := bisectCandidates(runs, snapshot, opts)
+	if err != nil {
+		return result, err
+	}
+	limit := result.Reference * (1 + opts.Threshold/100)
+	streak := 0
+	for i := len(candidates) - 1; i >= 0; i-- {
+		if v, _ := measurementValue(candidates[i], opts.MetricName, opts.QuantileName, opts.Percentile); v <= limit {
+			break
+		}
+		streak++
+	}
+	result.Sustained = streak
+	if streak < opts.Consecutive {
+		return result, nil
+	}
+	first := len(candidates) - streak
+	result.FirstRegressed = newBisectRun(candidates[first], opts, result.Reference)
+	if first > 0 {
+		result.LastGood = newBisectRun(candidates[first-1], opts, result.Reference)
+	}
+	return result, nil
+}
+
+// bisectCandidates returns the runs holding the metric checked against the reference. The reference is the mean of
+// the recorded baseline when the workload has one, the median of the oldest runs otherwise
+func bisectCandidates(runs []Run, snapshot *BaselineSnapshot, opts bisectOptions) (BisectResult, []Run, error) {
+	result := BisectResult{
+		MetricName:   opts.MetricName,
+		QuantileName: opts.QuantileName,
+		Percentile:   opts.Percentile,
+		Threshold:    opts.Threshold,
+		Consecutive:  opts.Consecutive,
+	}
+	runs = ChartOptions{}.filterRuns(runs)
+	var candidates []Run
+	for _, run := range runs {
+		if _, ok := measurementValue(run, opts.MetricName, opts.QuantileName, opts.Percentile); ok {
+			candidates = append(candidates, run)
+		}
+	}
+	key := opts.MetricName + "/" + opts.QuantileName
+	if snapshot != nil && len(snapshot.values(opts.Percentile)[key]) > 0 {
+		result.Reference = mean(snapshot.values(opts.Percentile)[key])
+		result.ReferenceSource = fmt.Sprintf("recorded baseline of %d runs", len(snapshot.Runs))
+		candidates = slices.DeleteFunc(candidates, func(r Run) bool { return slices.Contains(snapshot.Runs, r.Name()) })
+	} else {
+		if len(candidates) <= bisectReferenceRuns {
+			return result, nil, fmt.Errorf("%s %s: at least %d runs are needed, found %d", opts.MetricName, opts.QuantileName, bisectReferenceRuns+1, len(candidates))
+		}
+		var values []float64
+		for _, run := range candidates[:bisectReferenceRuns] {
+			v, _ := measurementValue(run, opts.MetricName, opts.QuantileName, opts.Percentile)
+			values = append(values, v)
+		}
+		result.Reference = quantile(sortedCopy(values), 0.5)
+		result.ReferenceSource = fmt.Sprintf("median of the %d oldest runs", bisectReferenceRuns)
+		result.LastGood = newBisectRun(candidates[bisectReferenceRuns-1], opts, result.Reference)
+		candidates = candidates[bisectReferenceRuns:]
+	}
+	if result.Reference == 0 {
+		return result, nil, fmt.Errorf("%s %s: the reference value is zero", opts.MetricName, opts.QuantileName)
+	}
+	result.Checked = len(candidates)
+	return result, candidates, nil
+}
+
+func newBisectRun(run Run, opts bisectOptions, reference float64) *BisectRun {
+	v, _ := measurementValue(run, opts.MetricName, opts.QuantileName, opts.Percentile)
+	return &BisectRun{
+		Name:      run.Name(),
+		Seq:       run.Seq,
+		UUID:      run.Summary.UUID,
+		Timestamp: run.Started(),
+		Value:     v,
+		Delta:     (v - reference) / reference * 100,
+		Metadata:  run.Metadata(),
+	}
+}
+
+func bisectOptionsFromRequest(r *http.Request) (bisectOptions, error) {
+	opts := bisectOptions{
+		MetricName:   r.URL.Query().Get("metric"),
+		QuantileName: r.URL.Query().Get("quantile"),
+		Percentile:   "P99",
+		Threshold:    defaultBisectThreshold,
+		Consecutive:  defaultBisectConsecutive,
+	}
+	if opts.MetricName == "" {
+		opts.MetricName = defaultOverlayMetric
+	}
+	if opts.QuantileName == "" {
+		opts.QuantileName = "Ready"
+	}
+	var err error
+	if p := r.URL.Query().Get("percentile"); p != "" {
+		if opts.Percentile, err = parsePercentile(p); err != nil {
+			return opts, err
+		}
+	}
+	if t := r.URL.Query().Get("threshold"); t != "" {
+		if opts.Threshold, err = strconv.ParseFloat(t, 64); err != nil || opts.Threshold <= 0 {
+			return opts, fmt.Errorf("invalid threshold: must be a positive percentage")
+		}
+	}
+	if n := r.URL.Query().Get("consecutive"); n != "" {
+		if opts.Consecutive, err = strconv.Atoi(n); err != nil || opts.Consecutive < 1 {
+			return opts, fmt.Errorf("invalid consecutive: must be a positive number")
+		}
+	}
+	return opts, nil
+}
+
+func (c *Config) loadBisect(r *http.Request, jobName, workloadName string) (BisectResult, []MetricGroup, int, error) {
+	opts, err := bisectOptionsFromRequest(r)
+	if err != nil {
+		return BisectResult{}, nil, http.StatusBadRequest, err
+	}
+	workloadPath := filepath.Join(c.resultsDir, jobName, workloadName)
+	job := Job{
+		Name: jobName,
+	}
+	job.Runs, err = loadRuns(workloadPath)
+	if err != nil {
+		return BisectResult{}, nil, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	snapshot, err := loadBaseline(workloadPath)
+	if err != nil {
+		return BisectResult{}, nil, http.StatusInternalServerError, err
+	}
+	result, err := bisect(job.Runs, snapshot, opts)
+	if err != nil {
+		return result, prepareChartData(&job, ChartOptions{}), http.StatusBadRequest, err
+	}
+	return result, prepareChartData(&job, ChartOptions{}), http.StatusOK, nil
+}
+
+func (c *Config) apiBisectHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	result, _, status, err := c.loadBisect(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, result)
+}
+
+func (c *Config) bisectHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	result, metricGroups, status, err := c.loadBisect(r, jobName, workloadName)
+	if err != nil && status != http.StatusBadRequest {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	type TemplateData struct {
+		JobName      string
+		WorkloadName string
+		Result       BisectResult
+		MetricGroups []MetricGroup
+		Percentiles  []string
+		Error        string
+	}
+	data := TemplateData{
+		JobName:      jobName,
+		WorkloadName: workloadName,
+		Result:       result,
+		MetricGroups: metricGroups,
+		Percentiles:  percentiles,
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "bisect.html", data)
+}
+
+// bisectCommand implements the bisect subcommand, reporting the first run of a workload where a metric regressed
+func bisectCommand(args []string) int {
+	flags := flag.NewFlagSet("bisect", flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path or URL of the directory holding results")
+	jobName := flags.String("job", "", "Job to bisect")
+	workloadName := flags.String("workload", "", "Workload to bisect")
+	metricName := flags.String("metric", defaultOverlayMetric, "Metric to check")
+	quantileName := flags.String("quantile", "Ready", "Quantile of the metric to check")
+	percentile := flags.String("percentile", "P99", "Percentile to check")
+	threshold := flags.Float64("threshold", defaultBisectThreshold, "Percent increase over the reference considered a regression")
+	consecutive := flags.Int("consecutive", defaultBisectConsecutive, "Number of runs in a row that must exceed the threshold")
+	output := flags.String("output", "text", "Output format, text or json")
+	timezone := flags.String("timezone", "", "Time zone timestamps are printed in, as recorded when empty")
+	flags.Parse(args)
+	if *jobName == "" || *workloadName == "" {
+		fmt.Fprintln(os.Stderr, "Both -job and -workload are required")
+		return 2
+	}
+	opts := bisectOptions{
+		MetricName:   *metricName,
+		QuantileName: *quantileName,
+		Threshold:    *threshold,
+		Consecutive:  *consecutive,
+	}
+	var err error
+	if opts.Percentile, err = parsePercentile(*percentile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if opts.Threshold <= 0 || opts.Consecutive < 1 {
+		fmt.Fprintln(os.Stderr, "Both -threshold and -consecutive must be positive")
+		return 2
+	}
+	loc, err := loadTimezone(*timezone)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	loadLog = os.Stderr
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	workloadPath := filepath.Join(root, *jobName, *workloadName)
+	runs, err := loadRuns(workloadPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading runs:", err)
+		return 1
+	}
+	snapshot, err := loadBaseline(workloadPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	result, err := bisect(runs, snapshot, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	switch *output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(result)
+	default:
+		err = result.writeText(os.Stdout, loc)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func (b BisectResult) writeText(w io.Writer, loc *time.Location) error {
+	fmt.Fprintf(w, "%s %s %s, reference %.0f (%s), threshold +%g%% over %d consecutive runs, %d runs checked\n",
+		b.MetricName, b.QuantileName, b.Percentile, b.Reference, b.ReferenceSource, b.Threshold, b.Consecutive, b.Checked)
+	if b.FirstRegressed == nil {
+		_, err := fmt.Fprintln(w, "No regression found")
+		return err
+	}
+	if b.LastGood != nil {
+		fmt.Fprintln(w, "\nLast good run:")
+		b.LastGood.writeText(w, loc)
+	}
+	fmt.Fprintln(w, "\nFirst regressed run:")
+	return b.FirstRegressed.writeText(w, loc)
+}
+
+func (r *BisectRun) writeText(w io.Writer, loc *time.Location) error {
+	fmt.Fprintf(w, "  #%d %s (%s)\n  %s: %.0f (%+.1f%%)\n", r.Seq, r.Name, r.UUID, formatTime(r.Timestamp, loc, time.RFC3339), r.Value, r.Delta)
+	for _, k := range slices.Sorted(maps.Keys(r.Metadata)) {
+		fmt.Fprintf(w, "  %s: %v\n", k, r.Metadata[k])
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// BoxPlotSet holds the box plots of every quantile of a metric, computed over the runs in a range
+type BoxPlotSet struct {
+	MetricName string
+	Percentile string
+	Runs       int
+	From       time.Time
+	To         time.Time
+	Boxes      []BoxPlot
+}
+
+// BoxPlot summarizes the values of a quantile across runs. Whiskers extend to the furthest values within
+// 1.5 times the interquartile range, values beyond them are outliers
+type BoxPlot struct {
+	QuantileName string
+	Count        int
+	Min          float64
+	Q1           float64
+	Median       float64
+	Q3           float64
+	Max          float64
+	LowerWhisker float64
+	UpperWhisker float64
+	Outliers     []Outlier
+}
+
+type Outlier struct {
+	Value     float64
+	Seq       int
+	UUID      string
+	Timestamp time.Time
+}
+
+func boxPlot(chart ChartData, percentile string) BoxPlot {
+	values := make([]float64, len(chart.Datapoints))
+	for i, dp := range chart.Datapoints {
+		values[i] = dp.value(percentile)
+	}
+	sorted := sortedCopy(values)
+	box := BoxPlot{
+		QuantileName: chart.QuantileName,
+		Count:        len(sorted),
+	}
+	if len(sorted) == 0 {
+		return box
+	}
+	box.Min = sorted[0]
+	box.Max = sorted[len(sorted)-1]
+	box.Q1 = quantile(sorted, 0.25)
+	box.Median = quantile(sorted, 0.5)
+	box.Q3 = quantile(sorted, 0.75)
+	iqr := box.Q3 - box.Q1
+	lowerFence, upperFence := box.Q1-1.5*iqr, box.Q3+1.5*iqr
+	box.LowerWhisker, box.UpperWhisker = box.Max, box.Min
+	for i, dp := range chart.Datapoints {
+		v := values[i]
+		if v < lowerFence || v > upperFence {
+			box.Outliers = append(box.Outliers, Outlier{
+				Value:     v,
+				Seq:       dp.Seq,
+				UUID:      dp.JobSummary.UUID,
+				Timestamp: dp.Timestamp,
+			})
+			continue
+		}
+		box.LowerWhisker = min(box.LowerWhisker, v)
+		box.UpperWhisker = max(box.UpperWhisker, v)
+	}
+	return box
+}
+
+// loadBoxPlots computes the box plots of a metric of a workload, over the runs selected by the chart options
+func (c *Config) loadBoxPlots(r *http.Request, jobName, workloadName string) (BoxPlotSet, []MetricGroup, int, error) {
+	set := BoxPlotSet{
+		MetricName: r.URL.Query().Get("metric"),
+		Percentile: "P99",
+	}
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		return set, nil, http.StatusBadRequest, err
+	}
+	if p := r.URL.Query().Get("percentile"); p != "" {
+		if set.Percentile, err = parsePercentile(p); err != nil {
+			return set, nil, http.StatusBadRequest, err
+		}
+	}
+	job := Job{
+		Name: jobName,
+	}
+	job.Runs, err = loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		return set, nil, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	runs := opts.filterRuns(job.Runs)
+	set.Runs = len(runs)
+	if set.MetricName == "" {
+		set.MetricName = defaultOverlayMetric
+	}
+	if len(runs) == 0 {
+		return set, nil, http.StatusOK, nil
+	}
+	set.From = runs[0].Started()
+	set.To = runs[len(runs)-1].Started()
+	metricGroups := prepareChartData(&job, opts)
+	i := slices.IndexFunc(metricGroups, func(mg MetricGroup) bool { return mg.MetricName == set.MetricName })
+	if i < 0 {
+		return set, metricGroups, http.StatusNotFound, fmt.Errorf("metric %s not found", set.MetricName)
+	}
+	for _, chart := range metricGroups[i].Charts {
+		set.Boxes = append(set.Boxes, boxPlot(chart, set.Percentile))
+	}
+	return set, metricGroups, http.StatusOK, nil
+}
+
+func (c *Config) apiBoxPlotHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	set, _, status, err := c.loadBoxPlots(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, set)
+}
+
+func (c *Config) boxPlotHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	set, metricGroups, status, err := c.loadBoxPlots(r, jobName, workloadName)
+	if err != nil && status == http.StatusBadRequest {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	type TemplateData struct {
+		JobName      string
+		WorkloadName string
+		BoxPlots     BoxPlotSet
+		BoxPlotsJSON template.JS
+		MetricGroups []MetricGroup
+		Percentiles  []string
+		Query        map[string]string
+		Error        string
+	}
+	boxPlotsJSON, _ := json.Marshal(set)
+	data := TemplateData{
+		JobName:      jobName,
+		WorkloadName: workloadName,
+		BoxPlots:     set,
+		BoxPlotsJSON: template.JS(boxPlotsJSON),
+		MetricGroups: metricGroups,
+		Percentiles:  percentiles,
+		Query: map[string]string{
+			"from": r.URL.Query().Get("from"),
+			"to":   r.URL.Query().Get("to"),
+			"last": r.URL.Query().Get("last"),
+		},
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "boxplot.html", data)
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// How long to wait for another process to release the disk cache before going on without it
+const diskCacheLockTimeout = time.Second
+
+// runsCache keeps the parsed runs in memory, so pages don't parse every run file on each request
+var runsCache = newRunCache()
+
+// runFingerprint identifies the state of the files of a run, it changes whenever a file is added, removed or modified.
+// Backends reporting ETags, such as object stores, also fingerprint the content of the files, so runs are
+// invalidated even when modification times aren't reliable
+type runFingerprint struct {
+	Files   int
+	Size    int64
+	ModTime int64
+	Hash    string
+}
+
+type cachedRun struct {
+	Run         Run
+	Fingerprint runFingerprint
+}
+
+type runCache struct {
+	mu   sync.RWMutex
+	runs map[string]cachedRun
+	// disk persists the runs of remote backends across restarts, nil when disabled
+	disk *diskCache
+}
+
+// diskCache persists parsed runs in a bolt database, in a bucket per results location
+type diskCache struct {
+	db     *bolt.DB
+	bucket []byte
+}
+
+func init() {
+	// Types found in the any fields of the parsed runs
+	gob.Register(map[string]any{})
+	gob.Register([]any{})
+}
+
+func newRunCache() *runCache {
+	return &runCache{
+		runs: make(map[string]cachedRun),
+	}
+}
+
+func fingerprint(runPath string) (runFingerprint, error) {
+	entries, err := storage.ReadDir(runPath)
+	if err != nil {
+		return runFingerprint{}, err
+	}
+	return fingerprintEntries(entries)
+}
+
+// fingerprintEntries computes the fingerprint of the files of a run
+func fingerprintEntries(entries []fs.DirEntry) (runFingerprint, error) {
+	var fp runFingerprint
+	var etags []string
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return fp, err
+		}
+		fp.Files++
+		fp.Size += info.Size()
+		fp.ModTime = max(fp.ModTime, info.ModTime().UnixNano())
+		if e, ok := entry.(remoteEntry); ok && e.etag != "" {
+			etags = append(etags, e.name+"="+e.etag)
+		}
+	}
+	if len(etags) > 0 {
+		slices.Sort(etags)
+		sum := sha256.Sum256([]byte(strings.Join(etags, "\n")))
+		fp.Hash = hex.EncodeToString(sum[:])
+	}
+	return fp, nil
+}
+
+// openDiskCache persists the runs of the given results location in the bolt database of the user cache directory
+func (rc *runCache) openDiskCache(location string) error {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(cacheDir, "ocp-perf-dash", "runs.db")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: diskCacheLockTimeout})
+	if err != nil {
+		return fmt.Errorf("error opening run cache %s: %v", path, err)
+	}
+	bucket := []byte(location)
+	if err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(bucket)
+		return err
+	}); err != nil {
+		db.Close()
+		return err
+	}
+	rc.disk = &diskCache{db: db, bucket: bucket}
+	return nil
+}
+
+// get returns the cached run when its files haven't changed since it was cached, runs found in the disk cache
+// are kept in memory from then on
+func (rc *runCache) get(runPath string, fp runFingerprint) (Run, bool) {
+	rc.mu.RLock()
+	cached, ok := rc.runs[runPath]
+	rc.mu.RUnlock()
+	if ok && cached.Fingerprint == fp {
+		return cached.Run, true
+	}
+	if rc.disk == nil {
+		return Run{}, false
+	}
+	if cached, ok = rc.disk.get(runPath); !ok || cached.Fingerprint != fp {
+		return Run{}, false
+	}
+	rc.mu.Lock()
+	rc.runs[runPath] = cached
+	rc.mu.Unlock()
+	return cached.Run, true
+}
+
+func (rc *runCache) put(runPath string, fp runFingerprint, run Run) {
+	cached := cachedRun{
+		Run:         run,
+		Fingerprint: fp,
+	}
+	rc.mu.Lock()
+	rc.runs[runPath] = cached
+	rc.mu.Unlock()
+	if rc.disk != nil {
+		if err := rc.disk.put(runPath, cached); err != nil {
+			fmt.Fprintf(loadLog, "Error persisting run %s: %v\n", runPath, err)
+		}
+	}
+}
+
+// delete drops the cached run of the given path, if any
+func (rc *runCache) delete(runPath string) {
+	rc.mu.Lock()
+	delete(rc.runs, runPath)
+	rc.mu.Unlock()
+	if rc.disk != nil {
+		rc.disk.delete(runPath)
+	}
+}
+
+// purge drops every cached run
+func (rc *runCache) purge() {
+	rc.mu.Lock()
+	clear(rc.runs)
+	rc.mu.Unlock()
+	if rc.disk != nil {
+		rc.disk.purge()
+	}
+}
+
+func (rc *runCache) len() int {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return len(rc.runs)
+}
+
+func (d *diskCache) get(runPath string) (cachedRun, bool) {
+	var cached cachedRun
+	err := d.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(d.bucket).Get([]byte(runPath))
+		if data == nil {
+			return fs.ErrNotExist
+		}
+		return gob.NewDecoder(bytes.NewReader(data)).Decode(&cached)
+	})
+	return cached, err == nil
+}
+
+func (d *diskCache) put(runPath string, cached cachedRun) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(cached); err != nil {
+		return err
+	}
+	return d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(d.bucket).Put([]byte(runPath), buf.Bytes())
+	})
+}
+
+func (d *diskCache) delete(runPath string) {
+	d.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(d.bucket).Delete([]byte(runPath))
+	})
+}
+
+func (d *diskCache) purge() {
+	d.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(d.bucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(d.bucket)
+		return err
+	})
+}
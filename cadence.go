@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// cadenceCheckInterval is how often the workloads are checked for missing runs
+const cadenceCheckInterval = 15 * time.Minute
+
+// namedCadences are the cadences descriptors can declare by name rather than as a duration
+var namedCadences = map[string]time.Duration{
+	"hourly": time.Hour,
+	"daily":  24 * time.Hour,
+	"weekly": 7 * 24 * time.Hour,
+}
+
+// parseCadence parses the cadence of a descriptor, a duration such as 24h or one of the named cadences
+func parseCadence(cadence string) (time.Duration, error) {
+	if d, ok := namedCadences[cadence]; ok {
+		return d, nil
+	}
+	d, err := time.ParseDuration(cadence)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid cadence %q: must be hourly, daily, weekly or a positive duration, such as 12h", cadence)
+	}
+	return d, nil
+}
+
+// Staleness flags a workload whose runs stopped landing at the cadence it declares, as when its CI pipeline broke
+// silently
+type Staleness struct {
+	Cadence string
+	LastRun time.Time
+	// Due is when the next run was expected at the latest
+	Due time.Time
+}
+
+// staleness returns the staleness of a workload of the cadence whose latest run started at lastRun, nil when it's not
+// stale. Runs are given a grace of a quarter of the cadence, as pipelines don't start or finish at the same time every
+// day, and workloads without runs aren't checked
+func staleness(cadence string, every time.Duration, lastRun, now time.Time) *Staleness {
+	if every <= 0 || lastRun.IsZero() {
+		return nil
+	}
+	due := lastRun.Add(every + every/4)
+	if now.Before(due) {
+		return nil
+	}
+	return &Staleness{Cadence: cadence, LastRun: lastRun, Due: due}
+}
+
+// workloadCadence returns the cadence of the workload, the one of its descriptor or else of the descriptor of its job
+func (d Descriptor) workloadCadence(job Descriptor) (string, time.Duration) {
+	if d.cadence > 0 {
+		return d.Cadence, d.cadence
+	}
+	return job.Cadence, job.cadence
+}
+
+// workloadStaleness checks the latest run of the workload against its cadence
+func workloadStaleness(job Descriptor, workload Workload, now time.Time) *Staleness {
+	cadence, every := workload.workloadCadence(job)
+	if every <= 0 || workload.Retired {
+		return nil
+	}
+	runs, err := loadRuns(workload.Path)
+	if err != nil || len(runs) == 0 {
+		return nil
+	}
+	return staleness(cadence, every, runs[len(runs)-1].Started(), now)
+}
+
+// addStaleness flags the stale workloads of the job, returning their number
+func addStaleness(job Descriptor, workloads []Workload) int {
+	now := time.Now()
+	var stale int
+	for i, workload := range workloads {
+		if workloads[i].Stale = workloadStaleness(job, workload, now); workloads[i].Stale != nil {
+			stale++
+		}
+	}
+	return stale
+}
+
+// watchCadences checks the workloads for missing runs periodically, notifying the ones which became stale, once
+// per run they've been waiting a successor for
+func (c *Config) watchCadences() {
+	go func() {
+		for {
+			c.checkCadences()
+			time.Sleep(cadenceCheckInterval)
+		}
+	}()
+}
+
+func (c *Config) checkCadences() {
+	jobs, err := loadJobs(c.resultsDir)
+	if err != nil {
+		fmt.Println("Error loading jobs:", err)
+		return
+	}
+	now := time.Now()
+	for _, job := range activeJobs(jobs) {
+		for _, workload := range job.Workloads {
+			stale := workloadStaleness(job.Descriptor, workload, now)
+			if stale == nil || !c.notify.markReported(fmt.Sprintf("%s/%s/%s/%d", notifyStale, job.Name, workload.Name, stale.LastRun.Unix())) {
+				continue
+			}
+			c.notifyStale(job.Name, workload.Name, stale)
+		}
+	}
+}
+
+// notifyStale notifies about a workload not receiving runs at its cadence
+func (c *Config) notifyStale(job, workload string, stale *Staleness) {
+	note := Notification{
+		Type:     notifyStale,
+		Job:      job,
+		Workload: workload,
+		Key:      job + "/" + workload,
+		Title:    fmt.Sprintf("No new run of %s/%s since %s", job, workload, formatTime(stale.LastRun, c.location, "2006-01-02 15:04 MST")),
+		Text: fmt.Sprintf("Runs of %s/%s are expected %s, the next one was due by %s. Its CI pipeline may be broken",
+			job, workload, cadenceText(stale.Cadence), formatTime(stale.Due, c.location, time.RFC3339)),
+	}
+	if c.notify.DashboardURL != "" {
+		note.URL = fmt.Sprintf("%s/job/%s/%s", strings.TrimSuffix(c.notify.DashboardURL, "/"), url.PathEscape(job), url.PathEscape(workload))
+	}
+	c.notify.send(note)
+}
+
+// cadenceText describes the cadence in a sentence, such as daily or every 12h
+func cadenceText(cadence string) string {
+	if _, ok := namedCadences[cadence]; ok {
+		return cadence
+	}
+	return "every " + cadence
+}
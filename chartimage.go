@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+)
+
+// Size of the charts rendered server side, for the notifications and printouts that can't run the dashboard scripts
+const (
+	chartImageWidth  = 800
+	chartImageHeight = 320
+	chartImageMargin = 24
+)
+
+var (
+	chartBackground = color.RGBA{255, 255, 255, 255}
+	chartGrid       = color.RGBA{230, 230, 230, 255}
+	chartAxis       = color.RGBA{120, 120, 120, 255}
+	chartSeries     = color.RGBA{54, 162, 235, 255}
+	chartReference  = color.RGBA{75, 192, 192, 255}
+	chartRegressed  = color.RGBA{255, 99, 132, 255}
+)
+
+// chartLine is a horizontal dashed line drawn across a chart at a value
+type chartLine struct {
+	value float64
+	color color.Color
+}
+
+// historyChart plots the values of a metric across runs as a PNG line chart, with the reference and the
+// regression threshold as dashed lines, and the values from regressedFrom onwards in red. It has no text, labels
+// are left to the notification carrying it
+func historyChart(values []float64, regressedFrom int, reference, limit float64) ([]byte, error) {
+	return lineChart(values, regressedFrom, chartLine{reference, chartReference}, chartLine{limit, chartRegressed})
+}
+
+// metricChart plots the P99 of the metric quantile over the runs, split by kube-burner job when group is set, along the
+// reference value the last run is compared to as a dashed line, unless NaN. When highlightLast is set, the last run is
+// in red if above it
+func metricChart(runs []Run, metric, group, quantile string, reference float64, highlightLast bool) ([]byte, error) {
+	var values []float64
+	for _, mg := range prepareChartData(&Job{Runs: runs}, ChartOptions{GroupByJob: group != ""}) {
+		if mg.MetricName != metric || mg.JobName != group {
+			continue
+		}
+		for _, chart := range mg.Charts {
+			if chart.QuantileName != quantile {
+				continue
+			}
+			for _, dp := range chart.Datapoints {
+				values = append(values, dp.P99)
+			}
+		}
+	}
+	highlightFrom := len(values)
+	var lines []chartLine
+	if !math.IsNaN(reference) {
+		lines = append(lines, chartLine{reference, chartReference})
+		if highlightLast && len(values) > 0 && values[len(values)-1] > reference {
+			highlightFrom = len(values) - 1
+		}
+	}
+	return lineChart(values, highlightFrom, lines...)
+}
+
+// chartRange returns the indexes of the first and last runs charted for the run at index current: the n runs up to
+// it, or when baseline isn't negative, the runs from the earlier to the later of both, the n latest of them
+func chartRange(current, baseline, n int) (int, int) {
+	if baseline < 0 {
+		return max(current+1-n, 0), current
+	}
+	last := max(current, baseline)
+	return max(min(current, baseline), last+1-n), last
+}
+
+// lineChart plots the values as a PNG line chart along the dashed lines, the values from highlightFrom onwards in
+// red, none when it's past the last value
+func lineChart(values []float64, highlightFrom int, lines ...chartLine) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, chartImageWidth, chartImageHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{chartBackground}, image.Point{}, draw.Src)
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, l := range lines {
+		lo, hi = min(lo, l.value), max(hi, l.value)
+	}
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+	if len(values) == 0 && len(lines) == 0 {
+		lo, hi = 0, 0
+	}
+	// Leave some room above and below the extreme values
+	pad := (hi - lo) * 0.1
+	if pad == 0 {
+		pad = 1
+	}
+	lo, hi = lo-pad, hi+pad
+	left, right := chartImageMargin, chartImageWidth-chartImageMargin
+	top, bottom := chartImageMargin, chartImageHeight-chartImageMargin
+	y := func(v float64) int {
+		return bottom - int(math.Round((v-lo)/(hi-lo)*float64(bottom-top)))
+	}
+	x := func(i int) int {
+		if len(values) < 2 {
+			return (left + right) / 2
+		}
+		return left + i*(right-left)/(len(values)-1)
+	}
+	for i := range 5 {
+		gy := top + i*(bottom-top)/4
+		drawLine(img, left, gy, right, gy, chartGrid, false)
+	}
+	drawLine(img, left, top, left, bottom, chartAxis, false)
+	drawLine(img, left, bottom, right, bottom, chartAxis, false)
+	for _, l := range lines {
+		drawLine(img, left, y(l.value), right, y(l.value), l.color, true)
+	}
+	for i := 1; i < len(values); i++ {
+		drawLine(img, x(i-1), y(values[i-1]), x(i), y(values[i]), chartSeries, false)
+	}
+	for i, v := range values {
+		c := chartSeries
+		if i >= highlightFrom {
+			c = chartRegressed
+		}
+		draw.Draw(img, image.Rect(x(i)-3, y(v)-3, x(i)+4, y(v)+4), &image.Uniform{c}, image.Point{}, draw.Src)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// drawLine draws a two pixel wide line between both points, dashed lines alternate 6 pixel dashes and gaps
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color, dashed bool) {
+	steps := max(abs(x1-x0), abs(y1-y0))
+	for i := 0; i <= steps; i++ {
+		if dashed && (i/6)%2 == 1 {
+			continue
+		}
+		px, py := x0, y0
+		if steps > 0 {
+			px, py = x0+(x1-x0)*i/steps, y0+(y1-y0)*i/steps
+		}
+		img.Set(px, py, c)
+		img.Set(px, py+1, c)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
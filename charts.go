@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// percentiles are the series available in every datapoint, in display order
+var percentiles = []string{"P99", "P95", "P50", "Min", "Max", "Avg"}
+
+// X axis modes, runs are either evenly spaced by their sequence number or placed at their start time
+const (
+	xAxisIndex = "index"
+	xAxisTime  = "time"
+)
+
+// Groupings of the chart data, measurements of the different kube-burner jobs of a workload are either mixed in the
+// groups of their metric or split by job
+const (
+	groupByMetric = "metric"
+	groupByJob    = "job"
+)
+
+// Buckets the datapoints can be aggregated into, runs are charted individually by default
+const (
+	bucketWeek = "week"
+)
+
+// ChartOptions tune the chart data built by prepareChartData
+type ChartOptions struct {
+	// Percentiles restricts the series included in the datapoints, all of them are included when empty
+	Percentiles []string
+	// From and To restrict the runs to the ones started within the range, when set
+	From time.Time
+	To   time.Time
+	// Last keeps only the given number of most recent runs, when set
+	Last int
+	// UUIDs keeps only the runs of the given kube-burner UUIDs, when set
+	UUIDs []string
+	// XAxis is the x axis mode the datapoints are placed with, index when empty
+	XAxis string
+	// Phase keeps only the measurements of the kube-burner job of that name, when set
+	Phase string
+	// GroupByJob splits the metric groups by the kube-burner job of their measurements, so the measurements of
+	// the jobs of a multi-job workload aren't mixed into the same series
+	GroupByJob bool
+	// SplitBy splits the series of every chart by the value of that metadata field of the runs, such as platform, so
+	// the runs of the different environments are told apart, when set
+	SplitBy string
+	// Bucket collapses the runs of every week into a single datapoint, the median of their values, when set to week
+	Bucket string
+}
+
+// maxSplitSeries is the number of distinct values a metadata field can have to be offered for splitting the series,
+// fields with more of them, such as the cluster name, would produce a series per run
+const maxSplitSeries = 8
+
+// splitUnknown is the series of the runs lacking the metadata field the series are split by
+const splitUnknown = "unknown"
+
+// chartOptionsFromRequest parses the chart options from the query parameters of the request
+func chartOptionsFromRequest(r *http.Request) (ChartOptions, error) {
+	return chartOptionsFromValues(r.URL.Query())
+}
+
+// chartOptionsFromValues parses the chart options from query parameters or form values
+func chartOptionsFromValues(values url.Values) (ChartOptions, error) {
+	var opts ChartOptions
+	if p := values.Get("percentiles"); p != "" {
+		for _, name := range splitList(p) {
+			percentile, err := parsePercentile(name)
+			if err != nil {
+				return opts, err
+			}
+			if !slices.Contains(opts.Percentiles, percentile) {
+				opts.Percentiles = append(opts.Percentiles, percentile)
+			}
+		}
+	}
+	var err error
+	if opts.From, err = parseTime(values.Get("from")); err != nil {
+		return opts, fmt.Errorf("invalid from: %v", err)
+	}
+	if opts.To, err = parseTime(values.Get("to")); err != nil {
+		return opts, fmt.Errorf("invalid to: %v", err)
+	}
+	// A date without time includes the whole day
+	if to := values.Get("to"); len(to) == len(time.DateOnly) {
+		opts.To = opts.To.Add(24*time.Hour - time.Nanosecond)
+	}
+	switch opts.XAxis = values.Get("xaxis"); opts.XAxis {
+	case "", xAxisIndex, xAxisTime:
+	default:
+		return opts, fmt.Errorf("invalid xaxis: must be %s or %s", xAxisIndex, xAxisTime)
+	}
+	opts.UUIDs = splitList(values.Get("uuid"))
+	opts.Phase = values.Get("phase")
+	switch values.Get("group") {
+	case "", groupByMetric:
+	case groupByJob:
+		opts.GroupByJob = true
+	default:
+		return opts, fmt.Errorf("invalid group: must be %s or %s", groupByMetric, groupByJob)
+	}
+	opts.SplitBy = values.Get("split")
+	switch opts.Bucket = values.Get("bucket"); opts.Bucket {
+	case "", bucketWeek:
+	default:
+		return opts, fmt.Errorf("invalid bucket: must be %s", bucketWeek)
+	}
+	if last := values.Get("last"); last != "" {
+		if opts.Last, err = strconv.Atoi(last); err != nil || opts.Last < 1 {
+			return opts, fmt.Errorf("invalid last: must be a positive number")
+		}
+	}
+	return opts, nil
+}
+
+// parseTime parses RFC3339 timestamps and dates, an empty string is the zero time
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// filterRuns returns the runs within the range and of the UUIDs of the options, sorted by start time
+func (opts ChartOptions) filterRuns(runs []Run) []Run {
+	var filtered []Run
+	for _, run := range runs {
+		if !opts.From.IsZero() && run.Started().Before(opts.From) {
+			continue
+		}
+		if !opts.To.IsZero() && run.Started().After(opts.To) {
+			continue
+		}
+		if len(opts.UUIDs) > 0 && !slices.Contains(opts.UUIDs, run.UUID()) {
+			continue
+		}
+		filtered = append(filtered, run)
+	}
+	slices.SortStableFunc(filtered, func(a, b Run) int {
+		return a.Started().Compare(b.Started())
+	})
+	if opts.Last > 0 && len(filtered) > opts.Last {
+		filtered = filtered[len(filtered)-opts.Last:]
+	}
+	return filtered
+}
+
+// series returns the series of the run when splitting them by a metadata field, the value of its field
+func (opts ChartOptions) series(run Run) string {
+	if opts.SplitBy == "" {
+		return ""
+	}
+	value, ok := run.metadataFields()[opts.SplitBy]
+	if !ok || value == nil || value == "" {
+		return splitUnknown
+	}
+	return fmt.Sprint(value)
+}
+
+// splitFields returns the metadata fields worth splitting the series of the runs by, the ones taking several values
+// but no more than maxSplitSeries
+func splitFields(runs []Run) []string {
+	values := make(map[string]map[string]bool)
+	for _, run := range runs {
+		for field, value := range run.metadataFields() {
+			if values[field] == nil {
+				values[field] = make(map[string]bool)
+			}
+			values[field][fmt.Sprint(value)] = true
+		}
+	}
+	var fields []string
+	for field, distinct := range values {
+		if len(distinct) > 1 && len(distinct) <= maxSplitSeries {
+			fields = append(fields, field)
+		}
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// weekStart returns the start of the ISO week of the time, Monday at midnight UTC
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	daysSinceMonday := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, time.UTC)
+}
+
+// bucketDatapoints collapses the datapoints of every week, and series when split, into their median, placed at the
+// start of the week. The bucket keeps the sequence number and job summary of its latest run
+func (opts ChartOptions) bucketDatapoints(datapoints []DataPoint) []DataPoint {
+	type bucketKey struct {
+		week   time.Time
+		series string
+	}
+	var keys []bucketKey
+	buckets := make(map[bucketKey][]DataPoint)
+	for _, dp := range datapoints {
+		key := bucketKey{week: weekStart(dp.Timestamp), series: dp.Series}
+		if _, ok := buckets[key]; !ok {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], dp)
+	}
+	slices.SortStableFunc(keys, func(a, b bucketKey) int {
+		return a.week.Compare(b.week)
+	})
+	var bucketed []DataPoint
+	for _, key := range keys {
+		bucket := buckets[key]
+		median := func(percentile string) float64 {
+			var values []float64
+			for _, dp := range bucket {
+				values = append(values, dp.value(percentile))
+			}
+			return quantile(sortedCopy(values), 0.5)
+		}
+		latest := bucket[len(bucket)-1]
+		dp := DataPoint{
+			Timestamp:   key.week,
+			Seq:         latest.Seq,
+			P99:         median("P99"),
+			P95:         median("P95"),
+			P50:         median("P50"),
+			Min:         median("Min"),
+			Max:         median("Max"),
+			Avg:         median("Avg"),
+			Runs:        len(bucket),
+			JobSummary:  latest.JobSummary,
+			Series:      key.series,
+			percentiles: latest.percentiles,
+		}
+		dp.X = opts.x(dp)
+		bucketed = append(bucketed, dp)
+	}
+	return bucketed
+}
+
+// parsePercentile returns the canonical name of the given percentile, names are case-insensitive
+func parsePercentile(name string) (string, error) {
+	i := slices.IndexFunc(percentiles, func(percentile string) bool {
+		return strings.EqualFold(percentile, name)
+	})
+	if i < 0 {
+		return "", fmt.Errorf("unknown percentile %s, valid values are %s", name, strings.Join(percentiles, ","))
+	}
+	return percentiles[i], nil
+}
+
+// xAxis returns the selected x axis mode
+func (opts ChartOptions) xAxis() string {
+	if opts.XAxis == "" {
+		return xAxisIndex
+	}
+	return opts.XAxis
+}
+
+// x returns the position of a datapoint on the x axis: the run sequence number, or the Unix time in
+// milliseconds, as JavaScript dates expect
+func (opts ChartOptions) x(dp DataPoint) float64 {
+	if opts.xAxis() == xAxisTime {
+		return float64(dp.Timestamp.UnixMilli())
+	}
+	return float64(dp.Seq)
+}
+
+// selectedPercentiles returns the percentiles to render, all of them when no selection was made
+func (opts ChartOptions) selectedPercentiles() []string {
+	if len(opts.Percentiles) == 0 {
+		return percentiles
+	}
+	return opts.Percentiles
+}
+
+// value returns the value of the given percentile
+func (d DataPoint) value(percentile string) float64 {
+	switch percentile {
+	case "P99":
+		return d.P99
+	case "P95":
+		return d.P95
+	case "P50":
+		return d.P50
+	case "Min":
+		return d.Min
+	case "Max":
+		return d.Max
+	case "Avg":
+		return d.Avg
+	}
+	return 0
+}
+
+// MarshalJSON leaves out the percentiles that weren't selected
+func (d DataPoint) MarshalJSON() ([]byte, error) {
+	type dataPoint DataPoint
+	data, err := json.Marshal(dataPoint(d))
+	if err != nil || len(d.percentiles) == 0 {
+		return data, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	for _, percentile := range percentiles {
+		if !slices.Contains(d.percentiles, percentile) {
+			delete(fields, percentile)
+		}
+	}
+	return json.Marshal(fields)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// checksumsFile lists the SHA-256 checksums of the files of a run, in the format of sha256sum. It's signed by the
+// publishers of signed runs, and generated when the runs are ingested with -checksums
+const checksumsFile = "sha256sums"
+
+// checksumError is the kind of the diagnostics of the runs whose files no longer match their checksums
+const checksumError = "checksum"
+
+// runChecksums enables the generation of the checksums of the local runs ingested without them, and the verification
+// of the runs having them
+var runChecksums bool
+
+// writeChecksums lets runChecksums write the checksums of the runs ingested without them, turned off in read-only
+// mode so the runs having them are only verified
+var writeChecksums = true
+
+// runFileNames returns the names of the files of the run covered by its checksums, leaving out the checksums, their
+// signatures, the provenance of promoted runs and hidden files, such as the ones NFS clients leave behind
+func runFileNames(files []fs.DirEntry) []string {
+	var names []string
+	for _, f := range files {
+		switch name := f.Name(); {
+		case f.IsDir(), strings.HasPrefix(name, "."), name == checksumsFile, name == cosignSignatureFile, name == gpgSignatureFile, name == promotionFile:
+		default:
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// verifyRunChecksums checks the files of the run against its checksums, returning why they don't match, empty when
+// they do. Local runs without checksums are being ingested, and get them unless writeChecksums is off
+func verifyRunChecksums(runPath string, files []fs.DirEntry) string {
+	names := runFileNames(files)
+	if !slices.ContainsFunc(files, func(f fs.DirEntry) bool { return f.Name() == checksumsFile }) {
+		if localResults() && writeChecksums {
+			if err := writeRunChecksums(runPath, names); err != nil {
+				fmt.Fprintf(loadLog, "Error writing the checksums of %s: %v\n", runPath, err)
+			}
+		}
+		return ""
+	}
+	data, err := storage.ReadFile(filepath.Join(runPath, checksumsFile))
+	if err == nil {
+		var sums map[string]string
+		if sums, err = parseChecksums(data); err == nil {
+			err = checkRunFiles(runPath, sums, names)
+		}
+	}
+	if err != nil {
+		diagnostics.report(runPath, checksumsFile, checksumError, err)
+		return err.Error()
+	}
+	return ""
+}
+
+// writeRunChecksums writes the checksums of the files of the run
+func writeRunChecksums(runPath string, names []string) error {
+	var buf bytes.Buffer
+	for _, name := range slices.Sorted(slices.Values(names)) {
+		data, err := os.ReadFile(filepath.Join(runPath, name))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&buf, "%x  %s\n", sha256.Sum256(data), name)
+	}
+	// Write to a temporary file first, so readers never see partial checksums
+	tmp := filepath.Join(runPath, "."+checksumsFile)
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(runPath, checksumsFile))
+}
+
+// checkRunFiles checks the files of the run against the checksums, none missing or added
+func checkRunFiles(runPath string, sums map[string]string, names []string) error {
+	sums = maps.Clone(sums)
+	for _, name := range names {
+		sum, ok := sums[name]
+		if !ok {
+			return fmt.Errorf("%s was added after the checksums were computed", name)
+		}
+		data, err := storage.ReadFile(filepath.Join(runPath, name))
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", name, err)
+		}
+		if digest := sha256.Sum256(data); hex.EncodeToString(digest[:]) != sum {
+			return fmt.Errorf("%s was modified after the checksums were computed", name)
+		}
+		delete(sums, name)
+	}
+	if missing := slices.Sorted(maps.Keys(sums)); len(missing) > 0 {
+		return fmt.Errorf("%s was deleted after the checksums were computed", missing[0])
+	}
+	return nil
+}
+
+// parseChecksums returns the checksums of the files of the sha256sum output, by file name
+func parseChecksums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if scanner.Text() == "" {
+			continue
+		}
+		sum, name, ok := strings.Cut(scanner.Text(), " ")
+		// The name is preceded by a space in text mode, and by a * in binary mode
+		name, _ = strings.CutPrefix(strings.TrimPrefix(name, " "), "*")
+		if _, err := hex.DecodeString(sum); !ok || err != nil || len(sum) != 2*sha256.Size || name == "" {
+			return nil, fmt.Errorf("invalid %s line %q", checksumsFile, scanner.Text())
+		}
+		if strings.ContainsAny(name, `/\`) {
+			return nil, fmt.Errorf("%s lists %s outside of the run directory", checksumsFile, name)
+		}
+		sums[strings.TrimPrefix(name, "./")] = strings.ToLower(sum)
+	}
+	return sums, scanner.Err()
+}
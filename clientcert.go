@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// ClientCertConfig maps the client certificates verified against -client-ca-file to identities, for machines such
+// as CI runners calling the API. The common name of the certificate is the user, and its organizational units are
+// its groups
+type ClientCertConfig struct {
+	// Roles are granted by the common name or the organizational units of the certificates
+	Roles Roles `yaml:"roles"`
+}
+
+func (cc *ClientCertConfig) validate() error {
+	if len(cc.Roles.Admin) == 0 && len(cc.Roles.ReadOnly) == 0 {
+		return fmt.Errorf("clientCerts roles grant access to no certificate")
+	}
+	return nil
+}
+
+// loadClientCAs reads the PEM bundle of the CAs the client certificates are verified against
+func loadClientCAs(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificate found in %s", path)
+	}
+	return pool, nil
+}
+
+// clientCertSession returns the session of the client certificate of the request, only verified certificates are
+// presented to the handlers. Its role is empty when the certificate is granted none
+func (c *Config) clientCertSession(r *http.Request) (Session, bool) {
+	if c.policy.ClientCerts == nil || r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+		return Session{}, false
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	session := Session{User: cert.Subject.CommonName, Groups: cert.Subject.OrganizationalUnit}
+	session.Role = c.policy.ClientCerts.Roles.role(append([]string{session.User}, session.Groups...))
+	return session, true
+}
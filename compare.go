@@ -0,0 +1,740 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	defaultBaselineSelector = "last:5"
+	defaultCurrentSelector  = "last:1"
+	defaultConfidence       = 0.95
+	// Aggregates of fewer runs are flagged, as a single run says little about the expected value of a metric
+	defaultMinSamples   = 3
+	defaultSignificance = 0.05
+)
+
+// Reference windows the current runs are also compared against, alongside the baseline, since a single reference
+// point often misleads
+const (
+	// windowPrevious is the run right before the current ones
+	windowPrevious = "previous"
+	// windowTrailingMedian is the median of the runs right before the current ones
+	windowTrailingMedian = "median-7"
+	// windowRecorded is the recorded baseline snapshot of the workload
+	windowRecorded     = "recorded"
+	trailingMedianRuns = 7
+)
+
+// Significance tests supported by the comparison
+const (
+	testWelch       = "welch"
+	testMannWhitney = "mann-whitney"
+)
+
+// compareOptions holds the parameters of a comparison between two groups of runs
+type compareOptions struct {
+	// Baseline selects the baseline runs, or the recorded baseline snapshot. When empty, the snapshot is used
+	// if the workload has one and the most recent runs before the current ones otherwise
+	Baseline   string
+	Current    string
+	Percentile string
+	Confidence float64
+	MinSamples int
+	// Test is the significance test run between both groups, none when empty
+	Test         string
+	Significance float64
+}
+
+// Comparison is the result of comparing a group of current runs against a group of baseline runs
+type Comparison struct {
+	// Baseline is the selector the baseline runs were picked with
+	Baseline     string
+	Percentile   string
+	Confidence   float64
+	MinSamples   int
+	Test         string
+	Significance float64
+	BaselineRuns []string
+	CurrentRuns  []string
+	// Windows are the reference windows found for the workload, in the order of the deltas of the metrics
+	Windows []string `json:",omitempty"`
+	Metrics []MetricComparison
+}
+
+// MetricComparison compares the aggregated values of a metric quantile across both groups of runs
+type MetricComparison struct {
+	MetricName   string
+	QuantileName string
+	// Owner is the team owning the metric, empty when no owner claims it
+	Owner    string `json:",omitempty"`
+	Baseline GroupStats
+	Current  GroupStats
+	// Delta is the percent change of the current mean over the baseline mean
+	Delta float64
+	// InsufficientSamples is set when either group has fewer runs than the minimum required
+	InsufficientSamples bool
+	// PValue is the result of the significance test, null when no test was requested or it couldn't be computed
+	PValue *float64
+	// Significant is set when the p-value is below the significance level
+	Significant bool
+	// Windows are the deltas of the current mean over every reference window having the metric
+	Windows []WindowDelta `json:",omitempty"`
+}
+
+// WindowDelta is the percent change of the current mean over the reference value of a window
+type WindowDelta struct {
+	Window    string
+	Reference float64
+	Delta     float64
+}
+
+// WindowDelta returns the delta over the given window, nil when the window doesn't have the metric
+func (mc MetricComparison) WindowDelta(window string) *WindowDelta {
+	i := slices.IndexFunc(mc.Windows, func(wd WindowDelta) bool { return wd.Window == window })
+	if i < 0 {
+		return nil
+	}
+	return &mc.Windows[i]
+}
+
+// FormatPValue returns the p-value for display, a dash when there is none
+func (mc MetricComparison) FormatPValue() string {
+	if mc.PValue == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.4f", *mc.PValue)
+}
+
+// Regressed reports whether the metric increased significantly, as every metric is a latency
+func (mc MetricComparison) Regressed() bool {
+	return mc.Significant && mc.Delta > 0
+}
+
+// Improved reports whether the metric decreased significantly
+func (mc MetricComparison) Improved() bool {
+	return mc.Significant && mc.Delta < 0
+}
+
+// GroupStats aggregates the values of a group of runs, CILow and CIHigh bound the confidence interval of the mean
+type GroupStats struct {
+	Runs   int
+	Mean   float64
+	CILow  float64
+	CIHigh float64
+}
+
+// selectRuns returns the runs matching the selector, which is either last:N for the N most recent runs
+// or a comma separated list of run directory names or UUIDs. Runs must be sorted by start time
+func selectRuns(runs []Run, selector string) ([]Run, error) {
+	if n, ok := strings.CutPrefix(selector, "last:"); ok {
+		count, err := strconv.Atoi(n)
+		if err != nil || count < 1 {
+			return nil, fmt.Errorf("invalid run selector %s: last must be followed by a positive number", selector)
+		}
+		return runs[max(len(runs)-count, 0):], nil
+	}
+	var selected []Run
+	for _, name := range splitList(selector) {
+		i := slices.IndexFunc(runs, func(r Run) bool { return r.Name() == name || r.UUID() == name })
+		if i < 0 {
+			return nil, fmt.Errorf("run %s not found", name)
+		}
+		selected = append(selected, runs[i])
+	}
+	return selected, nil
+}
+
+// compareRuns selects the current and baseline runs of a workload and compares them. When the baseline is
+// given as last:N, it selects among the runs started before the current ones. The snapshot is the recorded
+// baseline of the workload, if any
+func compareRuns(runs []Run, snapshot *BaselineSnapshot, opts compareOptions) (Comparison, error) {
+	if opts.Baseline == "" {
+		opts.Baseline = defaultBaselineSelector
+		if snapshot != nil {
+			opts.Baseline = recordedBaseline
+		}
+	}
+	comparison := Comparison{
+		Baseline:     opts.Baseline,
+		Percentile:   opts.Percentile,
+		Confidence:   opts.Confidence,
+		MinSamples:   opts.MinSamples,
+		Test:         opts.Test,
+		Significance: opts.Significance,
+	}
+	runs = ChartOptions{}.filterRuns(runs)
+	current, err := selectRuns(runs, opts.Current)
+	if err != nil {
+		return comparison, err
+	}
+	if len(current) == 0 {
+		return comparison, fmt.Errorf("no runs to compare")
+	}
+	var baselineValues map[string][]float64
+	if opts.Baseline == recordedBaseline {
+		if snapshot == nil {
+			return comparison, fmt.Errorf("no baseline recorded for this workload")
+		}
+		comparison.BaselineRuns = snapshot.Runs
+		baselineValues = snapshot.values(opts.Percentile)
+	} else {
+		candidates := runs
+		if strings.HasPrefix(opts.Baseline, "last:") {
+			candidates = slices.DeleteFunc(slices.Clone(runs), func(r Run) bool {
+				return !r.Started().Before(current[0].Started())
+			})
+		}
+		baseline, err := selectRuns(candidates, opts.Baseline)
+		if err != nil {
+			return comparison, err
+		}
+		if len(baseline) == 0 {
+			return comparison, fmt.Errorf("no baseline runs found before %s", current[0].Name())
+		}
+		for _, run := range baseline {
+			comparison.BaselineRuns = append(comparison.BaselineRuns, run.Name())
+		}
+		baselineValues = quantileValues(baseline, opts.Percentile)
+	}
+	for _, run := range current {
+		comparison.CurrentRuns = append(comparison.CurrentRuns, run.Name())
+	}
+	comparison = compareValues(comparison, baselineValues, quantileValues(current, opts.Percentile), opts)
+	prior := slices.DeleteFunc(slices.Clone(runs), func(r Run) bool { return !r.Started().Before(current[0].Started()) })
+	addWindows(&comparison, prior, snapshot, opts.Percentile)
+	return comparison, nil
+}
+
+// addWindows adds the deltas of the metrics over the previous run, the trailing median of the prior runs and the
+// recorded baseline, the windows without runs being left out
+func addWindows(comparison *Comparison, prior []Run, snapshot *BaselineSnapshot, percentile string) {
+	references := make(map[string]map[string]float64)
+	if len(prior) > 0 {
+		references[windowPrevious] = make(map[string]float64)
+		for key, values := range quantileValues(prior[len(prior)-1:], percentile) {
+			references[windowPrevious][key] = mean(values)
+		}
+		references[windowTrailingMedian] = make(map[string]float64)
+		for key, values := range quantileValues(prior[max(len(prior)-trailingMedianRuns, 0):], percentile) {
+			references[windowTrailingMedian][key] = quantile(sortedCopy(values), 0.5)
+		}
+	}
+	if snapshot != nil {
+		references[windowRecorded] = make(map[string]float64)
+		for key, values := range snapshot.values(percentile) {
+			references[windowRecorded][key] = mean(values)
+		}
+	}
+	for _, window := range []string{windowPrevious, windowTrailingMedian, windowRecorded} {
+		if references[window] != nil {
+			comparison.Windows = append(comparison.Windows, window)
+		}
+	}
+	for i, mc := range comparison.Metrics {
+		for _, window := range comparison.Windows {
+			reference, ok := references[window][mc.MetricName+"/"+mc.QuantileName]
+			if !ok {
+				continue
+			}
+			wd := WindowDelta{Window: window, Reference: reference}
+			if reference != 0 {
+				wd.Delta = (mc.Current.Mean - reference) / reference * 100
+			}
+			comparison.Metrics[i].Windows = append(comparison.Metrics[i].Windows, wd)
+		}
+	}
+}
+
+// compareValues compares the values of every metric quantile found in both groups of runs
+func compareValues(comparison Comparison, baselineValues, currentValues map[string][]float64, opts compareOptions) Comparison {
+	for _, key := range slices.Sorted(maps.Keys(currentValues)) {
+		values, ok := baselineValues[key]
+		if !ok {
+			continue
+		}
+		metricName, quantileName, _ := strings.Cut(key, "/")
+		mc := MetricComparison{
+			MetricName:   metricName,
+			QuantileName: quantileName,
+			Owner:        metricOwners.owner(metricName, quantileName),
+			Baseline:     groupStats(values, opts.Confidence),
+			Current:      groupStats(currentValues[key], opts.Confidence),
+		}
+		if mc.Baseline.Mean != 0 {
+			mc.Delta = (mc.Current.Mean - mc.Baseline.Mean) / mc.Baseline.Mean * 100
+		}
+		mc.InsufficientSamples = mc.Baseline.Runs < opts.MinSamples || mc.Current.Runs < opts.MinSamples
+		if p := significanceTest(opts.Test, values, currentValues[key]); !math.IsNaN(p) {
+			mc.PValue = &p
+			mc.Significant = p < opts.Significance
+		}
+		comparison.Metrics = append(comparison.Metrics, mc)
+	}
+	return comparison
+}
+
+// quantileValues returns the value of the percentile in every run, keyed by metric and quantile name
+func quantileValues(runs []Run, percentile string) map[string][]float64 {
+	job := Job{
+		Runs: runs,
+	}
+	values := make(map[string][]float64)
+	for _, mg := range prepareChartData(&job, ChartOptions{}) {
+		for _, chart := range mg.Charts {
+			key := mg.MetricName + "/" + chart.QuantileName
+			for _, dp := range chart.Datapoints {
+				values[key] = append(values[key], dp.value(percentile))
+			}
+		}
+	}
+	return values
+}
+
+// significanceTest returns the p-value of the given test between both samples, NaN when there is no test
+func significanceTest(test string, baseline, current []float64) float64 {
+	switch test {
+	case testWelch:
+		return welchTTest(baseline, current)
+	case testMannWhitney:
+		return mannWhitneyU(baseline, current)
+	}
+	return math.NaN()
+}
+
+func parseTest(test string) (string, error) {
+	switch test {
+	case "", testWelch, testMannWhitney:
+		return test, nil
+	}
+	return "", fmt.Errorf("invalid test %s: must be %s or %s", test, testWelch, testMannWhitney)
+}
+
+func groupStats(values []float64, confidence float64) GroupStats {
+	stats := GroupStats{
+		Runs: len(values),
+		Mean: mean(values),
+	}
+	stats.CILow, stats.CIHigh = confidenceInterval(values, confidence)
+	return stats
+}
+
+// Insufficient returns the metrics compared over too few runs
+func (c Comparison) Insufficient() []MetricComparison {
+	var insufficient []MetricComparison
+	for _, mc := range c.Metrics {
+		if mc.InsufficientSamples {
+			insufficient = append(insufficient, mc)
+		}
+	}
+	return insufficient
+}
+
+// Regressions returns the metrics that increased significantly
+func (c Comparison) Regressions() []MetricComparison {
+	var regressions []MetricComparison
+	for _, mc := range c.Metrics {
+		if mc.Regressed() {
+			regressions = append(regressions, mc)
+		}
+	}
+	return regressions
+}
+
+// Improvements returns the metrics that decreased significantly, largest decrease first
+func (c Comparison) Improvements() []MetricComparison {
+	var improvements []MetricComparison
+	for _, mc := range c.Metrics {
+		if mc.Improved() {
+			improvements = append(improvements, mc)
+		}
+	}
+	slices.SortStableFunc(improvements, func(a, b MetricComparison) int { return cmp.Compare(a.Delta, b.Delta) })
+	return improvements
+}
+
+// compareOptionsFromRequest parses the comparison parameters of a request, defaulting to the server settings
+func (c *Config) compareOptionsFromRequest(r *http.Request) (compareOptions, error) {
+	opts := compareOptions{
+		Current:      defaultCurrentSelector,
+		Percentile:   "P99",
+		Confidence:   defaultConfidence,
+		MinSamples:   c.minSamples,
+		Significance: defaultSignificance,
+	}
+	var err error
+	if b := r.URL.Query().Get("baseline"); b != "" {
+		opts.Baseline = b
+	}
+	if cur := r.URL.Query().Get("current"); cur != "" {
+		opts.Current = cur
+	}
+	if p := r.URL.Query().Get("percentile"); p != "" {
+		if opts.Percentile, err = parsePercentile(p); err != nil {
+			return opts, err
+		}
+	}
+	if conf := r.URL.Query().Get("confidence"); conf != "" {
+		if opts.Confidence, err = strconv.ParseFloat(conf, 64); err != nil || opts.Confidence <= 0 || opts.Confidence >= 1 {
+			return opts, fmt.Errorf("invalid confidence: must be between 0 and 1")
+		}
+	}
+	if opts.Test, err = parseTest(r.URL.Query().Get("test")); err != nil {
+		return opts, err
+	}
+	if sig := r.URL.Query().Get("significance"); sig != "" {
+		if opts.Significance, err = strconv.ParseFloat(sig, 64); err != nil || opts.Significance <= 0 || opts.Significance >= 1 {
+			return opts, fmt.Errorf("invalid significance: must be between 0 and 1")
+		}
+	}
+	return opts, nil
+}
+
+func (c *Config) loadComparison(r *http.Request, jobName, workloadName string) (Comparison, compareOptions, int, error) {
+	opts, err := c.compareOptionsFromRequest(r)
+	if err != nil {
+		return Comparison{}, opts, http.StatusBadRequest, err
+	}
+	workloadPath := filepath.Join(c.resultsDir, jobName, workloadName)
+	runs, err := loadRuns(workloadPath)
+	if err != nil {
+		return Comparison{}, opts, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	snapshot, err := loadBaseline(workloadPath)
+	if err != nil {
+		return Comparison{}, opts, http.StatusInternalServerError, err
+	}
+	comparison, err := compareRuns(runs, snapshot, opts)
+	if err != nil {
+		return comparison, opts, http.StatusBadRequest, err
+	}
+	return comparison, opts, http.StatusOK, nil
+}
+
+func (c *Config) apiCompareHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	comparison, _, status, err := c.loadComparison(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, comparison)
+}
+
+// ComparePage is the data of the comparison page, and of its comparison table fragment
+type ComparePage struct {
+	JobName      string
+	WorkloadName string
+	Comparison   Comparison
+	Options      compareOptions
+	Percentiles  []string
+	// ConfidencePercent is the confidence level formatted for display
+	ConfidencePercent string
+	// Permalink is the comparison addressed by the UUIDs of its runs, when it compares a run against another, and
+	// Summary its summary as a single HTML file
+	Permalink string
+	Summary   string
+	Error     string
+}
+
+// loadComparePage compares the runs of the workload, invalid options being shown on the page rather than failing it
+func (c *Config) loadComparePage(r *http.Request, jobName, workloadName string) (ComparePage, int, error) {
+	comparison, opts, status, err := c.loadComparison(r, jobName, workloadName)
+	if err != nil && status != http.StatusBadRequest {
+		return ComparePage{}, status, err
+	}
+	page := ComparePage{
+		JobName:           jobName,
+		WorkloadName:      workloadName,
+		Comparison:        comparison,
+		Options:           opts,
+		Percentiles:       percentiles,
+		ConfidencePercent: strconv.FormatFloat(comparison.Confidence*100, 'f', -1, 64),
+	}
+	if err != nil {
+		page.Error = err.Error()
+	}
+	if len(comparison.BaselineRuns) == 1 && len(comparison.CurrentRuns) == 1 && comparison.Baseline != recordedBaseline {
+		runs, _ := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+		baseline, _ := selectRuns(runs, comparison.BaselineRuns[0])
+		current, _ := selectRuns(runs, comparison.CurrentRuns[0])
+		if len(baseline) == 1 && len(current) == 1 && baseline[0].UUID() != "" && current[0].UUID() != "" {
+			page.Permalink = comparePermalink(baseline[0].UUID(), current[0].UUID(), opts)
+			page.Summary = fmt.Sprintf("/summary/%s...%s", url.PathEscape(baseline[0].UUID()), url.PathEscape(current[0].UUID()))
+		}
+	}
+	return page, http.StatusOK, nil
+}
+
+// comparePermalink returns the comparison of both runs addressed by their UUIDs, which keeps working when the
+// results are reorganized, along with the percentile and test compared with
+func comparePermalink(baselineUUID, currentUUID string, opts compareOptions) string {
+	query := url.Values{"percentile": {opts.Percentile}}
+	if opts.Test != "" {
+		query.Set("test", opts.Test)
+	}
+	return fmt.Sprintf("/compare/%s...%s?%s", url.PathEscape(baselineUUID), url.PathEscape(currentUUID), query.Encode())
+}
+
+// runPairOptions returns the options comparing the run of currentUUID against the one of baselineUUID, at the P99
+func (c *Config) runPairOptions(baselineUUID, currentUUID string) compareOptions {
+	return compareOptions{
+		Baseline:     baselineUUID,
+		Current:      currentUUID,
+		Percentile:   "P99",
+		Confidence:   defaultConfidence,
+		MinSamples:   c.minSamples,
+		Significance: defaultSignificance,
+	}
+}
+
+// findComparedRuns looks the runs of a permalink, given as baseline...current UUIDs, up in the workloads of the jobs,
+// which must be the ones the client can access, as the errors name them. Both runs must belong to the same workload
+func findComparedRuns(jobs []Job, runs string) (baseline, current RunLocation, status int, err error) {
+	baselineUUID, currentUUID, ok := strings.Cut(runs, "...")
+	if !ok || baselineUUID == "" || currentUUID == "" {
+		return baseline, current, http.StatusBadRequest, fmt.Errorf("invalid comparison: must be baseline...current run UUIDs")
+	}
+	if baseline, ok = findRun(jobs, baselineUUID); !ok {
+		return baseline, current, http.StatusNotFound, fmt.Errorf("run %s not found", baselineUUID)
+	}
+	if current, ok = findRun(jobs, currentUUID); !ok {
+		return baseline, current, http.StatusNotFound, fmt.Errorf("run %s not found", currentUUID)
+	}
+	if baseline.Job != current.Job || baseline.Workload != current.Workload {
+		return baseline, current, http.StatusBadRequest, fmt.Errorf("runs %s and %s belong to different workloads, %s/%s and %s/%s",
+			baselineUUID, currentUUID, baseline.Job, baseline.Workload, current.Job, current.Workload)
+	}
+	return baseline, current, http.StatusOK, nil
+}
+
+// comparePermalinkHandler renders the comparison of the runs of a permalink, wherever their workload lives now
+func (c *Config) comparePermalinkHandler(w http.ResponseWriter, r *http.Request) {
+	jobs, err := c.visibleJobs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	baseline, current, status, err := findComparedRuns(jobs, r.PathValue("runs"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	baselineUUID, currentUUID := baseline.Run.UUID(), current.Run.UUID()
+	query := r.URL.Query()
+	query.Set("baseline", baselineUUID)
+	query.Set("current", currentUUID)
+	r = r.Clone(r.Context())
+	r.URL.RawQuery = query.Encode()
+	c.compareHandler(w, r, baseline.Job, baseline.Workload)
+}
+
+func (c *Config) compareHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	page, status, err := c.loadComparePage(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	c.renderTemplate(w, r, "compare.html", page)
+}
+
+// compareCommand implements the compare subcommand, comparing the runs of a workload from the command line.
+// When a significance level is given it acts as a gate, exiting with 1 if any metric regressed significantly, and
+// with 2 if the significance of any metric couldn't be tested
+func compareCommand(args []string) int {
+	flags := flag.NewFlagSet("compare", flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path or URL of the directory holding results")
+	jobName := flags.String("job", "", "Job to compare")
+	workloadName := flags.String("workload", "", "Workload to compare")
+	baseline := flags.String("baseline", "", "Baseline runs, last:N, a comma separated list of run directories or UUIDs, or recorded for the baseline snapshot. Defaults to the snapshot when recorded, last:5 otherwise")
+	current := flags.String("current", defaultCurrentSelector, "Runs to compare against the baseline, last:N or a comma separated list of run directories or UUIDs")
+	percentile := flags.String("percentile", "P99", "Percentile to compare")
+	confidence := flags.Float64("confidence", defaultConfidence, "Confidence level of the intervals")
+	minSamples := flags.Int("min-samples", defaultMinSamples, "Minimum number of runs per group for a comparison to be conclusive")
+	test := flags.String("test", "", "Significance test to run between both groups, welch or mann-whitney")
+	significance := flags.Float64("significance", 0, "Fail when a metric regresses with a p-value below this level, runs Welch's t-test unless -test is given")
+	output := flags.String("output", "text", "Output format, text or json")
+	junit := flags.String("junit", "", "Path of a JUnit XML report to write, with a test case per metric")
+	flags.Parse(args)
+	if *jobName == "" || *workloadName == "" {
+		fmt.Fprintln(os.Stderr, "Both -job and -workload are required")
+		return 2
+	}
+	p, err := parsePercentile(*percentile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	opts := compareOptions{
+		Baseline:     *baseline,
+		Current:      *current,
+		Percentile:   p,
+		Confidence:   *confidence,
+		MinSamples:   *minSamples,
+		Significance: *significance,
+	}
+	if opts.Test, err = parseTest(*test); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if opts.Confidence <= 0 || opts.Confidence >= 1 {
+		fmt.Fprintln(os.Stderr, "Invalid -confidence: must be between 0 and 1")
+		return 2
+	}
+	if opts.Significance < 0 || opts.Significance >= 1 {
+		fmt.Fprintln(os.Stderr, "Invalid -significance: must be between 0 and 1")
+		return 2
+	}
+	gate := opts.Significance > 0
+	switch {
+	case gate && opts.Test == "":
+		opts.Test = testWelch
+	case !gate:
+		opts.Significance = defaultSignificance
+	}
+	loadLog = os.Stderr
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	workloadPath := filepath.Join(root, *jobName, *workloadName)
+	runs, err := loadRuns(workloadPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading runs:", err)
+		return 1
+	}
+	snapshot, err := loadBaseline(workloadPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	comparison, err := compareRuns(runs, snapshot, opts)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if gate {
+		// A gate which can't test the metrics must not pass
+		if err := comparison.checkTestable(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	switch *output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(comparison)
+	default:
+		err = comparison.writeText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if *junit != "" {
+		if err := writeJUnit(*junit, junitSuite(*jobName, *workloadName, comparison)); err != nil {
+			fmt.Fprintln(os.Stderr, "Error writing JUnit report:", err)
+			return 1
+		}
+	}
+	if gate && len(comparison.Regressions()) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// checkTestable checks that the significance test could be run on every metric, both groups having at least 2 runs
+func (c Comparison) checkTestable() error {
+	if len(c.BaselineRuns) < 2 || len(c.CurrentRuns) < 2 {
+		return fmt.Errorf("cannot test significance with %d baseline and %d current runs: both groups need at least 2 runs",
+			len(c.BaselineRuns), len(c.CurrentRuns))
+	}
+	var untested []string
+	for _, mc := range c.Metrics {
+		if mc.PValue == nil {
+			untested = append(untested, mc.MetricName+" "+mc.QuantileName)
+		}
+	}
+	if len(untested) > 0 {
+		return fmt.Errorf("cannot test significance of %s", strings.Join(untested, ", "))
+	}
+	return nil
+}
+
+// writeText prints the comparison as a table followed by the sample size warnings
+func (c Comparison) writeText(w io.Writer) error {
+	fmt.Fprintf(w, "Baseline (%s): %d runs, current: %d runs, %s with %.0f%% confidence intervals\n\n",
+		c.Baseline, len(c.BaselineRuns), len(c.CurrentRuns), c.Percentile, c.Confidence*100)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := "METRIC\tQUANTILE\tBASELINE\tCURRENT\tDELTA\t"
+	if c.Test != "" {
+		header += "P-VALUE\t"
+	}
+	for _, window := range c.Windows {
+		header += "VS " + strings.ToUpper(window) + "\t"
+	}
+	fmt.Fprintln(tw, header)
+	for _, mc := range c.Metrics {
+		mark := ""
+		if mc.InsufficientSamples {
+			mark = "*"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%+.1f%%%s\t", mc.MetricName, mc.QuantileName,
+			mc.Baseline.format(), mc.Current.format(), mc.Delta, mark)
+		if c.Test != "" {
+			pValue := mc.FormatPValue()
+			if mc.Regressed() {
+				pValue += " REGRESSION"
+			} else if mc.Improved() {
+				pValue += " IMPROVEMENT"
+			}
+			fmt.Fprintf(tw, "%s\t", pValue)
+		}
+		for _, window := range c.Windows {
+			if wd := mc.WindowDelta(window); wd != nil {
+				fmt.Fprintf(tw, "%+.1f%%\t", wd.Delta)
+			} else {
+				fmt.Fprint(tw, "-\t")
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(c.Insufficient()) > 0 {
+		fmt.Fprintf(w, "\n* Fewer than %d runs in a group, the delta is not conclusive\n", c.MinSamples)
+	}
+	if c.Test != "" {
+		_, err := fmt.Fprintf(w, "\n%d significant regressions, %d significant improvements (%s, p < %g)\n",
+			len(c.Regressions()), len(c.Improvements()), c.Test, c.Significance)
+		return err
+	}
+	return nil
+}
+
+func (s GroupStats) format() string {
+	if s.Runs < 2 {
+		return fmt.Sprintf("%.0f (n=%d)", s.Mean, s.Runs)
+	}
+	return fmt.Sprintf("%.0f [%.0f, %.0f] (n=%d)", s.Mean, s.CILow, s.CIHigh, s.Runs)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+const (
+	defaultCorrelationLimit = 10
+	// Correlations computed over fewer runs are too noisy to be reported
+	minCorrelationRuns = 3
+)
+
+// Correlation is the Pearson correlation of two metric quantiles across the runs they share
+type Correlation struct {
+	A           string
+	B           string
+	Coefficient float64
+	Runs        int
+}
+
+type CorrelationReport struct {
+	Percentile   string
+	Runs         int
+	Correlations []Correlation
+}
+
+// correlations computes the pairwise correlations between every metric quantile across runs and returns them
+// sorted by strength, strongest first
+func correlations(metricGroups []MetricGroup, percentile string) []Correlation {
+	type series struct {
+		name   string
+		values map[string]float64
+	}
+	var all []series
+	for _, mg := range metricGroups {
+		for _, chart := range mg.Charts {
+			s := series{
+				name:   mg.MetricName + "/" + chart.QuantileName,
+				values: make(map[string]float64, len(chart.Datapoints)),
+			}
+			for _, dp := range chart.Datapoints {
+				s.values[runKey(dp)] = dp.value(percentile)
+			}
+			all = append(all, s)
+		}
+	}
+	var result []Correlation
+	for i := range all {
+		for j := i + 1; j < len(all); j++ {
+			var x, y []float64
+			for run, v := range all[i].values {
+				if w, ok := all[j].values[run]; ok {
+					x = append(x, v)
+					y = append(y, w)
+				}
+			}
+			if len(x) < minCorrelationRuns {
+				continue
+			}
+			r := pearson(x, y)
+			if math.IsNaN(r) {
+				continue
+			}
+			result = append(result, Correlation{
+				A:           all[i].name,
+				B:           all[j].name,
+				Coefficient: r,
+				Runs:        len(x),
+			})
+		}
+	}
+	slices.SortStableFunc(result, func(a, b Correlation) int {
+		return cmp.Compare(math.Abs(b.Coefficient), math.Abs(a.Coefficient))
+	})
+	return result
+}
+
+func (c *Config) apiCorrelationsHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	report := CorrelationReport{
+		Percentile: "P99",
+	}
+	if p := r.URL.Query().Get("percentile"); p != "" {
+		if report.Percentile, err = parsePercentile(p); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	limit := defaultCorrelationLimit
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if limit, err = strconv.Atoi(l); err != nil || limit < 1 {
+			http.Error(w, "invalid limit: must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+	job := Job{
+		Name: jobName,
+	}
+	job.Runs, err = loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("workload %s/%s not found", jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	report.Runs = len(opts.filterRuns(job.Runs))
+	report.Correlations = correlations(prepareChartData(&job, opts), report.Percentile)
+	if len(report.Correlations) > limit {
+		report.Correlations = report.Correlations[:limit]
+	}
+	writeJSON(w, report)
+}
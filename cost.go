@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// nodeRoles are the node roles kube-burner records the instance type and count of, as <role>NodesType and
+// <role>NodesCount metadata fields
+var nodeRoles = []string{"master", "worker", "infra"}
+
+// Pricing is the pricing table of the cost estimator
+type Pricing struct {
+	Currency string `yaml:"currency"`
+	// Instances maps instance types to their hourly price
+	Instances map[string]float64 `yaml:"instances"`
+}
+
+// pricing is the pricing table loaded from -pricing, costs aren't estimated without it
+var pricing *Pricing
+
+func loadPricing(pricingPath string) error {
+	data, err := os.ReadFile(pricingPath)
+	if err != nil {
+		return err
+	}
+	p := Pricing{
+		Currency: "USD",
+	}
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return fmt.Errorf("error parsing pricing %s: %v", pricingPath, err)
+	}
+	for instanceType, price := range p.Instances {
+		if price < 0 {
+			return fmt.Errorf("invalid price %v of instance type %s", price, instanceType)
+		}
+	}
+	pricing = &p
+	return nil
+}
+
+// NodeCost is the cost of the nodes of a role during a run
+type NodeCost struct {
+	Role         string
+	InstanceType string
+	Count        int
+	HourlyPrice  float64
+	Cost         float64
+}
+
+// RunCost is the estimated cloud cost of a run, the price of its nodes over its duration
+type RunCost struct {
+	UUID      string
+	Seq       int
+	Timestamp time.Time
+	// Duration is the wall-clock time of the run in seconds
+	Duration float64
+	Nodes    []NodeCost
+	Cost     float64
+	Currency string
+	// Unpriced are the instance types missing from the pricing table, their nodes are left out of the cost
+	Unpriced []string `json:",omitempty"`
+}
+
+// runCost estimates the cost of the run from the instance types and node counts of its metadata, it fails when
+// no pricing is configured or the run lacks node metadata
+func runCost(run Run) (RunCost, error) {
+	cost := RunCost{
+		UUID:      run.UUID(),
+		Seq:       run.Seq,
+		Timestamp: run.Started(),
+	}
+	if pricing == nil {
+		return cost, fmt.Errorf("no pricing configured")
+	}
+	cost.Currency = pricing.Currency
+	timeline, err := runTimeline(run)
+	if err != nil {
+		return cost, err
+	}
+	cost.Duration = timeline.Duration
+	metadata := run.metadataFields()
+	for _, role := range nodeRoles {
+		instanceType, _ := metadata[role+"NodesType"].(string)
+		count, _ := metadata[role+"NodesCount"].(float64)
+		if instanceType == "" || count <= 0 {
+			continue
+		}
+		price, ok := pricing.Instances[instanceType]
+		if !ok {
+			if !slices.Contains(cost.Unpriced, instanceType) {
+				cost.Unpriced = append(cost.Unpriced, instanceType)
+			}
+			continue
+		}
+		node := NodeCost{
+			Role:         role,
+			InstanceType: instanceType,
+			Count:        int(count),
+			HourlyPrice:  price,
+			Cost:         count * price * cost.Duration / 3600,
+		}
+		cost.Nodes = append(cost.Nodes, node)
+		cost.Cost += node.Cost
+	}
+	if len(cost.Nodes) == 0 && len(cost.Unpriced) == 0 {
+		return cost, fmt.Errorf("run %s has no node metadata", run.UUID())
+	}
+	return cost, nil
+}
+
+// runCosts estimates the cost of the runs, by UUID, leaving out the ones it can't be estimated for
+func runCosts(runs []Run) map[string]RunCost {
+	if pricing == nil {
+		return nil
+	}
+	costs := make(map[string]RunCost)
+	for _, run := range runs {
+		if cost, err := runCost(run); err == nil {
+			costs[run.UUID()] = cost
+		}
+	}
+	return costs
+}
+
+// CostSet is the cost of the runs of a workload over time
+type CostSet struct {
+	Runs     []RunCost
+	Total    float64
+	Currency string
+}
+
+// loadCosts estimates the cost of the runs of a workload selected by the run filters of the request
+func (c *Config) loadCosts(r *http.Request, jobName, workloadName string) (CostSet, int, error) {
+	var set CostSet
+	if pricing == nil {
+		return set, http.StatusNotFound, fmt.Errorf("cost estimation is disabled, no pricing is configured")
+	}
+	set.Currency = pricing.Currency
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		return set, http.StatusBadRequest, err
+	}
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		return set, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	for _, run := range opts.filterRuns(runs) {
+		cost, err := runCost(run)
+		if err != nil {
+			continue
+		}
+		set.Runs = append(set.Runs, cost)
+		set.Total += cost.Cost
+	}
+	return set, http.StatusOK, nil
+}
+
+func (c *Config) apiCostHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	set, status, err := c.loadCosts(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, set)
+}
+
+func (c *Config) costHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	set, status, err := c.loadCosts(r, jobName, workloadName)
+	if err != nil && status == http.StatusBadRequest {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	type TemplateData struct {
+		JobName      string
+		WorkloadName string
+		Costs        CostSet
+		CostsJSON    template.JS
+		Query        map[string]string
+		Error        string
+	}
+	costsJSON, _ := json.Marshal(set)
+	data := TemplateData{
+		JobName:      jobName,
+		WorkloadName: workloadName,
+		Costs:        set,
+		CostsJSON:    template.JS(costsJSON),
+		Query: map[string]string{
+			"from": r.URL.Query().Get("from"),
+			"to":   r.URL.Query().Get("to"),
+			"last": r.URL.Query().Get("last"),
+		},
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "cost.html", data)
+}
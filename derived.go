@@ -0,0 +1,193 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+	"gopkg.in/yaml.v3"
+)
+
+// transformError is the kind of the problems found computing the derived metrics of a run
+const transformError = "transform error"
+
+const defaultTransformFunction = "transform"
+
+// transformTimeout bounds the calls to the functions of the derived metrics, so a function that never returns can't
+// hang the loading of the runs
+const transformTimeout = time.Second
+
+// DerivedMetric is a metric computed at load time from quantiles of other metrics, by a function exported by a WASM
+// module, such as the ratio of two latencies. It's added to the measurements of the runs, so it's charted and
+// compared like any other metric
+type DerivedMetric struct {
+	Metric   string `yaml:"metric"`
+	Quantile string `yaml:"quantile"`
+	// Inputs are the metric/quantile pairs passed to the function, in order
+	Inputs []string `yaml:"inputs"`
+	// Module is the path to the WASM module, relative to the file declaring it
+	Module string `yaml:"module"`
+	// Function is the name of the exported function, taking a float64 per input and returning a float64
+	Function string `yaml:"function"`
+
+	transform *wasmFunction
+}
+
+// wasmFunction is a function exported by a WASM module instance. Instances aren't safe for concurrent use, so calls
+// to the functions of an instance are serialized
+type wasmFunction struct {
+	mu *sync.Mutex
+	fn api.Function
+}
+
+func (f *wasmFunction) call(args []float64) (float64, error) {
+	params := make([]uint64, len(args))
+	for i, arg := range args {
+		params[i] = api.EncodeF64(arg)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), transformTimeout)
+	defer cancel()
+	results, err := f.fn.Call(ctx, params...)
+	if err != nil {
+		return 0, err
+	}
+	return api.DecodeF64(results[0]), nil
+}
+
+// derivedMetrics are the metrics computed from the measurements of the runs, loaded from -derived-metrics
+var derivedMetrics []DerivedMetric
+
+// loadDerivedMetrics loads the derived metrics declared in the YAML file, compiling and instantiating their WASM
+// modules once
+func loadDerivedMetrics(configPath string) error {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return err
+	}
+	var metrics []DerivedMetric
+	if err := yaml.Unmarshal(data, &metrics); err != nil {
+		return fmt.Errorf("error parsing derived metrics %s: %v", configPath, err)
+	}
+	ctx := context.Background()
+	// Calls timing out are aborted, closing the module they belong to
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().WithCloseOnContextDone(true))
+	// Modules built for WASI, as most toolchains do by default, import its functions
+	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
+	instances := make(map[string]api.Module)
+	locks := make(map[string]*sync.Mutex)
+	for i, dm := range metrics {
+		if dm.Metric == "" || dm.Quantile == "" || dm.Module == "" || len(dm.Inputs) == 0 {
+			return fmt.Errorf("invalid derived metric %s/%s: metric, quantile, inputs and module are required", dm.Metric, dm.Quantile)
+		}
+		for _, input := range dm.Inputs {
+			if metric, quantile, ok := strings.Cut(input, "/"); !ok || metric == "" || quantile == "" {
+				return fmt.Errorf("invalid input %q of derived metric %s/%s, must be metric/quantile", input, dm.Metric, dm.Quantile)
+			}
+		}
+		modulePath := dm.Module
+		if !filepath.IsAbs(modulePath) {
+			modulePath = filepath.Join(filepath.Dir(configPath), modulePath)
+		}
+		instance, ok := instances[modulePath]
+		if !ok {
+			wasm, err := os.ReadFile(modulePath)
+			if err != nil {
+				return err
+			}
+			// Reactor modules initialize themselves through _initialize, command modules aren't run
+			config := wazero.NewModuleConfig().WithName("").WithStartFunctions("_initialize")
+			if instance, err = runtime.InstantiateWithConfig(ctx, wasm, config); err != nil {
+				return fmt.Errorf("error instantiating module %s: %v", modulePath, err)
+			}
+			instances[modulePath] = instance
+			locks[modulePath] = &sync.Mutex{}
+		}
+		name := cmp.Or(dm.Function, defaultTransformFunction)
+		fn := instance.ExportedFunction(name)
+		if fn == nil {
+			return fmt.Errorf("module %s doesn't export function %s", modulePath, name)
+		}
+		params, results := fn.Definition().ParamTypes(), fn.Definition().ResultTypes()
+		if len(params) != len(dm.Inputs) || slices.ContainsFunc(params, func(t api.ValueType) bool { return t != api.ValueTypeF64 }) ||
+			!slices.Equal(results, []api.ValueType{api.ValueTypeF64}) {
+			return fmt.Errorf("function %s of module %s must take %d f64 parameters and return an f64", name, modulePath, len(dm.Inputs))
+		}
+		metrics[i].transform = &wasmFunction{mu: locks[modulePath], fn: fn}
+	}
+	derivedMetrics = metrics
+	return nil
+}
+
+// percentileValues returns the values of the percentiles of the measurement, in the order of percentiles
+func (m *Measurement) percentileValues() []*float64 {
+	return []*float64{&m.P99, &m.P95, &m.P50, &m.Min, &m.Max, &m.Avg}
+}
+
+// deriveMeasurements computes the derived metrics of the measurements of a run, for every kube-burner job holding
+// all their inputs. Values the functions return as NaN or infinite, such as ratios over zero, leave the measurement
+// out
+func deriveMeasurements(runPath string, measurements []Measurement) []Measurement {
+	if len(derivedMetrics) == 0 {
+		return measurements
+	}
+	byJob := make(map[string]map[string]Measurement)
+	var jobs []string
+	for _, m := range measurements {
+		if byJob[m.JobName] == nil {
+			byJob[m.JobName] = make(map[string]Measurement)
+			jobs = append(jobs, m.JobName)
+		}
+		byJob[m.JobName][m.MetricName+"/"+m.QuantileName] = m
+	}
+	derived := slices.Clone(measurements)
+	for _, dm := range derivedMetrics {
+	jobs:
+		for _, job := range jobs {
+			inputs := make([]Measurement, len(dm.Inputs))
+			for i, input := range dm.Inputs {
+				m, ok := byJob[job][input]
+				if !ok {
+					continue jobs
+				}
+				inputs[i] = m
+			}
+			m := Measurement{
+				MetricName:   dm.Metric,
+				QuantileName: dm.Quantile,
+				UUID:         inputs[0].UUID,
+				Timestamp:    inputs[0].Timestamp,
+				JobName:      job,
+				Metadata:     inputs[0].Metadata,
+			}
+			for p, value := range m.percentileValues() {
+				args := make([]float64, len(inputs))
+				for i := range inputs {
+					args[i] = *inputs[i].percentileValues()[p]
+				}
+				result, err := dm.transform.call(args)
+				if err != nil {
+					diagnostics.report(runPath, "", transformError, fmt.Errorf("error computing %s/%s: %v", dm.Metric, dm.Quantile, err))
+					continue jobs
+				}
+				if math.IsNaN(result) || math.IsInf(result, 0) {
+					continue jobs
+				}
+				*value = result
+			}
+			derived = append(derived, m)
+		}
+	}
+	return derived
+}
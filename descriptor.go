@@ -0,0 +1,122 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// descriptorFile describes the job or workload directory holding it to the dashboard, such as its display name and
+// owner, shown in the listings
+const descriptorFile = "dashboard.yaml"
+
+// Descriptor is the dashboard.yaml file of a job or workload directory, the zero one when it has none
+type Descriptor struct {
+	// DisplayName is shown instead of the directory name
+	DisplayName string `yaml:"displayName" json:",omitempty"`
+	Description string `yaml:"description" json:",omitempty"`
+	// Owner is the team or person owning the job or workload
+	Owner string `yaml:"owner" json:",omitempty"`
+	// Cadence is how often runs are expected, hourly, daily, weekly or a duration such as 12h
+	Cadence string `yaml:"cadence" json:",omitempty"`
+	// SLOs override the SLOs of -slo-file of the same name for the workloads of the directory, or add others
+	SLOs    []SLOOverride `yaml:"slos" json:",omitempty"`
+	cadence time.Duration
+}
+
+// SLOOverride is an SLO of a descriptor, its unset fields keeping the values of the SLO of the same name of
+// -slo-file, if any. Its job and workload patterns are ignored, it applies to the workloads of the directory
+type SLOOverride struct {
+	SLO `yaml:",inline"`
+	// Disabled drops the SLO of the same name from the workloads
+	Disabled bool `yaml:"disabled" json:",omitempty"`
+}
+
+// loadDescriptor returns the descriptor of the directory. Invalid ones are reported to the diagnostics and ignored
+func loadDescriptor(dir string) Descriptor {
+	path := filepath.Join(dir, descriptorFile)
+	data, err := storage.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Descriptor{}
+	}
+	if err != nil {
+		diagnostics.reportFile(path, readError, err)
+		return Descriptor{}
+	}
+	var d Descriptor
+	if err := yaml.Unmarshal(data, &d); err != nil {
+		diagnostics.reportFile(path, parseError, err)
+		return Descriptor{}
+	}
+	if err := d.validate(); err != nil {
+		diagnostics.reportFile(path, parseError, err)
+		return Descriptor{}
+	}
+	diagnostics.clear(path)
+	return d
+}
+
+// validate checks the descriptor, resolving its SLOs against the ones of -slo-file
+func (d *Descriptor) validate() error {
+	if d.Cadence != "" {
+		var err error
+		if d.cadence, err = parseCadence(d.Cadence); err != nil {
+			return err
+		}
+	}
+	for i, o := range d.SLOs {
+		if o.Name == "" {
+			return fmt.Errorf("SLO %d: name is required", i+1)
+		}
+		if o.Disabled {
+			continue
+		}
+		s, err := o.resolve()
+		if err != nil {
+			return fmt.Errorf("invalid SLO %d: %v", i+1, err)
+		}
+		d.SLOs[i].SLO = s
+	}
+	return nil
+}
+
+// resolve fills the unset fields of the override with the ones of the SLO of the same name of -slo-file
+func (o SLOOverride) resolve() (SLO, error) {
+	s := o.SLO
+	if i := slices.IndexFunc(slos, func(g SLO) bool { return g.Name == s.Name }); i >= 0 {
+		g := slos[i]
+		s.Metric = cmp.Or(s.Metric, g.Metric)
+		s.Quantile = cmp.Or(s.Quantile, g.Quantile)
+		s.Percentile = cmp.Or(s.Percentile, g.Percentile)
+		s.Phase = cmp.Or(s.Phase, g.Phase)
+		s.Threshold = cmp.Or(s.Threshold, g.Threshold)
+		s.Budget = cmp.Or(s.Budget, g.Budget)
+		s.Window = cmp.Or(s.Window, g.Window)
+	}
+	s.Job, s.Workload = "", ""
+	return s.validate()
+}
+
+// overrideSLOs applies the SLO overrides of the descriptor to the SLOs of a workload
+func (d Descriptor) overrideSLOs(applying []SLO) []SLO {
+	for _, o := range d.SLOs {
+		i := slices.IndexFunc(applying, func(s SLO) bool { return s.Name == o.Name })
+		switch {
+		case o.Disabled:
+			if i >= 0 {
+				applying = slices.Delete(applying, i, i+1)
+			}
+		case i >= 0:
+			applying[i] = o.SLO
+		default:
+			applying = append(applying, o.SLO)
+		}
+	}
+	return applying
+}
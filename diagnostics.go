@@ -0,0 +1,158 @@
+package main
+
+import (
+	"cmp"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of the problems found loading the results
+const (
+	readError   = "read error"
+	parseError  = "parse error"
+	missingData = "missing data"
+)
+
+// Diagnostic is a problem found while loading a results file, or a run directory when File is empty
+type Diagnostic struct {
+	Dir   string
+	File  string
+	Kind  string
+	Error string
+	Time  time.Time
+}
+
+// diagnosticsStore keeps the latest problem of every results file and run, until they load cleanly again
+type diagnosticsStore struct {
+	mu     sync.Mutex
+	byPath map[string]Diagnostic
+	// onNew is called with the problems found, but not with the ones found again until they're fixed
+	onNew func(Diagnostic)
+}
+
+// diagnostics collects the problems found by the result loaders, shown by the diagnostics page
+var diagnostics = &diagnosticsStore{byPath: make(map[string]Diagnostic)}
+
+func (d *diagnosticsStore) report(dir, file, kind string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	path := filepath.Join(dir, file)
+	_, known := d.byPath[path]
+	diagnostic := Diagnostic{Dir: dir, File: file, Kind: kind, Error: err.Error(), Time: time.Now()}
+	d.byPath[path] = diagnostic
+	if !known && d.onNew != nil {
+		go d.onNew(diagnostic)
+	}
+}
+
+// reportFile reports a problem found in the file of the given path
+func (d *diagnosticsStore) reportFile(path, kind string, err error) {
+	d.report(filepath.Dir(path), filepath.Base(path), kind, err)
+}
+
+// clear drops the diagnostic of the file or directory of the given path, once it loaded cleanly
+func (d *diagnosticsStore) clear(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.byPath, path)
+}
+
+// clearDir drops the diagnostics of the directory and of the files it holds
+func (d *diagnosticsStore) clearDir(dir string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	maps.DeleteFunc(d.byPath, func(_ string, diagnostic Diagnostic) bool { return diagnostic.Dir == dir })
+}
+
+// list returns the diagnostics sorted by path
+func (d *diagnosticsStore) list() []Diagnostic {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var list []Diagnostic
+	for _, diagnostic := range d.byPath {
+		list = append(list, diagnostic)
+	}
+	slices.SortFunc(list, func(a, b Diagnostic) int {
+		return cmp.Or(cmp.Compare(a.Dir, b.Dir), cmp.Compare(a.File, b.File))
+	})
+	return list
+}
+
+// DiagnosticEntry is a diagnostic located within the results directory
+type DiagnosticEntry struct {
+	Job      string
+	Workload string
+	Run      string
+	File     string
+	Kind     string
+	Error    string
+	Time     time.Time
+}
+
+// diagnosticEntries returns the diagnostics of the jobs the client of the request can access
+func (c *Config) diagnosticEntries(r *http.Request) []DiagnosticEntry {
+	var entries []DiagnosticEntry
+	for _, diagnostic := range diagnostics.list() {
+		rel, err := filepath.Rel(c.resultsDir, diagnostic.Dir)
+		if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+			continue
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		entry := DiagnosticEntry{
+			File:  diagnostic.File,
+			Kind:  diagnostic.Kind,
+			Error: diagnostic.Error,
+			Time:  diagnostic.Time,
+		}
+		for i, field := range []*string{&entry.Job, &entry.Workload, &entry.Run} {
+			if i < len(parts) {
+				*field = parts[i]
+			}
+		}
+		if !c.jobAllowed(r, entry.Job) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// DiagnosticCount is the number of problems found in a workload, or in a job outside of its workloads when Workload
+// is empty
+type DiagnosticCount struct {
+	Job      string
+	Workload string
+	Count    int
+}
+
+// DiagnosticsReport lists the problems found loading the results, with their count per job and workload
+type DiagnosticsReport struct {
+	Counts      []DiagnosticCount
+	Diagnostics []DiagnosticEntry
+}
+
+func (c *Config) diagnosticsReport(r *http.Request) DiagnosticsReport {
+	report := DiagnosticsReport{Diagnostics: c.diagnosticEntries(r)}
+	for _, entry := range report.Diagnostics {
+		// Entries are sorted by path, so the ones of a workload are contiguous
+		if n := len(report.Counts); n > 0 && report.Counts[n-1].Job == entry.Job && report.Counts[n-1].Workload == entry.Workload {
+			report.Counts[n-1].Count++
+			continue
+		}
+		report.Counts = append(report.Counts, DiagnosticCount{Job: entry.Job, Workload: entry.Workload, Count: 1})
+	}
+	return report
+}
+
+func (c *Config) apiDiagnosticsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, c.diagnosticsReport(r))
+}
+
+func (c *Config) diagnosticsHandler(w http.ResponseWriter, r *http.Request) {
+	c.renderTemplate(w, r, "diagnostics.html", c.diagnosticsReport(r))
+}
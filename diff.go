@@ -0,0 +1,183 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ANSI escape sequences used to highlight deltas on terminals
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorReset = "\033[0m"
+)
+
+// DiffRow is the delta of a percentile of a metric quantile between two runs
+type DiffRow struct {
+	MetricName   string
+	QuantileName string
+	Percentile   string
+	A            float64
+	B            float64
+	// Delta is the percent change of B over A, null when A is zero
+	Delta *float64
+}
+
+// diffRuns compares the given percentiles of every metric quantile present in both runs
+func diffRuns(a, b Run, selected []string) []DiffRow {
+	var rows []DiffRow
+	for _, p := range selected {
+		valuesA := quantileValues([]Run{a}, p)
+		for key, valuesB := range quantileValues([]Run{b}, p) {
+			va, ok := valuesA[key]
+			if !ok {
+				continue
+			}
+			metricName, quantileName, _ := strings.Cut(key, "/")
+			row := DiffRow{
+				MetricName:   metricName,
+				QuantileName: quantileName,
+				Percentile:   p,
+				A:            va[0],
+				B:            valuesB[0],
+			}
+			if row.A != 0 {
+				delta := (row.B - row.A) / row.A * 100
+				row.Delta = &delta
+			}
+			rows = append(rows, row)
+		}
+	}
+	slices.SortFunc(rows, func(x, y DiffRow) int {
+		return cmp.Or(
+			strings.Compare(x.MetricName, y.MetricName),
+			strings.Compare(x.QuantileName, y.QuantileName),
+			cmp.Compare(slices.Index(selected, x.Percentile), slices.Index(selected, y.Percentile)),
+		)
+	})
+	return rows
+}
+
+func (r DiffRow) formatDelta() string {
+	if r.Delta == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", *r.Delta)
+}
+
+// diffCommand implements the diff subcommand, printing the metric deltas between two run directories
+func diffCommand(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	selectedPercentiles := flags.String("percentiles", "P99", "Comma separated list of percentiles to compare")
+	threshold := flags.Float64("threshold", 5, "Percent change highlighted as a regression or an improvement")
+	output := flags.String("output", "text", "Output format, text, json or markdown")
+	noColor := flags.Bool("no-color", false, "Disable colors in the text output")
+	timezone := flags.String("timezone", "", "Time zone timestamps are printed in, as recorded when empty")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ocp-perf-dash diff [flags] <run-a> <run-b>")
+		flags.PrintDefaults()
+	}
+	// Flags are accepted before, between and after the run directories
+	var runPaths []string
+	for {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			break
+		}
+		runPaths = append(runPaths, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(runPaths) != 2 {
+		flags.Usage()
+		return 2
+	}
+	var selected []string
+	for _, p := range splitList(*selectedPercentiles) {
+		percentile, err := parsePercentile(p)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		selected = append(selected, percentile)
+	}
+	loc, err := loadTimezone(*timezone)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	loadLog = os.Stderr
+	var runs []Run
+	for _, runPath := range runPaths {
+		run, err := loadRun(runPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		runs = append(runs, run)
+	}
+	rows := diffRuns(runs[0], runs[1], selected)
+	switch *output {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rows)
+	case "markdown":
+		err = writeDiffMarkdown(os.Stdout, runs[0], runs[1], rows, loc)
+	default:
+		color := !*noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+		err = writeDiffText(os.Stdout, runs[0], runs[1], rows, *threshold, color, loc)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// writeDiffText prints the deltas as an aligned table. Only the last column is colored, so escape sequences
+// don't throw off the alignment
+func writeDiffText(w io.Writer, a, b Run, rows []DiffRow, threshold float64, color bool, loc *time.Location) error {
+	fmt.Fprintf(w, "A: %s (%s)\nB: %s (%s)\n\n", a.Name(), formatTime(a.Started(), loc, "2006-01-02 15:04"),
+		b.Name(), formatTime(b.Started(), loc, "2006-01-02 15:04"))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METRIC\tQUANTILE\tPERCENTILE\tA\tB\tDELTA")
+	for _, row := range rows {
+		delta := row.formatDelta()
+		if color && row.Delta != nil {
+			switch {
+			case *row.Delta > threshold:
+				delta = colorRed + delta + colorReset
+			case *row.Delta < -threshold:
+				delta = colorGreen + delta + colorReset
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.0f\t%.0f\t%s\n", row.MetricName, row.QuantileName, row.Percentile, row.A, row.B, delta)
+	}
+	return tw.Flush()
+}
+
+func writeDiffMarkdown(w io.Writer, a, b Run, rows []DiffRow, loc *time.Location) error {
+	fmt.Fprintf(w, "**A:** `%s` (%s)  \n**B:** `%s` (%s)\n\n", a.Name(), formatTime(a.Started(), loc, "2006-01-02 15:04"),
+		b.Name(), formatTime(b.Started(), loc, "2006-01-02 15:04"))
+	fmt.Fprintln(w, "| Metric | Quantile | Percentile | A | B | Delta |")
+	fmt.Fprintln(w, "|--------|----------|------------|--:|--:|------:|")
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(w, "| %s | %s | %s | %.0f | %.0f | %s |\n", row.MetricName, row.QuantileName, row.Percentile, row.A, row.B, row.formatDelta()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDigestTime      = "07:00"
+	defaultDigestThreshold = 5
+)
+
+// Digest emails a summary of the runs of the matching jobs to its recipients every day: the runs added since the
+// previous digest and whether they regressed against their baseline
+type Digest struct {
+	Name       string   `yaml:"name"`
+	Recipients []string `yaml:"recipients"`
+	// Jobs are the patterns of the jobs summarized, every job when empty
+	Jobs []string `yaml:"jobs"`
+	// Time is the time of the day the digest is sent at, HH:MM in the -timezone time zone
+	Time       string  `yaml:"time"`
+	Percentile string  `yaml:"percentile"`
+	Test       string  `yaml:"test"`
+	Threshold  float64 `yaml:"threshold"`
+}
+
+// JobDigest holds the workloads of a job with new runs
+type JobDigest struct {
+	Job       string
+	Workloads []WorkloadDigest
+}
+
+type WorkloadDigest struct {
+	Workload string
+	NewRuns  []Run
+	// Report compares the new runs against the baseline of the workload
+	Report WorkloadReport
+}
+
+// validate checks the digest and fills in its defaults
+func (d Digest) validate() (Digest, error) {
+	if len(d.Recipients) == 0 {
+		return d, fmt.Errorf("no recipients")
+	}
+	if err := validJobPatterns(d.Jobs); err != nil {
+		return d, err
+	}
+	d.Time = cmp.Or(d.Time, defaultDigestTime)
+	if _, err := time.Parse("15:04", d.Time); err != nil {
+		return d, fmt.Errorf("invalid time %s: must be HH:MM", d.Time)
+	}
+	var err error
+	if d.Percentile, err = parsePercentile(cmp.Or(d.Percentile, "P99")); err != nil {
+		return d, err
+	}
+	if d.Test, err = parseTest(d.Test); err != nil {
+		return d, err
+	}
+	if d.Threshold == 0 {
+		d.Threshold = defaultDigestThreshold
+	}
+	return d, nil
+}
+
+// next returns the first time the digest is due after now
+func (d Digest) next(now time.Time, loc *time.Location) time.Time {
+	at, _ := time.Parse("15:04", d.Time)
+	now = now.In(loc)
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, loc)
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// buildDigest collects the runs of the matching jobs started after since, comparing the new runs of every
+// workload against its baseline
+func buildDigest(resultsDir string, d Digest, since time.Time, minSamples int) ([]JobDigest, error) {
+	jobs, err := loadJobs(resultsDir)
+	if err != nil {
+		return nil, err
+	}
+	opts := compareOptions{
+		Percentile:   d.Percentile,
+		Confidence:   defaultConfidence,
+		MinSamples:   minSamples,
+		Test:         d.Test,
+		Significance: defaultSignificance,
+	}
+	var digests []JobDigest
+	for _, job := range jobs {
+		if !matchJob(d.Jobs, job.Name) {
+			continue
+		}
+		jd := JobDigest{Job: job.Name}
+		for _, workload := range job.Workloads {
+			runs, err := loadRuns(workload.Path)
+			if err != nil {
+				fmt.Fprintf(loadLog, "Error loading workload %s/%s: %v\n", job.Name, workload.Name, err)
+				continue
+			}
+			wd := WorkloadDigest{Workload: workload.Name}
+			for _, run := range runs {
+				if run.Started().After(since) {
+					wd.NewRuns = append(wd.NewRuns, run)
+				}
+			}
+			if len(wd.NewRuns) == 0 {
+				continue
+			}
+			// Runs are ordered by start time, the new ones are the latest
+			opts.Current = fmt.Sprintf("last:%d", len(wd.NewRuns))
+			wd.Report = newReport(resultsDir, job.Name, []string{workload.Name}, opts, d.Threshold).Workloads[0]
+			jd.Workloads = append(jd.Workloads, wd)
+		}
+		if len(jd.Workloads) > 0 {
+			digests = append(digests, jd)
+		}
+	}
+	return digests, nil
+}
+
+// writeDigest prints the digest as plain text, linking every workload to its comparison page when the URL of the
+// dashboard is configured
+func writeDigest(w io.Writer, jobs []JobDigest, d Digest, since time.Time, dashboardURL string, loc *time.Location) {
+	dashboardURL = strings.TrimSuffix(dashboardURL, "/")
+	fmt.Fprintf(w, "Runs since %s, %s regressions beyond %g%%.\n", formatTime(since, loc, "2006-01-02 15:04 MST"), d.Percentile, d.Threshold)
+	for _, jd := range jobs {
+		fmt.Fprintf(w, "\n== %s ==\n", jd.Job)
+		for _, wd := range jd.Workloads {
+			fmt.Fprintf(w, "\n%s: %d new runs\n", wd.Workload, len(wd.NewRuns))
+			for _, run := range wd.NewRuns {
+				fmt.Fprintf(w, "  #%d %s  %s\n", run.Seq, run.Name(), formatTime(run.Started(), loc, "2006-01-02 15:04"))
+			}
+			switch regressions := wd.Report.changes(d.Threshold, true); {
+			case wd.Report.Error != "":
+				fmt.Fprintf(w, "  Not compared: %s\n", wd.Report.Error)
+			case len(regressions) == 0:
+				fmt.Fprintln(w, "  No regression")
+			default:
+				fmt.Fprintf(w, "  %d regressions:\n", len(regressions))
+				for _, mc := range regressions {
+					fmt.Fprintf(w, "    %s %s: %s -> %s (%+.1f%%)\n", mc.MetricName, mc.QuantileName, mc.Baseline.format(), mc.Current.format(), mc.Delta)
+				}
+			}
+			if len(wd.Report.Comparison.Insufficient()) > 0 {
+				fmt.Fprintf(w, "  Fewer than %d runs in a group, deltas are not conclusive\n", wd.Report.Comparison.MinSamples)
+			}
+			if dashboardURL != "" && wd.Report.Error == "" {
+				fmt.Fprintf(w, "  %s/job/%s/%s/compare?%s\n", dashboardURL, url.PathEscape(jd.Job), url.PathEscape(wd.Workload),
+					url.Values{"baseline": {wd.Report.Comparison.Baseline}, "current": {strings.Join(wd.Report.Comparison.CurrentRuns, ",")}, "percentile": {d.Percentile}}.Encode())
+			}
+		}
+		if dashboardURL != "" {
+			fmt.Fprintf(w, "\n%s/job/%s\n", dashboardURL, url.PathEscape(jd.Job))
+		}
+	}
+}
+
+// scheduleDigests sends every digest each day at its time, covering the runs started since the previous one. The
+// first digest covers the previous day
+func (c *Config) scheduleDigests() {
+	loc := cmp.Or(c.location, time.Local)
+	for _, d := range c.notify.Digests {
+		go func() {
+			since := time.Now().AddDate(0, 0, -1)
+			for {
+				next := d.next(time.Now(), loc)
+				time.Sleep(time.Until(next))
+				if err := c.sendDigest(d, since, loc); err != nil {
+					fmt.Printf("Error sending digest %s: %v\n", d.Name, err)
+				}
+				since = next
+			}
+		}()
+	}
+}
+
+// sendDigest emails the digest to its recipients, nothing is sent when no run was added since the previous one
+func (c *Config) sendDigest(d Digest, since time.Time, loc *time.Location) error {
+	jobs, err := buildDigest(c.resultsDir, d, since, c.minSamples)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		fmt.Printf("No new runs for digest %s, skipping it\n", d.Name)
+		return nil
+	}
+	var runs, regressions int
+	for _, jd := range jobs {
+		for _, wd := range jd.Workloads {
+			runs += len(wd.NewRuns)
+			regressions += len(wd.Report.changes(d.Threshold, true))
+		}
+	}
+	var body strings.Builder
+	writeDigest(&body, jobs, d, since, c.notify.DashboardURL, loc)
+	subject := fmt.Sprintf("Performance digest %s: %d new runs, %d regressions", d.Name, runs, regressions)
+	email := emailNotifier{smtp: c.notify.SMTP, recipients: d.Recipients}
+	if err := email.notify(Notification{Title: subject, Text: body.String()}); err != nil {
+		return err
+	}
+	fmt.Printf("Digest %s sent to %s\n", d.Name, strings.Join(d.Recipients, ", "))
+	return nil
+}
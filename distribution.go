@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"maps"
+	"math"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultHistogramBins = 20
+	maxHistogramBins     = 200
+	// Maximum number of points of each CDF, larger distributions are downsampled
+	maxCDFPoints     = 200
+	defaultRawMetric = "podLatencyMeasurement"
+	defaultRawField  = "podReadyLatency"
+)
+
+// RawLatencies holds the latencies found in the raw measurement documents of a run, by metric and latency field
+type RawLatencies map[string]map[string][]float64
+
+// DistributionSet holds the distribution of a latency field for several runs, histograms share the same bins
+type DistributionSet struct {
+	MetricName    string
+	Field         string
+	BinEdges      []float64
+	Distributions []Distribution
+	// Metrics and fields available in the selected runs
+	Available map[string][]string
+}
+
+type Distribution struct {
+	Run       string
+	Seq       int
+	UUID      string
+	Count     int
+	CDF       []CDFPoint
+	Histogram []int
+}
+
+type CDFPoint struct {
+	Latency  float64
+	Fraction float64
+}
+
+// loadRawLatencies reads the raw measurement documents of a run, every numeric field ending in Latency is collected
+func loadRawLatencies(runPath string) (RawLatencies, error) {
+	entries, err := storage.ReadDir(runPath)
+	if err != nil {
+		return nil, err
+	}
+	latencies := make(RawLatencies)
+	for _, file := range matchMeasurementFiles(runPath, entries, rawParser) {
+		data, err := storage.ReadFile(file)
+		if err != nil {
+			fmt.Printf("Error reading file %s: %v\n", file, err)
+			continue
+		}
+		var docs []map[string]any
+		if err := json.Unmarshal(data, &docs); err != nil {
+			fmt.Printf("Error unmarshaling file %s: %v\n", file, err)
+			continue
+		}
+		for _, doc := range docs {
+			metricName, _ := doc["metricName"].(string)
+			if metricName == "" {
+				continue
+			}
+			for field, value := range doc {
+				v, ok := value.(float64)
+				if !ok || !strings.HasSuffix(field, "Latency") {
+					continue
+				}
+				if latencies[metricName] == nil {
+					latencies[metricName] = make(map[string][]float64)
+				}
+				latencies[metricName][field] = append(latencies[metricName][field], v)
+			}
+		}
+	}
+	if len(latencies) == 0 {
+		return nil, fmt.Errorf("no raw latency measurements found")
+	}
+	return latencies, nil
+}
+
+// empiricalCDF returns the empirical CDF of the values, downsampled to maxPoints evenly spaced fractions
+func empiricalCDF(values []float64, maxPoints int) []CDFPoint {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	points := min(n, maxPoints)
+	cdf := make([]CDFPoint, 0, points)
+	for k := 1; k <= points; k++ {
+		i := int(math.Ceil(float64(k)*float64(n)/float64(points))) - 1
+		cdf = append(cdf, CDFPoint{
+			Latency:  sorted[i],
+			Fraction: float64(i+1) / float64(n),
+		})
+	}
+	return cdf
+}
+
+// histogramEdges returns bins+1 evenly spaced edges covering every value of every series
+func histogramEdges(series [][]float64, bins int) []float64 {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, values := range series {
+		for _, v := range values {
+			lo = min(lo, v)
+			hi = max(hi, v)
+		}
+	}
+	if math.IsInf(lo, 1) {
+		return nil
+	}
+	if lo == hi {
+		return []float64{lo, hi}
+	}
+	edges := make([]float64, bins+1)
+	width := (hi - lo) / float64(bins)
+	for i := range edges {
+		edges[i] = lo + float64(i)*width
+	}
+	edges[bins] = hi
+	return edges
+}
+
+// histogram counts the values falling in each bin, the last bin includes its upper edge
+func histogram(values []float64, edges []float64) []int {
+	if len(edges) < 2 {
+		return nil
+	}
+	bins := len(edges) - 1
+	counts := make([]int, bins)
+	width := (edges[bins] - edges[0]) / float64(bins)
+	for _, v := range values {
+		i := bins - 1
+		if width > 0 {
+			i = min(int((v-edges[0])/width), bins-1)
+		}
+		counts[max(i, 0)]++
+	}
+	return counts
+}
+
+// loadDistributions computes the distribution of a latency field for the requested runs of a workload. The most
+// recent run is used when no runs are requested
+func (c *Config) loadDistributions(r *http.Request, jobName, workloadName string) (DistributionSet, []Run, int, error) {
+	set := DistributionSet{
+		MetricName: r.URL.Query().Get("metric"),
+		Field:      r.URL.Query().Get("field"),
+		Available:  make(map[string][]string),
+	}
+	bins := defaultHistogramBins
+	if b := r.URL.Query().Get("bins"); b != "" {
+		var err error
+		bins, err = strconv.Atoi(b)
+		if err != nil || bins < 1 || bins > maxHistogramBins {
+			return set, nil, http.StatusBadRequest, fmt.Errorf("bins must be a number between 1 and %d", maxHistogramBins)
+		}
+	}
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		return set, nil, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	selected := splitList(r.URL.Query().Get("runs"))
+	if len(selected) == 0 && len(runs) > 0 {
+		selected = []string{runs[len(runs)-1].Name()}
+	}
+
+	var series [][]float64
+	for _, name := range selected {
+		i := slices.IndexFunc(runs, func(run Run) bool { return run.Name() == name })
+		if i < 0 {
+			return set, runs, http.StatusNotFound, fmt.Errorf("run %s not found", name)
+		}
+		latencies, err := loadRawLatencies(runs[i].Path)
+		if err != nil {
+			return set, runs, http.StatusNotFound, fmt.Errorf("run %s: %v", name, err)
+		}
+		for metric, fields := range latencies {
+			for field := range fields {
+				if !slices.Contains(set.Available[metric], field) {
+					set.Available[metric] = append(set.Available[metric], field)
+					slices.Sort(set.Available[metric])
+				}
+			}
+		}
+		if set.MetricName == "" {
+			set.MetricName = defaultRawMetric
+			if latencies[set.MetricName] == nil && len(latencies) > 0 {
+				set.MetricName = slices.Sorted(maps.Keys(latencies))[0]
+			}
+		}
+		// The metric may come from the query, or from an earlier run
+		if latencies[set.MetricName] == nil {
+			return set, runs, http.StatusNotFound, fmt.Errorf("run %s has no %s values", name, set.MetricName)
+		}
+		if set.Field == "" {
+			set.Field = defaultRawField
+			if latencies[set.MetricName][set.Field] == nil {
+				set.Field = set.Available[set.MetricName][0]
+			}
+		}
+		values := latencies[set.MetricName][set.Field]
+		if len(values) == 0 {
+			return set, runs, http.StatusNotFound, fmt.Errorf("run %s has no %s values in %s", name, set.Field, set.MetricName)
+		}
+		series = append(series, values)
+		set.Distributions = append(set.Distributions, Distribution{
+			Run:   name,
+			Seq:   runs[i].Seq,
+			UUID:  runs[i].Summary.UUID,
+			Count: len(values),
+			CDF:   empiricalCDF(values, maxCDFPoints),
+		})
+	}
+	set.BinEdges = histogramEdges(series, bins)
+	for i := range set.Distributions {
+		set.Distributions[i].Histogram = histogram(series[i], set.BinEdges)
+	}
+	return set, runs, http.StatusOK, nil
+}
+
+func (c *Config) apiDistributionsHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	set, _, status, err := c.loadDistributions(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, set)
+}
+
+func (c *Config) distributionHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	set, runs, status, err := c.loadDistributions(r, jobName, workloadName)
+	if err != nil && status == http.StatusBadRequest {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	type TemplateData struct {
+		JobName           string
+		WorkloadName      string
+		Distributions     DistributionSet
+		DistributionsJSON template.JS
+		Runs              []Run
+		Selected          map[string]bool
+		Error             string
+	}
+	distributionsJSON, _ := json.Marshal(set)
+	data := TemplateData{
+		JobName:           jobName,
+		WorkloadName:      workloadName,
+		Distributions:     set,
+		DistributionsJSON: template.JS(distributionsJSON),
+		Runs:              runs,
+		Selected:          make(map[string]bool),
+	}
+	for _, d := range set.Distributions {
+		data.Selected[d.Run] = true
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "distribution.html", data)
+}
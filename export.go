@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// Formats measurements are exported in
+const (
+	exportFormatParquet = "parquet"
+	exportFormatNDJSON  = "ndjson"
+	// exportFormatInflux is the InfluxDB line protocol
+	exportFormatInflux = "influx"
+	exportFormatCSV    = "csv"
+)
+
+var exportFormats = []string{exportFormatParquet, exportFormatNDJSON, exportFormatInflux, exportFormatCSV}
+
+// csvColumns are the columns of the CSV exports, named like the ones of the Parquet ones
+var csvColumns = []string{"job", "workload", "run", "uuid", "seq", "timestamp", "phase", "metric", "quantile", "p99", "p95", "p50", "min", "max", "avg", "passed", "metadata"}
+
+// MeasurementRow is a quantile measurement of a run flattened into a row, the schema of the exports. Columns are only
+// ever added, so queries written against an export keep working with the next ones
+type MeasurementRow struct {
+	Job       string    `parquet:"job" json:"job"`
+	Workload  string    `parquet:"workload" json:"workload"`
+	Run       string    `parquet:"run" json:"run"`
+	UUID      string    `parquet:"uuid" json:"uuid"`
+	Seq       int64     `parquet:"seq" json:"seq"`
+	Timestamp time.Time `parquet:"timestamp,timestamp(millisecond)" json:"timestamp"`
+	// Phase is the kube-burner job the measurement was taken in
+	Phase    string  `parquet:"phase" json:"phase"`
+	Metric   string  `parquet:"metric" json:"metric"`
+	Quantile string  `parquet:"quantile" json:"quantile"`
+	P99      float64 `parquet:"p99" json:"p99"`
+	P95      float64 `parquet:"p95" json:"p95"`
+	P50      float64 `parquet:"p50" json:"p50"`
+	Min      float64 `parquet:"min" json:"min"`
+	Max      float64 `parquet:"max" json:"max"`
+	Avg      float64 `parquet:"avg" json:"avg"`
+	Passed   bool    `parquet:"passed" json:"passed"`
+	// Metadata holds the metadata fields of the run as a JSON object, such as the OCP version or the platform
+	Metadata string `parquet:"metadata" json:"metadata"`
+}
+
+// measurementRows flattens the measurements of the runs selected by the options into rows
+func measurementRows(jobName, workloadName string, runs []Run, opts ChartOptions) []MeasurementRow {
+	var rows []MeasurementRow
+	for _, run := range opts.filterRuns(runs) {
+		metadata, _ := json.Marshal(run.metadataFields())
+		for _, m := range run.Measurements {
+			if opts.Phase != "" && m.JobName != opts.Phase {
+				continue
+			}
+			rows = append(rows, MeasurementRow{
+				Job:       jobName,
+				Workload:  workloadName,
+				Run:       run.Name(),
+				UUID:      run.UUID(),
+				Seq:       int64(run.Seq),
+				Timestamp: m.Timestamp,
+				Phase:     m.JobName,
+				Metric:    m.MetricName,
+				Quantile:  m.QuantileName,
+				P99:       m.P99,
+				P95:       m.P95,
+				P50:       m.P50,
+				Min:       m.Min,
+				Max:       m.Max,
+				Avg:       m.Avg,
+				Passed:    run.PhaseSummary(m.JobName).Passed,
+				Metadata:  string(metadata),
+			})
+		}
+	}
+	return rows
+}
+
+// writeExport writes the rows in the given format, CSV in the given locale
+func writeExport(w io.Writer, rows []MeasurementRow, format string, locale *Locale) error {
+	switch format {
+	case exportFormatParquet:
+		pw := parquet.NewGenericWriter[MeasurementRow](w)
+		if _, err := pw.Write(rows); err != nil {
+			return err
+		}
+		return pw.Close()
+	case exportFormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, row := range rows {
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	case exportFormatInflux:
+		return writeLineProtocol(w, rows)
+	case exportFormatCSV:
+		return writeCSV(w, rows, locale)
+	}
+	return fmt.Errorf("unknown export format %s, must be one of %s", format, strings.Join(exportFormats, ", "))
+}
+
+// writeCSV writes the rows as CSV the way the spreadsheets of the locale read it: the timestamps with the dates of
+// the locale, and for the locales writing decimals with a comma, the values with a decimal comma and the fields
+// separated by semicolons. Values aren't grouped by thousands, so they're read back as numbers
+func writeCSV(w io.Writer, rows []MeasurementRow, locale *Locale) error {
+	cw := csv.NewWriter(w)
+	decimalComma := locale.decimalComma()
+	if decimalComma {
+		cw.Comma = ';'
+	}
+	number := func(v float64) string {
+		s := strconv.FormatFloat(v, 'f', -1, 64)
+		if decimalComma {
+			s = strings.Replace(s, ".", ",", 1)
+		}
+		return s
+	}
+	if err := cw.Write(csvColumns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		err := cw.Write([]string{
+			row.Job,
+			row.Workload,
+			row.Run,
+			row.UUID,
+			strconv.FormatInt(row.Seq, 10),
+			locale.formatTime(row.Timestamp, time.UTC, isoDateLayout+" 15:04:05"),
+			row.Phase,
+			row.Metric,
+			row.Quantile,
+			number(row.P99),
+			number(row.P95),
+			number(row.P50),
+			number(row.Min),
+			number(row.Max),
+			number(row.Avg),
+			strconv.FormatBool(row.Passed),
+			row.Metadata,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// apiExportHandler downloads the measurements of a workload, as Parquet unless format=ndjson, format=influx or
+// format=csv is given. It accepts the run filters and phase of the chart data, CSV being written in the locale
+// parameter or the one of the request
+func (c *Config) apiExportHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = exportFormatParquet
+	} else if !slices.Contains(exportFormats, format) {
+		http.Error(w, fmt.Sprintf("invalid format: must be one of %s", strings.Join(exportFormats, ", ")), http.StatusBadRequest)
+		return
+	}
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	locale := c.locale(r)
+	if name := r.URL.Query().Get("locale"); name != "" {
+		if locale, err = parseLocale(name); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("workload %s/%s not found", jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	switch format {
+	case exportFormatNDJSON:
+		w.Header().Set("Content-Type", ndjsonContentType)
+	case exportFormatInflux:
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	case exportFormatCSV:
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	default:
+		w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", jobName+"-"+workloadName+"."+format))
+	if err := writeExport(w, measurementRows(jobName, workloadName, runs, opts), format, locale); err != nil {
+		fmt.Printf("Error exporting %s/%s: %v\n", jobName, workloadName, err)
+	}
+}
+
+// exportCommand writes the measurements of the workloads of a job, or of every job, for analysis in tools like DuckDB
+// or Spark, or writes them straight to InfluxDB or BigQuery
+func exportCommand(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path or URL of the directory holding results")
+	jobName := flags.String("job", "", "Only export the workloads of this job")
+	workloadName := flags.String("workload", "", "Only export this workload, requires -job")
+	format := flags.String("format", exportFormatParquet, "Output format, parquet, ndjson, influx or csv")
+	localeName := flags.String("locale", "", "Locale the csv format writes numbers and dates in, such as de or fr, English when empty")
+	output := flags.String("output", "", "File to write the export to, stdout when empty")
+	influxURL := flags.String("influx-url", "", "InfluxDB write endpoint to write the measurements to instead, such as http://influxdb:8086/api/v2/write?org=perf&bucket=kube-burner")
+	influxToken := flags.String("influx-token", os.Getenv("INFLUX_TOKEN"), "Token of the InfluxDB write endpoint, defaults to $INFLUX_TOKEN")
+	bigqueryTable := flags.String("bigquery", "", "BigQuery table to append the measurements to instead, as dataset.table or project.dataset.table")
+	bigqueryProject := flags.String("bigquery-project", "", "Project of the BigQuery table, detected from the credentials when empty")
+	flags.Parse(args)
+	if *workloadName != "" && *jobName == "" {
+		fmt.Fprintln(os.Stderr, "-workload requires -job")
+		return 2
+	}
+	if !slices.Contains(exportFormats, *format) {
+		fmt.Fprintf(os.Stderr, "Unknown format %s, must be one of %s\n", *format, strings.Join(exportFormats, ", "))
+		return 2
+	}
+	locale, err := parseLocale(*localeName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	loadLog = os.Stderr
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	jobs, err := loadJobs(root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading jobs:", err)
+		return 1
+	}
+	var rows []MeasurementRow
+	for _, job := range jobs {
+		if *jobName != "" && job.Name != *jobName {
+			continue
+		}
+		for _, workload := range job.Workloads {
+			if *workloadName != "" && workload.Name != *workloadName {
+				continue
+			}
+			runs, err := loadRuns(workload.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading %s/%s: %v\n", job.Name, workload.Name, err)
+				continue
+			}
+			rows = append(rows, measurementRows(job.Name, workload.Name, runs, ChartOptions{})...)
+		}
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(os.Stderr, "No measurements found")
+		return 1
+	}
+	if *bigqueryTable != "" {
+		appended, err := exportBigQuery(context.Background(), *bigqueryProject, *bigqueryTable, rows)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Appended %d measurements to %s, %d were already there\n", appended, *bigqueryTable, len(rows)-appended)
+		return 0
+	}
+	if *influxURL != "" {
+		if err := writeInflux(*influxURL, *influxToken, rows); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d measurements to %s\n", len(rows), *influxURL)
+		return 0
+	}
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeExport(w, rows, *format, locale); err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing export:", err)
+		return 1
+	}
+	return 0
+}
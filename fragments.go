@@ -0,0 +1,187 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Partials rendered alone as fragments, which the pages swap in with HTMX rather than shipping their data up front
+const (
+	runTableFragment    = "run-table"
+	metricGroupFragment = "metric-group"
+	comparisonFragment  = "comparison-table"
+)
+
+// RunTable is the table of the runs of a workload matching the filters
+type RunTable struct {
+	JobName      string
+	WorkloadName string
+	// Runs are the runs matching the filters, latest first
+	Runs []Run
+	// Total is the number of runs of the workload
+	Total int
+}
+
+// MetricValues are the values of a percentile of the quantiles of a metric group, by run
+type MetricValues struct {
+	MetricName string
+	// JobName is the kube-burner job of the group, empty when the measurements aren't grouped by job
+	JobName     string
+	Percentile  string
+	Percentiles []string
+	Quantiles   []string
+	Rows        []MetricValuesRow
+	// URL is the fragment itself, without the percentile, which its percentile selection reloads it from
+	URL template.URL
+}
+
+// MetricValuesRow holds the values of a run, or of a bucket of runs, in the order of the quantiles, nil when the run
+// lacks the quantile
+type MetricValuesRow struct {
+	Seq       int
+	Timestamp time.Time
+	Series    string
+	Runs      int
+	Values    []*float64
+}
+
+// fragmentsURL returns the path the fragments of the workload are served under
+func fragmentsURL(jobName, workloadName string) string {
+	return fmt.Sprintf("/job/%s/%s/fragments", url.PathEscape(jobName), url.PathEscape(workloadName))
+}
+
+func (c *Config) fragmentHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	switch r.PathValue("fragment") {
+	case "runs":
+		c.runTableHandler(w, r, jobName, workloadName)
+	case "metric":
+		c.metricGroupHandler(w, r, jobName, workloadName)
+	case "compare":
+		c.comparisonHandler(w, r, jobName, workloadName)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// runTableHandler renders the runs of the workload matching the chart filters, and passed when given
+func (c *Config) runTableHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var passed *bool
+	if p := r.URL.Query().Get("passed"); p != "" {
+		value, err := strconv.ParseBool(p)
+		if err != nil {
+			http.Error(w, "invalid passed: must be true or false", http.StatusBadRequest)
+			return
+		}
+		passed = &value
+	}
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("workload %s/%s not found", jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	table := RunTable{JobName: jobName, WorkloadName: workloadName, Total: len(runs)}
+	for _, run := range slices.Backward(opts.filterRuns(runs)) {
+		if passed == nil || run.Summary.Passed == *passed {
+			table.Runs = append(table.Runs, run)
+		}
+	}
+	c.renderFragment(w, r, runTableFragment, table)
+}
+
+// metricGroupHandler renders the values of the metric group of the metric and kube-burner job, over the runs
+// matching the chart filters
+func (c *Config) metricGroupHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	query := r.URL.Query()
+	opts, err := chartOptionsFromValues(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	percentile, err := parsePercentile(cmp.Or(query.Get("percentile"), "P99"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	metric, kubeBurnerJob := query.Get("metric"), query.Get("job")
+	opts.GroupByJob = kubeBurnerJob != ""
+	job := Job{Name: jobName}
+	if job.Runs, err = loadRuns(filepath.Join(c.resultsDir, jobName, workloadName)); err != nil {
+		http.Error(w, fmt.Sprintf("workload %s/%s not found", jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	groups := prepareChartData(&job, opts)
+	i := slices.IndexFunc(groups, func(g MetricGroup) bool { return g.MetricName == metric && g.JobName == kubeBurnerJob })
+	if i < 0 {
+		http.Error(w, fmt.Sprintf("metric %s not found in workload %s/%s", metric, jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	query.Del("percentile")
+	values := MetricValues{
+		MetricName:  metric,
+		JobName:     kubeBurnerJob,
+		Percentile:  percentile,
+		Percentiles: percentiles,
+		URL:         template.URL(fragmentsURL(jobName, workloadName) + "/metric?" + query.Encode()),
+	}
+	type rowKey struct {
+		seq       int
+		timestamp time.Time
+		series    string
+	}
+	charts := groups[i].Charts
+	rows := make(map[rowKey]int)
+	for q, chart := range charts {
+		values.Quantiles = append(values.Quantiles, chart.QuantileName)
+		for _, dp := range chart.Datapoints {
+			key := rowKey{dp.Seq, dp.Timestamp, dp.Series}
+			row, ok := rows[key]
+			if !ok {
+				row = len(values.Rows)
+				rows[key] = row
+				values.Rows = append(values.Rows, MetricValuesRow{
+					Seq:       dp.Seq,
+					Timestamp: dp.Timestamp,
+					Series:    dp.Series,
+					Runs:      dp.Runs,
+					Values:    make([]*float64, len(charts)),
+				})
+			}
+			value := dp.value(percentile)
+			values.Rows[row].Values[q] = &value
+		}
+	}
+	// Latest first, like the run table
+	slices.SortStableFunc(values.Rows, func(a, b MetricValuesRow) int {
+		return cmp.Or(b.Timestamp.Compare(a.Timestamp), cmp.Compare(b.Seq, a.Seq))
+	})
+	c.renderFragment(w, r, metricGroupFragment, values)
+}
+
+// comparisonHandler renders the comparison table of the workload, pushing the comparison page of the options into
+// the history of the browser
+func (c *Config) comparisonHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	page, status, err := c.loadComparePage(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("HX-Push-Url", fmt.Sprintf("/job/%s/%s/compare?%s", url.PathEscape(jobName), url.PathEscape(workloadName), r.URL.RawQuery))
+	c.renderFragment(w, r, comparisonFragment, page)
+}
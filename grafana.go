@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Datasources the generated Grafana dashboards query
+const (
+	// grafanaDatasourcePrometheus queries the series written through the Prometheus remote write
+	grafanaDatasourcePrometheus = "prometheus"
+	// grafanaDatasourceJSON queries the chart data of the JSON API through the Infinity datasource plugin
+	grafanaDatasourceJSON = "json"
+)
+
+// grafanaPlugins are the plugin IDs of the datasources
+var grafanaPlugins = map[string]string{
+	grafanaDatasourcePrometheus: "prometheus",
+	grafanaDatasourceJSON:       "yesoreyeram-infinity-datasource",
+}
+
+// grafanaPanelHeight and grafanaPanelWidth lay the panels out two per row, on the 24 columns grid of Grafana
+const (
+	grafanaPanelHeight = 8
+	grafanaPanelWidth  = 12
+)
+
+// grafanaDashboard builds the dashboard of the metrics and their quantiles, a row per metric and a time series panel
+// per quantile. The job and workload are variables defaulting to the given ones, and so is the percentile
+func grafanaDashboard(title, datasource, dashboardURL, jobName, workloadName string, metrics map[string][]string) map[string]any {
+	ds := map[string]string{"type": grafanaPlugins[datasource], "uid": "${datasource}"}
+	variables := []map[string]any{{
+		"name":  "datasource",
+		"label": "Datasource",
+		"type":  "datasource",
+		"query": grafanaPlugins[datasource],
+	}}
+	for _, v := range []struct{ name, label, value, query string }{
+		{"job", "Job", jobName, "label_values(" + remoteWriteMetric + ", ci_job)"},
+		{"workload", "Workload", workloadName, "label_values(" + remoteWriteMetric + `{ci_job="$job"}, workload)`},
+	} {
+		variable := map[string]any{
+			"name":    v.name,
+			"label":   v.label,
+			"current": map[string]string{"text": v.value, "value": v.value},
+		}
+		if datasource == grafanaDatasourcePrometheus {
+			variable["type"] = "query"
+			variable["datasource"] = ds
+			variable["query"] = map[string]string{"query": v.query, "refId": v.name}
+			variable["refresh"] = 1
+		} else {
+			// The JSON API has no label values to list, the names are typed in
+			variable["type"] = "textbox"
+			variable["query"] = v.value
+		}
+		variables = append(variables, variable)
+	}
+	variables = append(variables, map[string]any{
+		"name":    "percentile",
+		"label":   "Percentile",
+		"type":    "custom",
+		"query":   strings.Join(percentiles, ","),
+		"current": map[string]string{"text": "P99", "value": "P99"},
+	})
+
+	var panels []map[string]any
+	y := 0
+	for _, metric := range slices.Sorted(maps.Keys(metrics)) {
+		panels = append(panels, map[string]any{
+			"type":    "row",
+			"title":   metric,
+			"id":      len(panels) + 1,
+			"gridPos": map[string]int{"x": 0, "y": y, "w": 24, "h": 1},
+		})
+		y++
+		for i, quantile := range metrics[metric] {
+			panels = append(panels, map[string]any{
+				"type":       "timeseries",
+				"title":      quantile + " ($percentile)",
+				"id":         len(panels) + 1,
+				"datasource": ds,
+				"gridPos":    map[string]int{"x": i % 2 * grafanaPanelWidth, "y": y + i/2*grafanaPanelHeight, "w": grafanaPanelWidth, "h": grafanaPanelHeight},
+				"targets":    []map[string]any{grafanaTarget(datasource, dashboardURL, ds, metric, quantile)},
+				"fieldConfig": map[string]any{
+					"defaults": map[string]any{
+						"unit": "ms",
+						"custom": map[string]any{
+							// A sample per run, joined across the gaps between runs
+							"drawStyle":   "line",
+							"showPoints":  "always",
+							"spanNulls":   true,
+							"lineWidth":   1,
+							"pointSize":   5,
+							"fillOpacity": 0,
+						},
+					},
+				},
+			})
+		}
+		y += (len(metrics[metric]) + 1) / 2 * grafanaPanelHeight
+	}
+	return map[string]any{
+		"title":         title,
+		"tags":          []string{"kube-burner", "ocp-perf-dash"},
+		"timezone":      "browser",
+		"schemaVersion": 39,
+		"editable":      true,
+		"time":          map[string]string{"from": "now-90d", "to": "now"},
+		"templating":    map[string]any{"list": variables},
+		"panels":        panels,
+	}
+}
+
+// grafanaTarget returns the query of the panel of a metric quantile
+func grafanaTarget(datasource, dashboardURL string, ds map[string]string, metric, quantile string) map[string]any {
+	if datasource == grafanaDatasourcePrometheus {
+		return map[string]any{
+			"refId":        "A",
+			"datasource":   ds,
+			"expr":         fmt.Sprintf(`%s{ci_job="$job", workload="$workload", metric=%q, quantile=%q, percentile="$percentile"}`, remoteWriteMetric, metric, quantile),
+			"legendFormat": "{{phase}}",
+			// Samples are written once per run, step through them with the finest resolution
+			"interval": "1m",
+		}
+	}
+	return map[string]any{
+		"refId":      "A",
+		"datasource": ds,
+		"type":       "json",
+		"source":     "url",
+		"parser":     "backend",
+		"format":     "timeseries",
+		"url":        strings.TrimSuffix(dashboardURL, "/") + "/api/v1/jobs/${job}/workloads/${workload}/charts",
+		"url_options": map[string]any{
+			"method": "GET",
+			"params": []map[string]string{
+				{"key": "group", "value": groupByMetric},
+				{"key": "percentile", "value": "$percentile"},
+				{"key": "from", "value": "${__from:date:YYYY-MM-DD}"},
+				{"key": "to", "value": "${__to:date:YYYY-MM-DD}"},
+			},
+		},
+		"root_selector": fmt.Sprintf("$[MetricName=%q].Charts[QuantileName=%q].Datapoints", metric, quantile),
+		"columns": []map[string]string{
+			{"selector": "Timestamp", "text": "Time", "type": "timestamp"},
+			{"selector": "$percentile", "text": quantile, "type": "number"},
+		},
+	}
+}
+
+// grafanaDashboardCommand prints a Grafana dashboard charting the metrics of the results, to bootstrap Grafana users
+func grafanaDashboardCommand(args []string) int {
+	flags := flag.NewFlagSet("grafana-dashboard", flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path or URL of the directory holding results, the metrics and quantiles charted are the ones found in it")
+	jobName := flags.String("job", "", "Job selected by default, its metrics are the ones charted when given")
+	workloadName := flags.String("workload", "", "Workload selected by default, its metrics are the ones charted when given")
+	datasource := flags.String("datasource", grafanaDatasourcePrometheus, "Datasource the dashboard queries, prometheus for the series written with -remote-write-config or json for the JSON API through the Infinity plugin")
+	dashboardURL := flags.String("dashboard-url", "http://localhost:8080", "Base URL Grafana reaches the dashboard at, for the json datasource")
+	title := flags.String("title", "OpenShift Performance", "Title of the Grafana dashboard")
+	output := flags.String("output", "", "File to write the dashboard JSON to, stdout when empty")
+	flags.Parse(args)
+	if _, ok := grafanaPlugins[*datasource]; !ok {
+		fmt.Fprintf(os.Stderr, "Unknown datasource %s, must be %s or %s\n", *datasource, grafanaDatasourcePrometheus, grafanaDatasourceJSON)
+		return 2
+	}
+	loadLog = os.Stderr
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	jobs, err := loadJobs(root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading jobs:", err)
+		return 1
+	}
+	metrics := make(map[string][]string)
+	for _, job := range jobs {
+		if *jobName != "" && job.Name != *jobName {
+			continue
+		}
+		for _, workload := range job.Workloads {
+			if *workloadName != "" && workload.Name != *workloadName {
+				continue
+			}
+			runs, err := loadRuns(workload.Path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading %s/%s: %v\n", job.Name, workload.Name, err)
+				continue
+			}
+			for _, run := range runs {
+				for _, m := range run.Measurements {
+					if !slices.Contains(metrics[m.MetricName], m.QuantileName) {
+						metrics[m.MetricName] = append(metrics[m.MetricName], m.QuantileName)
+					}
+				}
+			}
+		}
+	}
+	if len(metrics) == 0 {
+		fmt.Fprintln(os.Stderr, "No measurements found")
+		return 1
+	}
+	for _, quantiles := range metrics {
+		slices.Sort(quantiles)
+	}
+	data, err := json.MarshalIndent(grafanaDashboard(*title, *datasource, *dashboardURL, *jobName, *workloadName, metrics), "", "  ")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if _, err := fmt.Fprintln(w, string(data)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
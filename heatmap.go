@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// Heatmap is a matrix of metric quantiles by runs, every cell holding the percent deviation of the run
+// from the baseline of the quantile
+type Heatmap struct {
+	Percentile string
+	// Baseline is either median, first, recorded for the baseline snapshot or the name of a run directory
+	Baseline string
+	Runs     []HeatmapRun
+	Rows     []HeatmapRow
+}
+
+type HeatmapRun struct {
+	Name      string
+	Seq       int
+	UUID      string
+	Timestamp time.Time
+}
+
+type HeatmapRow struct {
+	MetricName    string
+	QuantileName  string
+	BaselineValue float64
+	// Deviations is aligned with the heatmap runs, cells are null when the run lacks the quantile or the baseline is zero
+	Deviations []*float64
+}
+
+// heatmap builds the deviation matrix of the given runs, which must be sorted by start time. The snapshot is only
+// used with the recorded baseline
+func heatmap(runs []Run, metricGroups []MetricGroup, percentile, baseline string, snapshot *BaselineSnapshot) (Heatmap, error) {
+	hm := Heatmap{
+		Percentile: percentile,
+		Baseline:   baseline,
+	}
+	columns := make(map[string]int, len(runs))
+	baselineColumn := -1
+	for i, run := range runs {
+		hm.Runs = append(hm.Runs, HeatmapRun{
+			Name:      run.Name(),
+			Seq:       run.Seq,
+			UUID:      run.Summary.UUID,
+			Timestamp: run.Started(),
+		})
+		columns[runKey(DataPoint{Timestamp: run.Summary.Timestamp, JobSummary: run.Summary})] = i
+		if run.Name() == baseline {
+			baselineColumn = i
+		}
+	}
+	var recorded map[string][]float64
+	switch {
+	case baseline == "first":
+		baselineColumn = 0
+	case baseline == recordedBaseline:
+		if snapshot == nil {
+			return hm, fmt.Errorf("no baseline recorded for this workload")
+		}
+		recorded = snapshot.values(percentile)
+	case baseline != "median" && baselineColumn < 0:
+		return hm, fmt.Errorf("baseline run %s not found", baseline)
+	}
+	for _, mg := range metricGroups {
+		for _, chart := range mg.Charts {
+			values := make([]*float64, len(runs))
+			var present []float64
+			for _, dp := range chart.Datapoints {
+				if i, ok := columns[runKey(dp)]; ok {
+					v := dp.value(percentile)
+					values[i] = &v
+					present = append(present, v)
+				}
+			}
+			row := HeatmapRow{
+				MetricName:   mg.MetricName,
+				QuantileName: chart.QuantileName,
+				Deviations:   make([]*float64, len(runs)),
+			}
+			switch {
+			case recorded != nil:
+				if _, ok := recorded[mg.MetricName+"/"+chart.QuantileName]; !ok {
+					hm.Rows = append(hm.Rows, row)
+					continue
+				}
+				row.BaselineValue = mean(recorded[mg.MetricName+"/"+chart.QuantileName])
+			case baselineColumn >= 0:
+				if values[baselineColumn] == nil {
+					hm.Rows = append(hm.Rows, row)
+					continue
+				}
+				row.BaselineValue = *values[baselineColumn]
+			default:
+				row.BaselineValue = quantile(sortedCopy(present), 0.5)
+			}
+			for i, v := range values {
+				if v == nil || row.BaselineValue == 0 {
+					continue
+				}
+				deviation := (*v - row.BaselineValue) / row.BaselineValue * 100
+				row.Deviations[i] = &deviation
+			}
+			hm.Rows = append(hm.Rows, row)
+		}
+	}
+	return hm, nil
+}
+
+// loadHeatmap computes the heatmap of a workload over the runs selected by the chart options
+func (c *Config) loadHeatmap(r *http.Request, jobName, workloadName string) (Heatmap, int, error) {
+	percentile, baseline := "P99", ""
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		return Heatmap{Percentile: percentile, Baseline: baseline}, http.StatusBadRequest, err
+	}
+	if p := r.URL.Query().Get("percentile"); p != "" {
+		if percentile, err = parsePercentile(p); err != nil {
+			return Heatmap{Percentile: percentile, Baseline: baseline}, http.StatusBadRequest, err
+		}
+	}
+	if b := r.URL.Query().Get("baseline"); b != "" {
+		baseline = b
+	}
+	job := Job{
+		Name: jobName,
+	}
+	workloadPath := filepath.Join(c.resultsDir, jobName, workloadName)
+	job.Runs, err = loadRuns(workloadPath)
+	if err != nil {
+		return Heatmap{Percentile: percentile, Baseline: baseline}, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	snapshot, err := loadBaseline(workloadPath)
+	if err != nil {
+		return Heatmap{Percentile: percentile, Baseline: baseline}, http.StatusInternalServerError, err
+	}
+	// The recorded baseline, when there is one, is the default reference
+	if baseline == "" {
+		baseline = "median"
+		if snapshot != nil {
+			baseline = recordedBaseline
+		}
+	}
+	runs := opts.filterRuns(job.Runs)
+	if len(runs) == 0 {
+		return Heatmap{Percentile: percentile, Baseline: baseline}, http.StatusOK, nil
+	}
+	hm, err := heatmap(runs, prepareChartData(&job, opts), percentile, baseline, snapshot)
+	if err != nil {
+		return hm, http.StatusBadRequest, err
+	}
+	return hm, http.StatusOK, nil
+}
+
+func (c *Config) apiHeatmapHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	hm, status, err := c.loadHeatmap(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, hm)
+}
+
+func (c *Config) heatmapHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	hm, status, err := c.loadHeatmap(r, jobName, workloadName)
+	if err != nil && status != http.StatusBadRequest {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	type TemplateData struct {
+		JobName      string
+		WorkloadName string
+		Heatmap      Heatmap
+		HeatmapJSON  template.JS
+		Percentiles  []string
+		Query        map[string]string
+		Error        string
+	}
+	heatmapJSON, _ := json.Marshal(hm)
+	data := TemplateData{
+		JobName:      jobName,
+		WorkloadName: workloadName,
+		Heatmap:      hm,
+		HeatmapJSON:  template.JS(heatmapJSON),
+		Percentiles:  percentiles,
+		Query: map[string]string{
+			"from": r.URL.Query().Get("from"),
+			"to":   r.URL.Query().Get("to"),
+			"last": r.URL.Query().Get("last"),
+		},
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "heatmap.html", data)
+}
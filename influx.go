@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// influxMeasurement is the InfluxDB measurement the rows are written to
+const influxMeasurement = "kube_burner_quantile"
+
+// influxBatchLines is the number of lines written per request to InfluxDB
+const influxBatchLines = 5000
+
+var influxClient = &http.Client{Timeout: httpTimeout}
+
+var (
+	influxTagEscaper    = strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `)
+	influxStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+)
+
+// lineProtocol returns the row as a line of the InfluxDB line protocol, timestamped in nanoseconds. The dimensions
+// measurements are grouped by are tags, the ones identifying the run are fields to keep the series cardinality low
+func (row MeasurementRow) lineProtocol() string {
+	var b strings.Builder
+	b.WriteString(influxMeasurement)
+	for _, tag := range [][2]string{
+		{"job", row.Job},
+		{"metric", row.Metric},
+		{"phase", row.Phase},
+		{"quantile", row.Quantile},
+		{"workload", row.Workload},
+	} {
+		// Empty tag values aren't allowed
+		if tag[1] != "" {
+			fmt.Fprintf(&b, ",%s=%s", tag[0], influxTagEscaper.Replace(tag[1]))
+		}
+	}
+	fmt.Fprintf(&b, " p99=%s,p95=%s,p50=%s,min=%s,max=%s,avg=%s,passed=%t,seq=%di",
+		influxFloat(row.P99), influxFloat(row.P95), influxFloat(row.P50),
+		influxFloat(row.Min), influxFloat(row.Max), influxFloat(row.Avg), row.Passed, row.Seq)
+	for _, field := range [][2]string{{"run", row.Run}, {"uuid", row.UUID}, {"metadata", row.Metadata}} {
+		fmt.Fprintf(&b, `,%s="%s"`, field[0], influxStringEscaper.Replace(field[1]))
+	}
+	fmt.Fprintf(&b, " %d", row.Timestamp.UnixNano())
+	return b.String()
+}
+
+func influxFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// writeLineProtocol writes the rows in the InfluxDB line protocol, a line per row
+func writeLineProtocol(w io.Writer, rows []MeasurementRow) error {
+	bw := bufio.NewWriter(w)
+	for _, row := range rows {
+		if _, err := fmt.Fprintln(bw, row.lineProtocol()); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// writeInflux writes the rows to the InfluxDB write endpoint, in batches. The URL is the full write endpoint, e.g.
+// /api/v2/write?org=perf&bucket=kube-burner for InfluxDB 2 or /write?db=kube-burner for InfluxDB 1, the token is sent
+// in the Authorization header when set
+func writeInflux(url, token string, rows []MeasurementRow) error {
+	for start := 0; start < len(rows); start += influxBatchLines {
+		var body bytes.Buffer
+		if err := writeLineProtocol(&body, rows[start:min(start+influxBatchLines, len(rows))]); err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPost, url, &body)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+		if token != "" {
+			req.Header.Set("Authorization", "Token "+token)
+		}
+		resp, err := influxClient.Do(req)
+		if err != nil {
+			return err
+		}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		if resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("error writing to %s: %s: %s", url, resp.Status, strings.TrimSpace(string(msg)))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Number of largest runs reported by the stats endpoint unless told otherwise
+const defaultLargestRuns = 10
+
+// Inventory summarizes the size and the runs of the results, as input to retention decisions and capacity planning
+type Inventory struct {
+	TotalSize int64
+	Jobs      int
+	Workloads []WorkloadInventory
+	Runs      int
+	// LargestRuns are the runs taking up the most space, largest first
+	LargestRuns []RunInventory
+}
+
+// WorkloadInventory summarizes the runs of a workload
+type WorkloadInventory struct {
+	Job      string
+	Workload string
+	Retired  bool `json:",omitempty"`
+	Runs     int
+	Size     int64
+	// Oldest and Newest are the runs with the earliest and latest start times, nil when there are no runs
+	Oldest *RunInventory `json:",omitempty"`
+	Newest *RunInventory `json:",omitempty"`
+}
+
+// RunInventory is a run along with the size of its files
+type RunInventory struct {
+	Job       string `json:",omitempty"`
+	Workload  string `json:",omitempty"`
+	Name      string
+	UUID      string
+	Timestamp time.Time
+	Size      int64
+}
+
+// dirSize returns the size of the files found within the directory, recursively
+func dirSize(dir string) (int64, error) {
+	entries, err := storage.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, entry := range entries {
+		if entry.IsDir() {
+			sub, err := dirSize(filepath.Join(dir, entry.Name()))
+			if err != nil {
+				return 0, err
+			}
+			size += sub
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return 0, err
+		}
+		size += info.Size()
+	}
+	return size, nil
+}
+
+// newInventory sizes the runs of every workload of the jobs, retired ones included, keeping the given number of
+// largest runs
+func newInventory(jobs []Job, largest int) Inventory {
+	inventory := Inventory{Jobs: len(jobs), Workloads: []WorkloadInventory{}, LargestRuns: []RunInventory{}}
+	var runs []RunInventory
+	for _, job := range jobs {
+		for _, workload := range job.Workloads {
+			wi := WorkloadInventory{Job: job.Name, Workload: workload.Name, Retired: job.Retired || workload.Retired}
+			loaded, err := loadRuns(workload.Path)
+			if err != nil {
+				fmt.Fprintf(loadLog, "Error loading runs of %s/%s: %v\n", job.Name, workload.Name, err)
+			}
+			for _, run := range loaded {
+				size, err := dirSize(run.Path)
+				if err != nil {
+					fmt.Fprintf(loadLog, "Error sizing run %s: %v\n", run.Path, err)
+				}
+				ri := RunInventory{Job: job.Name, Workload: workload.Name, Name: run.Name(), UUID: run.UUID(), Timestamp: run.Started(), Size: size}
+				wi.Size += size
+				runs = append(runs, ri)
+			}
+			// Runs are sorted by start time
+			if len(loaded) > 0 {
+				oldest, newest := runs[len(runs)-len(loaded)], runs[len(runs)-1]
+				oldest.Job, oldest.Workload, newest.Job, newest.Workload = "", "", "", ""
+				wi.Oldest, wi.Newest = &oldest, &newest
+			}
+			wi.Runs = len(loaded)
+			inventory.Runs += wi.Runs
+			inventory.TotalSize += wi.Size
+			inventory.Workloads = append(inventory.Workloads, wi)
+		}
+	}
+	slices.SortStableFunc(runs, func(a, b RunInventory) int { return cmp.Compare(b.Size, a.Size) })
+	inventory.LargestRuns = append(inventory.LargestRuns, runs[:min(largest, len(runs))]...)
+	return inventory
+}
+
+// apiStatsHandler returns the inventory of the visible results, along with the number of largest runs given with
+// top
+func (c *Config) apiStatsHandler(w http.ResponseWriter, r *http.Request) {
+	largest := defaultLargestRuns
+	if top := r.URL.Query().Get("top"); top != "" {
+		n, err := strconv.Atoi(top)
+		if err != nil || n < 0 {
+			http.Error(w, fmt.Sprintf("invalid top %q", top), http.StatusBadRequest)
+			return
+		}
+		largest = n
+	}
+	jobs, err := c.visibleJobs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, newInventory(jobs, largest))
+}
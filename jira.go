@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	defaultJiraIssueType = "Bug"
+	// Latest runs plotted in the chart attached to the issues
+	jiraChartRuns = 50
+)
+
+var jiraClient = &http.Client{Timeout: httpTimeout}
+
+// JiraConfig opens an issue through the Jira REST API for every sustained regression, attaching the history of the
+// metric. A single issue is kept open per workload metric quantile
+type JiraConfig struct {
+	URL string `yaml:"url"`
+	// User and Token authenticate with basic auth, as Jira Cloud API tokens do, and Token alone as a bearer
+	// personal access token. Token expands environment variables, such as ${JIRA_TOKEN}
+	User      string   `yaml:"user"`
+	Token     string   `yaml:"token"`
+	Project   string   `yaml:"project"`
+	IssueType string   `yaml:"issueType"`
+	Labels    []string `yaml:"labels"`
+	// Assignees assign the issues of the matching jobs, the first match wins
+	Assignees []JiraAssignee `yaml:"assignees"`
+}
+
+type JiraAssignee struct {
+	Jobs []string `yaml:"jobs"`
+	// Name is the username on Jira Server and Data Center, AccountID the account on Jira Cloud
+	Name      string `yaml:"name"`
+	AccountID string `yaml:"accountId"`
+}
+
+func (j *JiraConfig) validate() error {
+	if j.URL == "" || j.Project == "" {
+		return fmt.Errorf("url and project are required")
+	}
+	j.Token = os.ExpandEnv(j.Token)
+	j.IssueType = cmp.Or(j.IssueType, defaultJiraIssueType)
+	for _, assignee := range j.Assignees {
+		if err := validJobPatterns(assignee.Jobs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignee returns the assignee field of the issues of the job, nil when none matches
+func (j *JiraConfig) assignee(job string) map[string]string {
+	for _, assignee := range j.Assignees {
+		if !matchJob(assignee.Jobs, job) {
+			continue
+		}
+		if assignee.AccountID != "" {
+			return map[string]string{"accountId": assignee.AccountID}
+		}
+		return map[string]string{"name": assignee.Name}
+	}
+	return nil
+}
+
+// do sends a request to the Jira REST API, decoding the JSON response into out when given
+func (j *JiraConfig) do(method, path, contentType string, body io.Reader, out any) error {
+	req, err := http.NewRequest(method, strings.TrimSuffix(j.URL, "/")+path, body)
+	if err != nil {
+		return err
+	}
+	if j.User != "" {
+		req.SetBasicAuth(j.User, j.Token)
+	} else if j.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+j.Token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	// Required by the attachments endpoint
+	req.Header.Set("X-Atlassian-Token", "no-check")
+	resp, err := jiraClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// regressionLabel identifies the workload metric quantile an issue is about, to find the issue already open
+func regressionLabel(r Regression) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{r.Job, r.Workload, r.MetricName, r.QuantileName}, "/")))
+	return "ocp-perf-dash-" + hex.EncodeToString(sum[:6])
+}
+
+// openIssue opens an issue for the regression with the history of the metric attached, unless one is already
+// open for the same workload metric quantile
+func (j *JiraConfig) openIssue(r Regression, runs []Run, dashboardURL string, loc *time.Location) error {
+	label := regressionLabel(r)
+	jql := fmt.Sprintf(`project = "%s" AND labels = "%s" AND statusCategory != Done`, j.Project, label)
+	var search struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	if err := j.do(http.MethodGet, "/rest/api/2/search?"+url.Values{"jql": {jql}, "maxResults": {"1"}, "fields": {"key"}}.Encode(), "", nil, &search); err != nil {
+		return err
+	}
+	if len(search.Issues) > 0 {
+		fmt.Printf("Regression %s already tracked in %s\n", r, search.Issues[0].Key)
+		return nil
+	}
+	fields := map[string]any{
+		"project":     map[string]string{"key": j.Project},
+		"issuetype":   map[string]string{"name": j.IssueType},
+		"summary":     "Performance regression: " + r.String(),
+		"description": jiraDescription(r, dashboardURL, loc),
+		"labels":      append(slices.Clone(j.Labels), label),
+	}
+	if assignee := j.assignee(r.Job); assignee != nil {
+		fields["assignee"] = assignee
+	}
+	payload, err := json.Marshal(map[string]any{"fields": fields})
+	if err != nil {
+		return err
+	}
+	var issue struct {
+		Key string `json:"key"`
+	}
+	if err := j.do(http.MethodPost, "/rest/api/2/issue", "application/json", bytes.NewReader(payload), &issue); err != nil {
+		return err
+	}
+	fmt.Printf("Opened %s for regression %s\n", issue.Key, r)
+	chart, err := regressionChart(r, runs)
+	if err != nil {
+		return fmt.Errorf("error rendering the chart of %s: %v", issue.Key, err)
+	}
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", fmt.Sprintf("%s-%s.png", r.MetricName, r.QuantileName))
+	if err != nil {
+		return err
+	}
+	part.Write(chart)
+	mw.Close()
+	if err := j.do(http.MethodPost, "/rest/api/2/issue/"+issue.Key+"/attachments", mw.FormDataContentType(), &body, nil); err != nil {
+		return fmt.Errorf("error attaching the chart to %s: %v", issue.Key, err)
+	}
+	return nil
+}
+
+// jiraDescription describes the regression in Jira wiki markup
+func jiraDescription(r Regression, dashboardURL string, loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "The %s of %s %s in *%s/%s* exceeded the reference by more than %g%% in the latest %d runs.\n\n",
+		r.Percentile, r.MetricName, r.QuantileName, r.Job, r.Workload, r.Threshold, r.Sustained)
+	fmt.Fprintf(&b, "||Reference|%.0f ms (%s)|\n", r.Reference, r.ReferenceSource)
+	for _, row := range []struct {
+		title string
+		run   *BisectRun
+	}{
+		{"Last good run", r.LastGood},
+		{"First regressed run", r.FirstRegressed},
+	} {
+		if row.run == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "||%s|#%d %s, %s, %.0f ms (%+.1f%%)|\n", row.title, row.run.Seq, row.run.Name,
+			formatTime(row.run.Timestamp, loc, "2006-01-02 15:04 MST"), row.run.Value, row.run.Delta)
+	}
+	if version, ok := r.FirstRegressed.Metadata["ocpVersion"]; ok {
+		fmt.Fprintf(&b, "||OCP version|%v|\n", version)
+	}
+	fmt.Fprintf(&b, "\nThe attached chart plots the %s of the latest runs, the reference and the threshold as dashed lines, and the regressed runs in red.\n", r.Percentile)
+	if dashboardURL != "" {
+		fmt.Fprintf(&b, "\n[View in the dashboard|%s]\n", regressionURL(r, dashboardURL))
+	}
+	return b.String()
+}
+
+// regressionChart plots the metric of the latest runs holding it
+func regressionChart(r Regression, runs []Run) ([]byte, error) {
+	var values []float64
+	regressedFrom := -1
+	for _, run := range (ChartOptions{}).filterRuns(runs) {
+		v, ok := measurementValue(run, r.MetricName, r.QuantileName, r.Percentile)
+		if !ok {
+			continue
+		}
+		if run.Seq == r.FirstRegressed.Seq {
+			regressedFrom = len(values)
+		}
+		values = append(values, v)
+	}
+	if len(values) > jiraChartRuns {
+		regressedFrom -= len(values) - jiraChartRuns
+		values = values[len(values)-jiraChartRuns:]
+	}
+	return historyChart(values, max(regressedFrom, 0), r.Reference, r.Reference*(1+r.Threshold/100))
+}
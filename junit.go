@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+)
+
+// JUnit XML report, as understood by Jenkins and Prow, with a test case per metric check
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitOutput struct {
+	Content string `xml:",cdata"`
+}
+
+// junitSuite turns every metric of the comparison into a test case, which fails when the metric regressed.
+// Checks whose significance couldn't be tested are skipped
+func junitSuite(jobName, workloadName string, c Comparison) junitTestSuite {
+	suite := junitTestSuite{
+		Name: jobName + "/" + workloadName,
+	}
+	for _, mc := range c.Metrics {
+		tc := junitTestCase{
+			Name:      fmt.Sprintf("%s %s %s", mc.MetricName, mc.QuantileName, c.Percentile),
+			Classname: jobName + "." + workloadName,
+			SystemOut: &junitOutput{
+				Content: fmt.Sprintf("baseline: %s\ncurrent: %s\ndelta: %+.1f%%\np-value: %s\n",
+					mc.Baseline.format(), mc.Current.format(), mc.Delta, mc.FormatPValue()),
+			},
+		}
+		switch {
+		case mc.Regressed():
+			tc.Failure = &junitMessage{
+				Message: fmt.Sprintf("%s %s increased %+.1f%% (p=%s < %g)", mc.MetricName, mc.QuantileName, mc.Delta, mc.FormatPValue(), c.Significance),
+			}
+			suite.Failures++
+		case c.Test != "" && mc.PValue == nil:
+			tc.Skipped = &junitMessage{
+				Message: "not enough runs to test the significance of the change",
+			}
+			suite.Skipped++
+		}
+		suite.TestCases = append(suite.TestCases, tc)
+	}
+	suite.Tests = len(suite.TestCases)
+	return suite
+}
+
+func writeJUnit(path string, suites ...junitTestSuite) error {
+	data, err := xml.MarshalIndent(junitTestSuites{Suites: suites}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0o644)
+}
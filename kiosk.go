@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Rotation interval of the kiosk, the default one and the shortest one allowed so the charts get time to render
+const (
+	defaultKioskInterval = 30 * time.Second
+	minKioskInterval     = 5 * time.Second
+)
+
+// kioskHandler shows the saved views of the views query parameter, or every saved view, one after the other for wall
+// displays. Every page renders a view and refreshes into the next one after the interval, so the charts are always
+// fresh and the rotation needs no script, nor any state on the server
+func (c *Config) kioskHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	interval := defaultKioskInterval
+	if value := query.Get("interval"); value != "" {
+		var err error
+		if interval, err = time.ParseDuration(value); err != nil || interval < minKioskInterval {
+			http.Error(w, fmt.Sprintf("invalid interval: must be a duration of at least %s, such as 30s", minKioskInterval), http.StatusBadRequest)
+			return
+		}
+	}
+	var views []View
+	if names := splitList(query.Get("views")); len(names) > 0 {
+		// Views deleted since the rotation was set up are skipped
+		for _, name := range names {
+			if view, ok := c.views.get(name); ok {
+				views = append(views, view)
+			}
+		}
+	} else {
+		views = c.views.list()
+	}
+	if len(views) == 0 {
+		http.Error(w, "no saved view to show", http.StatusNotFound)
+		return
+	}
+	position := 0
+	if value := query.Get("i"); value != "" {
+		i, err := strconv.Atoi(value)
+		if err != nil || i < 0 {
+			http.Error(w, "invalid i: must be a positive number", http.StatusBadRequest)
+			return
+		}
+		position = i % len(views)
+	}
+	next := (position + 1) % len(views)
+	nextQuery := maps.Clone(query)
+	nextQuery.Set("i", strconv.Itoa(next))
+
+	type TemplateData struct {
+		View       View
+		Charts     []ViewChartData
+		ChartsJSON template.JS
+		// Position is the position of the view in the rotation, from 1
+		Position int
+		Views    []string
+		Next     string
+		// Refresh is the content of the refresh of the page into the next view
+		Refresh string
+	}
+	view := views[position]
+	charts := c.prepareView(r, view)
+	chartsJSON, _ := json.Marshal(charts)
+	data := TemplateData{
+		View:       view,
+		Charts:     charts,
+		ChartsJSON: template.JS(chartsJSON),
+		Position:   position + 1,
+		Next:       views[next].Name,
+		Refresh:    fmt.Sprintf("%d;url=/kiosk?%s", int(interval.Seconds()), nextQuery.Encode()),
+	}
+	for _, view := range views {
+		data.Views = append(data.Views, view.Name)
+	}
+	c.renderTemplate(w, r, "kiosk.html", data)
+}
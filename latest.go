@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Statuses of the latest run of a workload, from the most to the least severe
+const (
+	latestFailed   = "failed"
+	latestViolated = "violated"
+	latestStale    = "stale"
+	latestOK       = "ok"
+)
+
+// LatestRun is the status of the newest run of a workload at a glance, small enough for CI badges and chatbots
+type LatestRun struct {
+	Job      string
+	Workload string
+	Run      string
+	UUID     string
+	Alias    string `json:",omitempty"`
+	Seq      int
+	Started  time.Time
+	// Age is the number of seconds since the run started
+	Age    int64
+	Passed bool
+	// Status sums the run up: failed when kube-burner failed it, violated when it violated an SLO, stale when the
+	// workload missed runs of its cadence since, and ok otherwise
+	Status string
+	// Metrics are the key metrics of the run, the P99 of the Ready quantile of every metric having one
+	Metrics []LatestMetric
+	SLOs    []LatestSLO `json:",omitempty"`
+	Stale   *Staleness  `json:",omitempty"`
+}
+
+// LatestMetric is a key metric of the latest run, along with its change from the median of the runs before it
+type LatestMetric struct {
+	MetricName string
+	JobName    string `json:",omitempty"`
+	Quantile   string
+	P99        float64
+	// Median is the median of the P99 over the runs before, up to -stability-runs, and Delta the relative change
+	// of the run from it. They're left out for the first run
+	Median float64 `json:",omitempty"`
+	Delta  float64 `json:",omitempty"`
+}
+
+// DeltaPercent returns the change of the run from the median in percent, for display
+func (m LatestMetric) DeltaPercent() float64 {
+	return m.Delta * 100
+}
+
+// LatestSLO is the verdict of an SLO on the latest run
+type LatestSLO struct {
+	Name      string
+	Value     float64
+	Threshold float64
+	Violated  bool
+	// Exhausted is set when the error budget of the SLO is exhausted, the run included
+	Exhausted bool
+}
+
+// latestRun sums the newest of the runs of the workload up
+func (c *Config) latestRun(jobName, workloadName string, runs []Run, now time.Time) LatestRun {
+	run := runs[len(runs)-1]
+	latest := LatestRun{
+		Job:      jobName,
+		Workload: workloadName,
+		Run:      run.Name(),
+		UUID:     run.UUID(),
+		Alias:    c.aliases.get(run.UUID()),
+		Seq:      run.Seq,
+		Started:  run.Started(),
+		Age:      int64(now.Sub(run.Started()).Seconds()),
+		Passed:   run.Summary.Passed,
+		Metrics:  []LatestMetric{},
+	}
+	job := Job{Runs: runs}
+	for _, group := range prepareChartData(&job, ChartOptions{Last: c.stabilityRuns + 1, GroupByJob: true}) {
+		i := slices.IndexFunc(group.Charts, func(chart ChartData) bool {
+			return chart.QuantileName == defaultTrendQuantile
+		})
+		if i < 0 {
+			continue
+		}
+		datapoints := group.Charts[i].Datapoints
+		if len(datapoints) == 0 || datapoints[len(datapoints)-1].Seq != run.Seq {
+			continue
+		}
+		metric := LatestMetric{
+			MetricName: group.MetricName,
+			JobName:    group.JobName,
+			Quantile:   defaultTrendQuantile,
+			P99:        datapoints[len(datapoints)-1].P99,
+		}
+		if previous := datapoints[:len(datapoints)-1]; len(previous) > 0 {
+			values := make([]float64, len(previous))
+			for j, dp := range previous {
+				values[j] = dp.P99
+			}
+			metric.Median = quantile(sortedCopy(values), 0.5)
+			if metric.Median != 0 {
+				metric.Delta = (metric.P99 - metric.Median) / metric.Median
+			}
+		}
+		latest.Metrics = append(latest.Metrics, metric)
+	}
+	var violated bool
+	for _, s := range c.workloadSLOs(jobName, workloadName) {
+		status := sloStatus(s, runs)
+		if len(status.Points) == 0 || status.Points[len(status.Points)-1].Seq != run.Seq {
+			continue
+		}
+		point := status.Points[len(status.Points)-1]
+		latest.SLOs = append(latest.SLOs, LatestSLO{
+			Name:      s.Name,
+			Value:     point.Value,
+			Threshold: s.Threshold,
+			Violated:  point.Violated,
+			Exhausted: status.Exhausted,
+		})
+		violated = violated || point.Violated
+	}
+	workloadPath := filepath.Join(c.resultsDir, jobName, workloadName)
+	cadence, every := loadDescriptor(workloadPath).workloadCadence(loadDescriptor(filepath.Dir(workloadPath)))
+	if !isRetired(workloadPath) {
+		latest.Stale = staleness(cadence, every, latest.Started, now)
+	}
+	switch {
+	case !latest.Passed:
+		latest.Status = latestFailed
+	case violated:
+		latest.Status = latestViolated
+	case latest.Stale != nil:
+		latest.Status = latestStale
+	default:
+		latest.Status = latestOK
+	}
+	return latest
+}
+
+// apiLatestHandler returns the status of the newest run of the workload
+func (c *Config) apiLatestHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("workload %s/%s not found", jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	if len(runs) == 0 {
+		http.Error(w, fmt.Sprintf("workload %s/%s has no runs", jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, c.latestRun(jobName, workloadName, runs, time.Now()))
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"cmp"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const defaultSessionTTL = 12 * time.Hour
+
+var errInvalidCredentials = errors.New("invalid username or password")
+
+// LDAPConfig authenticates the users against an LDAP or Active Directory server, as an alternative to the
+// authenticating proxy. The identities of the users logged in are handed to the policy as if the proxy had set them
+type LDAPConfig struct {
+	// URL of the server, ldap:// or ldaps://
+	URL                string `yaml:"url"`
+	StartTLS           bool   `yaml:"startTLS"`
+	CAFile             string `yaml:"caFile"`
+	InsecureSkipVerify bool   `yaml:"insecureSkipVerify"`
+	// BindDN and BindPassword are the service account searching the users and their groups, the searches are
+	// anonymous when empty. The password expands environment variables
+	BindDN       string `yaml:"bindDN"`
+	BindPassword string `yaml:"bindPassword"`
+	UserBaseDN   string `yaml:"userBaseDN"`
+	// UserFilter finds the entry of the user, %s being the username, such as (sAMAccountName=%s) for Active Directory
+	UserFilter string `yaml:"userFilter"`
+	// GroupAttribute lists the groups of the user entry, such as memberOf
+	GroupAttribute string `yaml:"groupAttribute"`
+	// GroupBaseDN and GroupFilter search the groups instead, %s being the DN of the user, for servers without memberOf
+	GroupBaseDN string `yaml:"groupBaseDN"`
+	GroupFilter string `yaml:"groupFilter"`
+	Roles       Roles  `yaml:"roles"`
+	// SessionTTL is how long users stay logged in
+	SessionTTL string `yaml:"sessionTTL"`
+
+	tlsConfig  *tls.Config
+	sessionTTL time.Duration
+}
+
+// validate checks the LDAP configuration and sets its defaults
+func (l *LDAPConfig) validate() error {
+	u, err := url.Parse(l.URL)
+	if err != nil || (u.Scheme != "ldap" && u.Scheme != "ldaps") || u.Host == "" {
+		return fmt.Errorf("ldap url must be an ldap:// or ldaps:// URL")
+	}
+	if l.UserBaseDN == "" {
+		return fmt.Errorf("ldap userBaseDN is required")
+	}
+	l.UserFilter = cmp.Or(l.UserFilter, "(uid=%s)")
+	l.GroupAttribute = cmp.Or(l.GroupAttribute, "memberOf")
+	l.GroupFilter = cmp.Or(l.GroupFilter, "(member=%s)")
+	for name, filter := range map[string]string{"userFilter": l.UserFilter, "groupFilter": l.GroupFilter} {
+		if strings.Count(filter, "%s") != 1 {
+			return fmt.Errorf("ldap %s must contain %%s once", name)
+		}
+	}
+	if len(l.Roles.Admin) == 0 && len(l.Roles.ReadOnly) == 0 {
+		return fmt.Errorf("ldap roles grant access to no group")
+	}
+	l.sessionTTL = defaultSessionTTL
+	if l.SessionTTL != "" {
+		if l.sessionTTL, err = time.ParseDuration(l.SessionTTL); err != nil || l.sessionTTL <= 0 {
+			return fmt.Errorf("invalid ldap sessionTTL %q", l.SessionTTL)
+		}
+	}
+	l.tlsConfig = &tls.Config{ServerName: u.Hostname(), InsecureSkipVerify: l.InsecureSkipVerify}
+	if l.CAFile != "" {
+		pem, err := os.ReadFile(l.CAFile)
+		if err != nil {
+			return err
+		}
+		l.tlsConfig.RootCAs = x509.NewCertPool()
+		if !l.tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificate found in %s", l.CAFile)
+		}
+	}
+	return nil
+}
+
+// dial connects to the server, bound as the service account when configured
+func (l *LDAPConfig) dial() (*ldap.Conn, error) {
+	conn, err := ldap.DialURL(l.URL, ldap.DialWithTLSConfig(l.tlsConfig), ldap.DialWithDialer(&net.Dialer{Timeout: httpTimeout}))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(httpTimeout)
+	if l.StartTLS {
+		if err := conn.StartTLS(l.tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if err := l.bindService(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (l *LDAPConfig) bindService(conn *ldap.Conn) error {
+	if l.BindDN == "" {
+		return nil
+	}
+	if err := conn.Bind(l.BindDN, os.ExpandEnv(l.BindPassword)); err != nil {
+		return fmt.Errorf("error binding as %s: %v", l.BindDN, err)
+	}
+	return nil
+}
+
+// authenticate binds as the user with the password, and returns the session of the user along with the role granted
+// by their groups
+func (l *LDAPConfig) authenticate(username, password string) (Session, error) {
+	var session Session
+	// Binds without a password are anonymous, and would always succeed
+	if username == "" || password == "" {
+		return session, errInvalidCredentials
+	}
+	conn, err := l.dial()
+	if err != nil {
+		return session, err
+	}
+	defer conn.Close()
+	res, err := conn.Search(ldap.NewSearchRequest(l.UserBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(l.UserFilter, ldap.EscapeFilter(username)), []string{l.GroupAttribute}, nil))
+	if err != nil {
+		return session, fmt.Errorf("error searching user %s: %v", username, err)
+	}
+	if len(res.Entries) != 1 {
+		return session, errInvalidCredentials
+	}
+	entry := res.Entries[0]
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return session, errInvalidCredentials
+		}
+		return session, err
+	}
+	var groups []string
+	if l.GroupBaseDN == "" {
+		for _, dn := range entry.GetAttributeValues(l.GroupAttribute) {
+			groups = append(groups, ldapGroupName(dn))
+		}
+	} else {
+		// Search the groups as the service account again, users may not be allowed to
+		if err := l.bindService(conn); err != nil {
+			return session, err
+		}
+		res, err := conn.Search(ldap.NewSearchRequest(l.GroupBaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
+			fmt.Sprintf(l.GroupFilter, ldap.EscapeFilter(entry.DN)), []string{"cn"}, nil))
+		if err != nil {
+			return session, fmt.Errorf("error searching the groups of %s: %v", username, err)
+		}
+		for _, group := range res.Entries {
+			groups = append(groups, cmp.Or(group.GetAttributeValue("cn"), ldapGroupName(group.DN)))
+		}
+	}
+	session = Session{User: username, Groups: groups, Role: l.Roles.role(groups)}
+	if session.Role == "" {
+		return session, fmt.Errorf("user %s is not a member of any group granted access", username)
+	}
+	session.Expires = time.Now().Add(l.sessionTTL).Unix()
+	return session, nil
+}
+
+// ldapGroupName returns the common name of the group of the DN, such as perf-admins for
+// cn=perf-admins,ou=groups,dc=example,dc=com, or the DN itself when it can't be parsed
+func ldapGroupName(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return dn
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
+
+// ldapSession returns the session of the client of the request, from its auth cookie or the credentials of its
+// basic authentication, the way API clients log in
+func (c *Config) ldapSession(r *http.Request) (Session, bool) {
+	if cookie, err := r.Cookie(authCookie); err == nil {
+		if session, ok := c.verifySession(cookie.Value); ok {
+			return session, true
+		}
+	}
+	if username, password, ok := r.BasicAuth(); ok {
+		session, err := c.policy.LDAP.authenticate(username, password)
+		if err != nil {
+			fmt.Printf("LDAP authentication of %s failed: %v\n", username, err)
+			return session, false
+		}
+		return session, true
+	}
+	return Session{}, false
+}
+
+// loginHandler shows the login form, and logs the users in with the credentials posted, going back to the page they
+// came from
+func (c *Config) loginHandler(w http.ResponseWriter, r *http.Request) {
+	if c.policy == nil || c.policy.LDAP == nil {
+		http.NotFound(w, r)
+		return
+	}
+	next := r.FormValue("next")
+	// Only redirect within the dashboard
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		next = "/"
+	}
+	type TemplateData struct {
+		Next  string
+		User  string
+		Error string
+	}
+	data := TemplateData{Next: next}
+	if r.Method == http.MethodPost {
+		if !sameOrigin(r) {
+			http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+			return
+		}
+		data.User = r.FormValue("username")
+		session, err := c.policy.LDAP.authenticate(data.User, r.FormValue("password"))
+		if err == nil {
+			http.SetCookie(w, &http.Cookie{
+				Name:     authCookie,
+				Value:    c.signSession(session),
+				Path:     "/",
+				MaxAge:   int(c.policy.LDAP.sessionTTL.Seconds()),
+				HttpOnly: true,
+				Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+				SameSite: http.SameSiteLaxMode,
+			})
+			http.Redirect(w, r, next, http.StatusSeeOther)
+			return
+		}
+		fmt.Printf("LDAP authentication of %s failed: %v\n", data.User, err)
+		data.Error = errInvalidCredentials.Error()
+		if !errors.Is(err, errInvalidCredentials) {
+			data.Error = "login failed, check the logs of the dashboard"
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	c.renderTemplate(w, r, "login.html", data)
+}
+
+// logoutHandler clears the session of the user
+func (c *Config) logoutHandler(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: authCookie, Path: "/", MaxAge: -1})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetadataLink links the runs having a metadata field into a related system, such as their Prow job or the Grafana
+// dashboard of their cluster. URL is a Go template executed with the metadata of the run
+type MetadataLink struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	tmpl *template.Template
+}
+
+// MetadataLinks maps metadata fields to the links of the runs having them
+type MetadataLinks map[string]MetadataLink
+
+// RunLink is a link of a run into a related system
+type RunLink struct {
+	Name string
+	URL  string
+}
+
+// metadataLinks are the links configured with -metadata-links, none by default
+var metadataLinks MetadataLinks
+
+// loadMetadataLinks loads the links of the YAML file, parsing their URL templates
+func loadMetadataLinks(linksPath string) error {
+	data, err := os.ReadFile(linksPath)
+	if err != nil {
+		return err
+	}
+	var links MetadataLinks
+	if err := yaml.Unmarshal(data, &links); err != nil {
+		return fmt.Errorf("error parsing metadata links %s: %v", linksPath, err)
+	}
+	for field, link := range links {
+		if link.URL == "" {
+			return fmt.Errorf("metadata link of %s has no url", field)
+		}
+		if link.Name == "" {
+			link.Name = field
+		}
+		link.tmpl, err = template.New(field).Option("missingkey=error").Funcs(templateFuncs).Funcs(template.FuncMap{
+			"pathescape": url.PathEscape,
+		}).Parse(link.URL)
+		if err != nil {
+			return fmt.Errorf("invalid url template of the %s metadata link: %v", field, err)
+		}
+		links[field] = link
+	}
+	metadataLinks = links
+	return nil
+}
+
+// Links returns the links of the run into related systems, in order of metadata field. Links are left out when the
+// run lacks the field, or any other field their template uses
+func (r Run) Links() []RunLink {
+	if len(metadataLinks) == 0 {
+		return nil
+	}
+	metadata := r.metadataFields()
+	var links []RunLink
+	for _, field := range slices.Sorted(maps.Keys(metadataLinks)) {
+		if value, ok := metadata[field]; !ok || value == nil || value == "" {
+			continue
+		}
+		link := metadataLinks[field]
+		var u strings.Builder
+		if err := link.tmpl.Execute(&u, metadata); err != nil {
+			continue
+		}
+		links = append(links, RunLink{Name: link.Name, URL: u.String()})
+	}
+	return links
+}
+
+// runLinks returns the links of the runs having some, by UUID
+func runLinks(runs []Run) map[string][]RunLink {
+	links := make(map[string][]RunLink)
+	for _, run := range runs {
+		if l := run.Links(); len(l) > 0 {
+			links[run.UUID()] = l
+		}
+	}
+	return links
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"golang.org/x/text/message"
+)
+
+// isoDateLayout is the date the layouts of the timestamps are written with, replaced by the date layout of the locale
+const isoDateLayout = "2006-01-02"
+
+// Locale formats the numbers and dates of the pages and CSV exports like a language does, e.g. 1,25s and 31.12.2025
+// in German
+type Locale struct {
+	Tag     language.Tag
+	printer *message.Printer
+	// dateLayout replaces the ISO dates of the layouts timestamps are formatted with
+	dateLayout string
+}
+
+func newLocale(tag language.Tag, dateLayout string) *Locale {
+	return &Locale{Tag: tag, printer: message.NewPrinter(tag), dateLayout: dateLayout}
+}
+
+// locales are the supported locales, English first as the default. English keeps the ISO dates the dashboard always
+// showed
+var locales = []*Locale{
+	newLocale(language.English, isoDateLayout),
+	newLocale(language.Czech, "2. 1. 2006"),
+	newLocale(language.German, "02.01.2006"),
+	newLocale(language.Spanish, "02/01/2006"),
+	newLocale(language.French, "02/01/2006"),
+	newLocale(language.Italian, "02/01/2006"),
+	newLocale(language.Dutch, "02-01-2006"),
+	newLocale(language.Polish, "02.01.2006"),
+	newLocale(language.Portuguese, "02/01/2006"),
+}
+
+var defaultLocale = locales[0]
+
+var localeMatcher = language.NewMatcher(localeTags())
+
+func localeTags() []language.Tag {
+	tags := make([]language.Tag, len(locales))
+	for i, l := range locales {
+		tags[i] = l.Tag
+	}
+	return tags
+}
+
+// localeNames returns the names of the supported locales
+func localeNames() []string {
+	names := make([]string, len(locales))
+	for i, l := range locales {
+		names[i] = l.String()
+	}
+	return names
+}
+
+// parseLocale returns the supported locale of the given language, such as de or de-AT, an empty name is the default
+func parseLocale(name string) (*Locale, error) {
+	if name == "" {
+		return defaultLocale, nil
+	}
+	tag, err := language.Parse(name)
+	if err == nil {
+		base, _ := tag.Base()
+		for _, l := range locales {
+			if b, _ := l.Tag.Base(); b == base {
+				return l, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unsupported locale %s, must be one of %s", name, strings.Join(localeNames(), ", "))
+}
+
+// locale returns the locale the pages of the request are formatted in: the one picked in the preferences of the
+// user, or the language of the browser closest to a supported one, falling back to the one configured with -locale
+func (c *Config) locale(r *http.Request) *Locale {
+	if c == nil || r == nil {
+		return defaultLocale
+	}
+	if name := c.preferences(r).Locale; name != "" {
+		if l, err := parseLocale(name); err == nil {
+			return l
+		}
+	}
+	if tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language")); err == nil && len(tags) > 0 {
+		if _, i, confidence := localeMatcher.Match(tags...); confidence != language.No {
+			return locales[i]
+		}
+	}
+	return cmp.Or(c.fallbackLocale, defaultLocale)
+}
+
+// String returns the name of the locale, such as de
+func (l *Locale) String() string {
+	return l.Tag.String()
+}
+
+// Label returns the name of the language of the locale in itself, such as Deutsch
+func (l *Locale) Label() string {
+	return display.Self.Name(l.Tag)
+}
+
+// sprintf formats like fmt.Sprintf, with the decimal and thousands separators of the locale
+func (l *Locale) sprintf(format string, a ...any) string {
+	return l.printer.Sprintf(format, a...)
+}
+
+// formatTime formats the timestamp like formatTime, with the dates of the layout in the order of the locale
+func (l *Locale) formatTime(t time.Time, loc *time.Location, layout string) string {
+	return formatTime(t, loc, strings.ReplaceAll(layout, isoDateLayout, l.dateLayout))
+}
+
+// decimalComma returns whether the locale writes decimals with a comma, the CSV exports of those locales separate
+// their fields with semicolons, like spreadsheets expect them
+func (l *Locale) decimalComma() bool {
+	return strings.Contains(l.sprintf("%.1f", 0.5), ",")
+}
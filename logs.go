@@ -0,0 +1,297 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// logLinesPerPage is the number of lines of a log page
+const logLinesPerPage = 500
+
+// logFilePattern identifies the log files kube-burner leaves in the run directories
+const logFilePattern = "*.log"
+
+// ansiSequence matches the ANSI escape sequences of colored logs, only SGR ones (ending in m) are rendered
+var ansiSequence = regexp.MustCompile(`\x1b\[([0-9;]*)([A-Za-z])`)
+
+// ansiColors are the names of the 8 ANSI colors, the classes the log viewer styles them with
+var ansiColors = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// LogSegment is a piece of a log line sharing the same style
+type LogSegment struct {
+	Text  string
+	Class string `json:",omitempty"`
+}
+
+// LogLine is a line of a log, split into segments where its style changes
+type LogLine struct {
+	Number   int
+	Segments []LogSegment
+}
+
+// LogPage is a page of a log file of a run
+type LogPage struct {
+	UUID  string
+	File  string
+	Files []string
+	Page  int
+	Pages int
+	// Lines is the total number of lines of the file
+	Lines int
+	// Size is the size of the file in bytes
+	Size    int64
+	Content []LogLine
+	// Links of the run into related systems
+	Links []RunLink `json:",omitempty"`
+}
+
+// Previous returns the number of the previous page, used by the template to link it
+func (p LogPage) Previous() int {
+	return p.Page - 1
+}
+
+// Next returns the number of the next page
+func (p LogPage) Next() int {
+	return p.Page + 1
+}
+
+// ansiStyle is the style set by the SGR sequences so far, kept from one line to the next
+type ansiStyle struct {
+	bold  bool
+	color string
+}
+
+// class returns the classes of the style, empty for the default one
+func (s ansiStyle) class() string {
+	var classes []string
+	if s.bold {
+		classes = append(classes, "ansi-bold")
+	}
+	if s.color != "" {
+		classes = append(classes, "ansi-"+s.color)
+	}
+	return strings.Join(classes, " ")
+}
+
+// apply updates the style with the parameters of an SGR sequence, unsupported ones are ignored
+func (s *ansiStyle) apply(params string) {
+	for _, param := range strings.Split(params, ";") {
+		code, _ := strconv.Atoi(param)
+		switch {
+		case code == 0:
+			*s = ansiStyle{}
+		case code == 1:
+			s.bold = true
+		case code == 22:
+			s.bold = false
+		case code >= 30 && code <= 37:
+			s.color = ansiColors[code-30]
+		case code >= 90 && code <= 97:
+			s.color = ansiColors[code-90]
+		case code == 39:
+			s.color = ""
+		}
+	}
+}
+
+// parseANSI splits the line into segments styled by its SGR sequences, dropping every escape sequence
+func parseANSI(line string, style *ansiStyle) []LogSegment {
+	var segments []LogSegment
+	add := func(text string) {
+		if text != "" {
+			segments = append(segments, LogSegment{Text: text, Class: style.class()})
+		}
+	}
+	start := 0
+	for _, match := range ansiSequence.FindAllStringSubmatchIndex(line, -1) {
+		add(line[start:match[0]])
+		if line[match[4]:match[5]] == "m" {
+			style.apply(line[match[2]:match[3]])
+		}
+		start = match[1]
+	}
+	add(line[start:])
+	return segments
+}
+
+// runLogFiles returns the names of the log files of the run
+func runLogFiles(run Run) ([]string, error) {
+	entries, err := storage.ReadDir(run.Path)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if ok, _ := path.Match(logFilePattern, entry.Name()); ok && !entry.IsDir() {
+			files = append(files, entry.Name())
+		}
+	}
+	return files, nil
+}
+
+// logOptions are the log selection parsed from the query parameters
+type logOptions struct {
+	uuid string
+	file string
+	// page is 1-based, the last page is shown when tailing
+	page int
+	tail bool
+}
+
+func logOptionsFromRequest(r *http.Request) (logOptions, error) {
+	opts := logOptions{
+		uuid: r.URL.Query().Get("uuid"),
+		file: r.URL.Query().Get("file"),
+		page: 1,
+		tail: r.URL.Query().Get("tail") == "true",
+	}
+	if page := r.URL.Query().Get("page"); page != "" {
+		var err error
+		if opts.page, err = strconv.Atoi(page); err != nil || opts.page < 1 {
+			return opts, fmt.Errorf("invalid page: must be a positive number")
+		}
+	}
+	return opts, nil
+}
+
+// logFile is a log file of a run, along with the other log files of the run
+type logFile struct {
+	run   Run
+	files []string
+	name  string
+	data  []byte
+}
+
+// loadLogFile loads the selected log file of the selected run, the first log file of the run when none is selected
+func (c *Config) loadLogFile(jobName, workloadName string, opts logOptions) (logFile, int, error) {
+	var file logFile
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		return file, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	runs = ChartOptions{}.filterRuns(runs)
+	if len(runs) == 0 {
+		return file, http.StatusNotFound, fmt.Errorf("workload %s/%s has no runs", jobName, workloadName)
+	}
+	if file.run, err = selectRun(runs, opts.uuid); err != nil {
+		return file, http.StatusNotFound, err
+	}
+	if file.files, err = runLogFiles(file.run); err != nil {
+		return file, http.StatusInternalServerError, fmt.Errorf("error reading run %s: %v", file.run.Name(), err)
+	}
+	if len(file.files) == 0 {
+		return file, http.StatusNotFound, fmt.Errorf("run %s has no log files", file.run.UUID())
+	}
+	file.name = file.files[0]
+	if opts.file != "" {
+		if !slices.Contains(file.files, opts.file) {
+			return file, http.StatusNotFound, fmt.Errorf("log file %s not found in run %s", opts.file, file.run.UUID())
+		}
+		file.name = opts.file
+	}
+	if file.data, err = storage.ReadFile(filepath.Join(file.run.Path, file.name)); err != nil {
+		return file, http.StatusInternalServerError, fmt.Errorf("error reading log file %s: %v", file.name, err)
+	}
+	return file, http.StatusOK, nil
+}
+
+// loadLogPage loads the page of the selected log file of a run
+func (c *Config) loadLogPage(r *http.Request, jobName, workloadName string) (LogPage, int, error) {
+	opts, err := logOptionsFromRequest(r)
+	if err != nil {
+		return LogPage{}, http.StatusBadRequest, err
+	}
+	file, status, err := c.loadLogFile(jobName, workloadName, opts)
+	page := LogPage{
+		UUID:  file.run.UUID(),
+		File:  file.name,
+		Files: file.files,
+		Links: file.run.Links(),
+	}
+	if err != nil {
+		return page, status, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(file.data), "\n"), "\n")
+	page.Lines = len(lines)
+	page.Size = int64(len(file.data))
+	page.Pages = max(1, (len(lines)+logLinesPerPage-1)/logLinesPerPage)
+	page.Page = min(opts.page, page.Pages)
+	if opts.tail {
+		page.Page = page.Pages
+	}
+	first, last := (page.Page-1)*logLinesPerPage, min(page.Page*logLinesPerPage, len(lines))
+	// Styles carry over lines, so the lines before the page are parsed as well
+	var style ansiStyle
+	for i, line := range lines[:last] {
+		segments := parseANSI(line, &style)
+		if i >= first {
+			page.Content = append(page.Content, LogLine{Number: i + 1, Segments: segments})
+		}
+	}
+	return page, http.StatusOK, nil
+}
+
+func (c *Config) apiLogsHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	page, status, err := c.loadLogPage(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, page)
+}
+
+// logsHandler renders a page of a log file of a run, or downloads the whole file when download=true
+func (c *Config) logsHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	if r.URL.Query().Get("download") == "true" {
+		opts, err := logOptionsFromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		file, status, err := c.loadLogFile(jobName, workloadName, opts)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", file.run.UUID()+"-"+file.name))
+		w.Write(file.data)
+		return
+	}
+	page, status, err := c.loadLogPage(r, jobName, workloadName)
+	if err != nil && status == http.StatusBadRequest {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	runs, _ := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	type TemplateData struct {
+		JobName      string
+		WorkloadName string
+		Log          LogPage
+		Runs         []Run
+		Tail         bool
+		Error        string
+	}
+	data := TemplateData{
+		JobName:      jobName,
+		WorkloadName: workloadName,
+		Log:          page,
+		Runs:         ChartOptions{}.filterRuns(runs),
+		Tail:         r.URL.Query().Get("tail") == "true",
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "logs.html", data)
+}
@@ -1,20 +1,26 @@
 package main
 
 import (
+	"cmp"
+	"crypto/tls"
 	"embed"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"html/template"
+	"io"
 	"io/fs"
 	"log"
+	"maps"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/kube-burner/kube-burner/v2/pkg/burner"
 )
 
@@ -24,6 +30,9 @@ var staticFiles embed.FS
 //go:embed templates
 var templateFiles embed.FS
 
+// loadLog receives the diagnostics of the result loaders, subcommands send them to stderr to keep their output clean
+var loadLog io.Writer = os.Stdout
+
 type Measurement struct {
 	QuantileName string    `json:"quantileName"`
 	UUID         string    `json:"uuid"`
@@ -42,41 +51,258 @@ type Measurement struct {
 type Config struct {
 	resultsDir string
 	port       int
+	hub        *wsHub
+	cors       corsConfig
+	rateLimit  rateLimitConfig
+	readOnly   bool
+	policy     *Policy
+	features   *featureFlags
+	watcher    *fsnotify.Watcher
+	// When set, the results directory is polled at this interval instead of being watched
+	refreshInterval time.Duration
+	rescan          chan struct{}
+	// Comparisons over fewer runs than minSamples are flagged as inconclusive
+	minSamples int
+	// stabilityRuns is the number of most recent runs the stability of the workloads is computed over
+	stabilityRuns int
+	// Time zone timestamps are displayed in, nil shows them as recorded
+	location *time.Location
+	// fallbackLocale formats the numbers and dates of the pages when neither the preferences nor the browser pick a
+	// supported locale
+	fallbackLocale *Locale
+	notify         *NotifyConfig
+	// Preferences of the authenticated users, such as their pinned workloads
+	prefs *preferencesStore
+	views *viewsStore
+	// aliases are the human names of the runs, shown along or instead of their UUIDs
+	aliases *aliasStore
+	// anomalies are the verdicts of the anomaly detector on the runs ingested
+	anomalies *anomalyStore
+	// tokens are the API tokens of the clients such as CI pipelines
+	tokens *tokenStore
+	// shareKey signs the share links, and the charts of the Slack replies
+	shareKey []byte
+	// slack answers the slash command of the Slack app, when set
+	slack *SlackConfig
+	// analytics holds the databases of the SQL queries, nil when they're disabled
+	analytics *analyticsStore
+	// remoteWrite receives the quantile values of the runs ingested, when set
+	remoteWrite *RemoteWriteConfig
+	// analyzers are run over the workloads as their runs are added, when set
+	analyzers *analyzers
+	// reports are rendered and published on a schedule, when set
+	reports *reportSchedule
+	// templates are the templates of the pages
+	templates *pageTemplates
+	// defaultJob, a job or job/workload, or else defaultView, a saved view, is the page / lands on instead of the job
+	// list
+	defaultJob  string
+	defaultView string
 }
 
+// Feature flags that can be toggled at runtime
+const (
+	featureLiveUpdates = "live-updates"
+	featureRateLimit   = "rate-limit"
+)
+
 type Job struct {
 	Name      string
-	Runs      []Run
-	Path      string
+	Runs      []Run  `json:",omitempty"`
+	Path      string `json:"-"`
 	Workloads []Workload
+	Retired   bool `json:",omitempty"`
+	// StaleWorkloads is the number of workloads that missed runs of their cadence, only set for the job list
+	StaleWorkloads int `json:",omitempty"`
+	Descriptor
 }
 
 type Workload struct {
 	Name     string
-	Path     string
+	Path     string `json:"-"`
 	Job      string
 	RunCount int
+	Retired  bool `json:",omitempty"`
+	Descriptor
+	// Stability of the recent runs, only computed for the workload listings
+	Stability *Stability `json:",omitempty"`
+	// Anomalies is the number of anomalous metrics of the latest run checked, only set for the workload listings
+	Anomalies int `json:",omitempty"`
+	// Stale is set when the workload missed runs of its cadence, only set for the workload listings
+	Stale *Staleness `json:",omitempty"`
 }
 
 type Run struct {
 	Measurements []Measurement
-	Summary      burner.JobSummary
-	Path         string
+	// Summary is the summary of the first job run by kube-burner
+	Summary burner.JobSummary
+	// Summaries are the summaries of every job run by kube-burner, such as the create and delete phases of a
+	// workload, in the order they ran
+	Summaries []burner.JobSummary `json:",omitempty"`
+	Path      string              `json:"-"`
+	// Seq is the position of the run within its workload, in order of start time, starting at 1
+	Seq int
+	// Duplicates are the directories of the ignored copies of the run, sharing its UUID
+	Duplicates []string `json:",omitempty"`
+	// Signature is the outcome of the verification of the signature of the run, nil when it isn't signed
+	Signature *RunSignature `json:",omitempty"`
+	// Modified is how the files of the run changed since their checksums were computed, empty when they didn't
+	Modified string `json:",omitempty"`
+	// Promotion is where the run was promoted from, nil when it wasn't
+	Promotion *RunPromotion `json:",omitempty"`
+}
+
+// UUID returns the UUID of the run, taken from the measurements when the job summary is missing
+func (r Run) UUID() string {
+	if r.Summary.UUID != "" {
+		return r.Summary.UUID
+	}
+	for _, m := range r.Measurements {
+		if m.UUID != "" {
+			return m.UUID
+		}
+	}
+	return ""
+}
+
+// Started returns the start time of the run, taken from the job summary or, when missing, from the earliest
+// measurement
+func (r Run) Started() time.Time {
+	if !r.Summary.Timestamp.IsZero() {
+		return r.Summary.Timestamp
+	}
+	var started time.Time
+	for _, m := range r.Measurements {
+		if !m.Timestamp.IsZero() && (started.IsZero() || m.Timestamp.Before(started)) {
+			started = m.Timestamp
+		}
+	}
+	return started
+}
+
+// Phases returns the names of the kube-burner jobs of the run, in the order they ran
+func (r Run) Phases() []string {
+	var phases []string
+	for _, summary := range r.Summaries {
+		if name := summary.JobConfig.Name; name != "" && !slices.Contains(phases, name) {
+			phases = append(phases, name)
+		}
+	}
+	return phases
+}
+
+// PhaseSummary returns the summary of the kube-burner job of the given name, the first one when not found
+func (r Run) PhaseSummary(jobName string) burner.JobSummary {
+	for _, summary := range r.Summaries {
+		if summary.JobConfig.Name == jobName {
+			return summary
+		}
+	}
+	return r.Summary
+}
+
+// selectRun returns the run of the UUID among the runs sorted by start time, the most recent one when no UUID is given
+func selectRun(runs []Run, uuid string) (Run, error) {
+	if uuid == "" {
+		return runs[len(runs)-1], nil
+	}
+	i := slices.IndexFunc(runs, func(run Run) bool { return run.UUID() == uuid })
+	if i < 0 {
+		return Run{}, fmt.Errorf("run %s not found", uuid)
+	}
+	return runs[i], nil
+}
+
+// PhaseSummary summarizes a kube-burner job across the runs of a workload
+type PhaseSummary struct {
+	Name        string
+	Runs        int
+	Passed      int
+	MeanElapsed float64
+}
+
+// phaseSummaries summarizes the kube-burner jobs found in the runs, in the order they first ran
+func phaseSummaries(runs []Run) []PhaseSummary {
+	var phases []PhaseSummary
+	var elapsed [][]float64
+	for _, run := range runs {
+		for _, summary := range run.Summaries {
+			name := summary.JobConfig.Name
+			if name == "" {
+				continue
+			}
+			i := slices.IndexFunc(phases, func(p PhaseSummary) bool { return p.Name == name })
+			if i < 0 {
+				i = len(phases)
+				phases = append(phases, PhaseSummary{Name: name})
+				elapsed = append(elapsed, nil)
+			}
+			phases[i].Runs++
+			if summary.Passed {
+				phases[i].Passed++
+			}
+			elapsed[i] = append(elapsed[i], summary.ElapsedTime)
+		}
+	}
+	for i := range phases {
+		phases[i].MeanElapsed = mean(elapsed[i])
+	}
+	return phases
+}
+
+// Name returns the name of the run directory
+func (r Run) Name() string {
+	return filepath.Base(r.Path)
+}
+
+// Metadata returns the metadata attached to the measurements of the run, such as the OCP version, along with
+// the kube-burner version that produced it
+func (r Run) Metadata() map[string]any {
+	metadata := make(map[string]any)
+	for _, m := range r.Measurements {
+		if md, ok := m.Metadata.(map[string]any); ok {
+			maps.Copy(metadata, md)
+		}
+	}
+	if r.Summary.Version != "" {
+		metadata["version"] = r.Summary.Version
+	}
+	return metadata
+}
+
+// metadataFields returns the fields of the job summary, such as the cluster name or the node counts, along with the
+// metadata of the run
+func (r Run) metadataFields() map[string]any {
+	metadata := maps.Clone(r.Summary.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]any)
+	}
+	maps.Copy(metadata, r.Metadata())
+	return metadata
 }
 
 type ChartData struct {
 	MetricName   string
 	QuantileName string
-	Datapoints   []DataPoint
+	// Description explains the quantile, shown as a tooltip, empty when undocumented
+	Description string `json:",omitempty"`
+	Datapoints  []DataPoint
 }
 
 type MetricGroup struct {
 	MetricName string
-	Charts     []ChartData
+	// JobName is the kube-burner job of the measurements when grouped by job, and the workload runs several
+	JobName     string `json:",omitempty"`
+	Description string `json:",omitempty"`
+	Charts      []ChartData
 }
 
 type DataPoint struct {
-	Timestamp  time.Time
+	Timestamp time.Time
+	// Seq is the sequence number of the run
+	Seq int
+	// X is the position of the datapoint in the selected x axis mode
+	X          float64
 	P99        float64
 	P95        float64
 	P50        float64
@@ -84,16 +310,323 @@ type DataPoint struct {
 	Max        float64
 	Avg        float64
 	JobSummary burner.JobSummary
+	// Series is the value of the metadata field the series are split by, empty when they aren't
+	Series string `json:",omitempty"`
+	// Runs is the number of runs aggregated into the datapoint, only set when bucketing them
+	Runs int `json:",omitempty"`
+	// percentiles selected for serialization, all of them when empty
+	percentiles []string
+}
+
+// readOnlyRoutes are the requests, besides the GET, HEAD and OPTIONS ones, still served in read-only mode, kept along
+// the routes of main. Only the sessions and the settings of the users are, every other write is denied
+var readOnlyRoutes = []string{
+	"/login",
+	"/logout",
+	"/preferences",
+	"/pins",
 }
 
 func main() {
-	resultsDir := flag.String("results-dir", "results", "Path to the directory holding results")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "compare":
+			os.Exit(compareCommand(os.Args[2:]))
+		case "baseline":
+			os.Exit(baselineCommand(os.Args[2:]))
+		case "bisect":
+			os.Exit(bisectCommand(os.Args[2:]))
+		case "diff":
+			os.Exit(diffCommand(os.Args[2:]))
+		case "tui":
+			os.Exit(tuiCommand(os.Args[2:]))
+		case "report":
+			os.Exit(reportCommand(os.Args[2:]))
+		case "index":
+			os.Exit(indexCommand(os.Args[2:]))
+		case "export":
+			os.Exit(exportCommand(os.Args[2:]))
+		case "grafana-dashboard":
+			os.Exit(grafanaDashboardCommand(os.Args[2:]))
+		case "token":
+			os.Exit(tokenCommand(os.Args[2:]))
+		case "backup":
+			os.Exit(backupCommand(os.Args[2:]))
+		case "restore":
+			os.Exit(restoreCommand(os.Args[2:]))
+		case "summary":
+			os.Exit(summaryCommand(os.Args[2:]))
+		}
+	}
+	resultsDir := flag.String("results-dir", "results", "Path or URL of the directory holding results")
 	port := flag.Int("port", 8080, "Port to listen on")
+	tlsCertFile := flag.String("tls-cert-file", "", "Path to the PEM certificate the dashboard serves HTTPS with, plain HTTP when empty")
+	tlsKeyFile := flag.String("tls-key-file", "", "Path to the PEM private key of -tls-cert-file")
+	clientCAFile := flag.String("client-ca-file", "", "Path to the PEM bundle of the CAs client certificates are verified against, enabling mutual TLS. Requires -tls-cert-file")
+	corsOrigins := flag.String("cors-allowed-origins", "", "Comma separated list of origins allowed to query the API, use * to allow any origin")
+	corsMethods := flag.String("cors-allowed-methods", "GET,HEAD,OPTIONS", "Comma separated list of methods allowed in cross-origin API requests")
+	corsHeaders := flag.String("cors-allowed-headers", "Accept,Content-Type,Authorization", "Comma separated list of headers allowed in cross-origin API requests")
+	rateLimit := flag.Float64("rate-limit", 20, "Maximum number of requests per second allowed per client, 0 disables rate limiting")
+	rateBurst := flag.Int("rate-limit-burst", 50, "Number of requests a client can burst over the rate limit")
+	maxBodySize := flag.Int64("max-body-size", 32<<20, "Maximum size in bytes of request bodies, 0 disables the limit")
+	readOnly := flag.Bool("read-only", false, "Disable all the endpoints that modify data")
+	policyFile := flag.String("policy-file", "", "Path to the YAML policy file mapping users and groups to the jobs they can access")
+	minSamples := flag.Int("min-samples", defaultMinSamples, "Minimum number of runs per group for a comparison to be conclusive")
+	stabilityRuns := flag.Int("stability-runs", defaultStabilityRuns, "Number of most recent runs the stability score of the workloads is computed over")
+	refreshInterval := flag.Duration("refresh-interval", 0, "Poll the results directory at this interval instead of watching it for changes, 0 disables polling")
+	timezone := flag.String("timezone", "", "Time zone timestamps are displayed in, such as Europe/Madrid or UTC, as recorded when empty")
+	localeName := flag.String("locale", "", "Locale numbers and dates are formatted in when the browser of the user doesn't ask for a supported one, such as de or fr, English when empty")
+	metadataLinksFile := flag.String("metadata-links", "", "Path to a YAML file mapping metadata fields to the URL templates linking the runs into related systems")
+	pricingFile := flag.String("pricing", "", "Path to a YAML file with the hourly price of the instance types, enabling the cost estimation of the runs")
+	sloFile := flag.String("slo-file", "", "Path to a YAML file defining the SLOs of the workloads, enabling the tracking of their error budgets")
+	metricDocsFile := flag.String("metric-docs", "", "Path to a YAML file documenting metrics and quantiles, on top of the built-in kube-burner ones")
+	derivedMetricsFile := flag.String("derived-metrics", "", "Path to a YAML file declaring metrics derived from the measurements of the runs by functions of WASM modules")
+	metricOwnersFile := flag.String("metric-owners", "", "Path to a YAML file mapping metric patterns to the teams owning them, routing the regression notifications and grouping the reports by owner")
+	metadataSchema := flag.String("metadata-schema", "", "Path to a JSON Schema the job summaries of the runs must conform to, runs violating it are rejected")
+	measurementSchema := flag.String("measurement-schema", "", "Path to a JSON Schema the measurement files of the runs must conform to, files violating it are ignored")
+	cosignKey := flag.String("cosign-key", "", "Path to the PEM public keys of cosign trusted to sign the runs")
+	gpgKeyring := flag.String("gpg-keyring", "", "Path to the armored GPG public keys trusted to sign the runs")
+	flag.BoolVar(&runChecksums, "checksums", false, "Write the sha256sums file of the local runs ingested without one, and flag the runs whose files changed since")
+	measurementFilesSpec := flag.String("measurement-files", "", "Comma separated list of pattern=parser assignments identifying the measurement files of the runs, the parser being quantiles or raw, kube-burner naming when empty")
+	strict := flag.Bool("strict", false, "Load every run at startup, printing a summary of the results found, and refuse to start when the results directory is missing or unreadable")
+	preferencesFile := flag.String("preferences-file", "", "Path to the JSON file persisting the preferences of authenticated users and browser sessions, kept in memory when empty")
+	viewsFile := flag.String("views-file", "", "Path to the JSON file persisting the saved custom views, kept in memory when empty")
+	aliasesFile := flag.String("aliases-file", "", "Path to the JSON file mapping run UUIDs to human names, such as \"rc.3 candidate\", also set through the API, kept in memory when empty")
+	anomaliesFile := flag.String("anomalies-file", "", "Path to the JSON file persisting the anomalies found in the runs ingested, kept in memory when empty")
+	tokensFile := flag.String("tokens-file", "", "Path to the JSON file persisting the API tokens, kept in memory when empty")
+	shareKeyFile := flag.String("share-key-file", "", "Path to the file holding the key signing the share links, a random key invalidating them on restart is used when empty")
+	slackConfig := flag.String("slack-config", "", "Path to the YAML file configuring the Slack app whose slash command, such as /perf, is answered at /slack/commands")
+	sqlQueries := flag.Bool("sql", false, "Enable read-only SQL queries over the measurements, loaded into an in-memory SQLite database")
+	remoteWriteConfig := flag.String("remote-write-config", "", "Path to the YAML file configuring the Prometheus remote-write endpoint the quantile values of the runs ingested are written to")
+	reportCron := flag.String("report-cron", "", "Cron schedule rendering and publishing the reports of the jobs, such as \"0 7 * * 1\" for Mondays at 7:00, in the -timezone time zone")
+	reportJobs := flag.String("report-jobs", "", "Comma separated list of patterns of the jobs reported on by -report-cron, every active job when empty")
+	reportTarget := flag.String("report-target", "", "Where -report-cron publishes the reports, a directory, s3://bucket/prefix or mailto:recipient,... emailed through the SMTP server of -notify-config")
+	analyzersConfig := flag.String("analyzers-config", "", "Path to the YAML file declaring the analyzers, commands run over the runs of the workloads and contributing their findings")
+	defaultJob := flag.String("default-job", "", "Job, or job/workload, the root page redirects to instead of listing the jobs, such as on wall displays")
+	defaultView := flag.String("default-view", "", "Saved view the root page redirects to instead of listing the jobs, such as on wall displays")
+	devTemplates := flag.String("dev-templates", "", "Directory to read the page templates from on every request instead of the embedded ones, so edits show without rebuilding, such as templates")
+	notifyConfig := flag.String("notify-config", "", "Path to the YAML file configuring the notifications, such as the daily digests and the regression issues and alerts")
 	flag.Parse()
+	location, err := loadTimezone(*timezone)
+	if err != nil {
+		log.Fatal(err)
+	}
+	locale, err := parseLocale(*localeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *metricDocsFile != "" {
+		if err := loadMetricDocs(*metricDocsFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *derivedMetricsFile != "" {
+		if err := loadDerivedMetrics(*derivedMetricsFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *metricOwnersFile != "" {
+		if err := loadMetricOwners(*metricOwnersFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *metadataLinksFile != "" {
+		if err := loadMetadataLinks(*metadataLinksFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *pricingFile != "" {
+		if err := loadPricing(*pricingFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *sloFile != "" {
+		if err := loadSLOs(*sloFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *measurementFilesSpec != "" {
+		measurementFiles, err = parseMeasurementFiles(*measurementFilesSpec)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	for kind, schemaPath := range map[string]string{metadataDocument: *metadataSchema, measurementDocument: *measurementSchema} {
+		if schemaPath == "" {
+			continue
+		}
+		if err := loadSchema(kind, schemaPath); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *cosignKey != "" {
+		if err := loadCosignKeys(*cosignKey); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *gpgKeyring != "" {
+		if err := loadGPGKeyring(*gpgKeyring); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if !localResults() && *refreshInterval == 0 {
+		*refreshInterval = defaultRemoteRefreshInterval
+	}
+	var policy *Policy
+	if *policyFile != "" {
+		var err error
+		policy, err = loadPolicy(*policyFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if (*tlsCertFile == "") != (*tlsKeyFile == "") {
+		log.Fatal("-tls-cert-file and -tls-key-file must be given together")
+	}
+	var tlsConfig *tls.Config
+	if *clientCAFile != "" {
+		if *tlsCertFile == "" {
+			log.Fatal("-client-ca-file requires -tls-cert-file")
+		}
+		clientCAs, err := loadClientCAs(*clientCAFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		// Clients without certificates are still served, authenticated by LDAP or the proxy
+		tlsConfig = &tls.Config{ClientCAs: clientCAs, ClientAuth: tls.VerifyClientCertIfGiven}
+	}
+	if policy != nil && policy.ClientCerts != nil && tlsConfig == nil {
+		log.Fatal("the clientCerts of the policy require -client-ca-file")
+	}
+	prefs, err := loadPreferences(*preferencesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	views, err := loadViews(*viewsFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	aliases, err := loadAliases(*aliasesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	anomalies, err := loadAnomalies(*anomaliesFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tokens, err := loadTokens(*tokensFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	shareKey, err := loadShareKey(*shareKeyFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var slack *SlackConfig
+	if *slackConfig != "" {
+		slack, err = loadSlackConfig(*slackConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	var remoteWrite *RemoteWriteConfig
+	if *remoteWriteConfig != "" {
+		remoteWrite, err = loadRemoteWriteConfig(*remoteWriteConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	var runAnalyzers *analyzers
+	if *analyzersConfig != "" {
+		runAnalyzers, err = loadAnalyzers(*analyzersConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	var notify *NotifyConfig
+	if *notifyConfig != "" {
+		notify, err = loadNotifyConfig(*notifyConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	templates, err := loadPageTemplates(*devTemplates)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *defaultJob != "" && *defaultView != "" {
+		log.Fatal("-default-job and -default-view are mutually exclusive")
+	}
+	if *defaultView != "" {
+		if _, ok := views.get(*defaultView); !ok {
+			fmt.Printf("Warning: default view %s not found, / lists the jobs until it's saved\n", *defaultView)
+		}
+	}
+	var reports *reportSchedule
+	if *reportCron != "" {
+		reports, err = newReportSchedule(*reportCron, splitList(*reportJobs), *reportTarget, notify)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
 	c := newConfig(
-		withResultsDir(*resultsDir),
+		withResultsDir(root),
 		WithListenPort(*port),
+		withCORS(splitList(*corsOrigins), splitList(*corsMethods), splitList(*corsHeaders)),
+		withRateLimit(*rateLimit, *rateBurst, *maxBodySize),
+		withReadOnly(*readOnly),
+		withPolicy(policy),
+		withMinSamples(*minSamples),
+		withStabilityRuns(*stabilityRuns),
+		withRefreshInterval(*refreshInterval),
+		withTimezone(location),
+		withLocale(locale),
+		withNotify(notify),
+		withPreferences(prefs),
+		withViews(views),
+		withAliases(aliases),
+		withAnomalies(anomalies),
+		withTokens(tokens),
+		withShareKey(shareKey),
+		withSlack(slack),
+		withAnalytics(*sqlQueries),
+		withRemoteWrite(remoteWrite),
+		withAnalyzers(runAnalyzers),
+		withReportSchedule(reports),
+		withTemplates(templates),
+		withLanding(*defaultJob, *defaultView),
 	)
+	writeChecksums = !c.readOnly
+	if *strict {
+		if err := selfCheck(c.resultsDir); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if c.notify != nil {
+		if c.notify.routed(notifyLoadError) {
+			diagnostics.onNew = c.notifyLoadError
+		}
+		c.scheduleDigests()
+		c.watchRegressions()
+		if c.notify.routed(notifyStale) {
+			c.watchCadences()
+		}
+	}
+	if c.reports != nil {
+		c.scheduleReports()
+	}
+	c.analyzeAll()
+
+	// Watch the results directory to push live updates to websocket clients
+	if c.refreshInterval > 0 {
+		c.pollResults(c.refreshInterval)
+	} else if err := c.watchResults(); err != nil {
+		fmt.Println("Error watching results directory, live updates disabled:", err)
+	}
 
 	// Serve static files from embedded filesystem
 	staticFS, err := fs.Sub(staticFiles, "static")
@@ -102,16 +635,68 @@ func main() {
 	}
 	http.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(staticFS))))
 
-	// Route handlers
+	// Route handlers, the ones serving other methods than GET in read-only mode are listed in readOnlyRoutes
 	http.HandleFunc("/", c.jobListHandler)
+	http.HandleFunc("GET /jobs", c.jobListHandler)
 	http.HandleFunc("/job/", c.jobDetailHandler)
+	http.HandleFunc("GET /job/{job}/scalability", c.checkPathNames(c.scalabilityHandler))
+	http.HandleFunc("GET /job/{job}/wins", c.checkPathNames(c.winsHandler))
+	http.HandleFunc("GET /job/{job}/{workload}/fragments/{fragment}", c.checkPathNames(c.fragmentHandler))
+	http.HandleFunc("GET /compare/{runs}", c.comparePermalinkHandler)
+	http.HandleFunc("GET /summary/{runs}", c.summaryHandler)
+	http.HandleFunc("GET /matrix", c.matrixHandler)
+	http.HandleFunc("GET /metrics-docs", c.metricDocsHandler)
+	http.HandleFunc("POST /pins", c.pinHandler)
+	http.HandleFunc("GET /preferences", c.preferencesHandler)
+	http.HandleFunc("POST /preferences", c.savePreferencesHandler)
+	http.HandleFunc("GET /diagnostics", c.diagnosticsHandler)
+	http.HandleFunc("GET /views", c.viewsHandler)
+	http.HandleFunc("POST /views", c.saveViewHandler)
+	http.HandleFunc("GET /views/{name}", c.viewHandler)
+	http.HandleFunc("GET /kiosk", c.kioskHandler)
+	http.HandleFunc("POST /views/{name}/delete", c.deleteViewHandler)
+	http.HandleFunc("/login", c.loginHandler)
+	http.HandleFunc("POST /logout", c.logoutHandler)
+	http.HandleFunc("POST /promote", c.promoteHandler)
+	http.HandleFunc("POST /share", c.createShareHandler)
+	http.HandleFunc("GET /share/{token}", c.shareHandler)
+	http.HandleFunc("GET /sql", c.sqlHandler)
+	if c.slack != nil {
+		http.HandleFunc("POST /slack/commands", c.slackCommandHandler)
+		http.HandleFunc("GET /slack/charts/{token}", c.slackChartHandler)
+	}
+	http.HandleFunc("/ws", c.wsHandler)
+	http.Handle("/api/", c.corsMiddleware(c.apiRoutes()))
+	admin := c.adminRoutes()
+	http.Handle("/admin", admin)
+	http.Handle("/admin/", admin)
 
 	fmt.Printf("Server starting on :%d\n", c.port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", c.port), nil))
+	// Rate limits apply after authentication, so users behind the proxy get their own
+	handler := c.authMiddleware(c.rateLimitMiddleware(c.readOnlyMiddleware(c.bodyLimitMiddleware(http.DefaultServeMux))))
+	server := &http.Server{Addr: fmt.Sprintf(":%d", c.port), Handler: handler, TLSConfig: tlsConfig}
+	if *tlsCertFile != "" {
+		log.Fatal(server.ListenAndServeTLS(*tlsCertFile, *tlsKeyFile))
+	}
+	log.Fatal(server.ListenAndServe())
 }
 
 func newConfig(options ...func(*Config)) *Config {
-	c := &Config{}
+	c := &Config{
+		hub:           newHub(),
+		minSamples:    defaultMinSamples,
+		stabilityRuns: defaultStabilityRuns,
+		prefs:         &preferencesStore{users: make(map[string]UserPreferences)},
+		views:         &viewsStore{views: make(map[string]View)},
+		aliases:       &aliasStore{aliases: make(map[string]string)},
+		anomalies:     &anomalyStore{runs: make(map[string]RunAnomalies)},
+		tokens:        &tokenStore{tokens: make(map[string]APIToken)},
+		shareKey:      randomShareKey(),
+		features: newFeatureFlags(map[string]bool{
+			featureLiveUpdates: true,
+			featureRateLimit:   true,
+		}),
+	}
 	for _, o := range options {
 		o(c)
 	}
@@ -130,37 +715,213 @@ func WithListenPort(port int) func(*Config) {
 	}
 }
 
-func (c *Config) jobListHandler(w http.ResponseWriter, r *http.Request) {
-	jobs, err := loadJobs(c.resultsDir)
-	if err != nil {
-		fmt.Println("Error loading jobs:", err)
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+func withCORS(origins, methods, headers []string) func(*Config) {
+	return func(c *Config) {
+		c.cors = corsConfig{
+			allowedOrigins: origins,
+			allowedMethods: methods,
+			allowedHeaders: headers,
+		}
 	}
+}
 
-	templateFS, err := fs.Sub(templateFiles, "templates")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+func withRateLimit(rate float64, burst int, maxBodyBytes int64) func(*Config) {
+	return func(c *Config) {
+		c.rateLimit = rateLimitConfig{
+			rate:         rate,
+			burst:        burst,
+			maxBodyBytes: maxBodyBytes,
+		}
 	}
+}
 
-	templateData, err := fs.ReadFile(templateFS, "jobs.html")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+func withReadOnly(readOnly bool) func(*Config) {
+	return func(c *Config) {
+		c.readOnly = readOnly
 	}
+}
 
-	t, err := template.New("jobs.html").Parse(string(templateData))
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+func withPolicy(policy *Policy) func(*Config) {
+	return func(c *Config) {
+		c.policy = policy
+	}
+}
+
+func withMinSamples(minSamples int) func(*Config) {
+	return func(c *Config) {
+		c.minSamples = minSamples
+	}
+}
+
+func withStabilityRuns(runs int) func(*Config) {
+	return func(c *Config) {
+		c.stabilityRuns = runs
+	}
+}
+
+func withRefreshInterval(interval time.Duration) func(*Config) {
+	return func(c *Config) {
+		c.refreshInterval = interval
+	}
+}
+
+func withTimezone(loc *time.Location) func(*Config) {
+	return func(c *Config) {
+		c.location = loc
+	}
+}
+
+func withLocale(locale *Locale) func(*Config) {
+	return func(c *Config) {
+		c.fallbackLocale = locale
+	}
+}
+
+func withNotify(notify *NotifyConfig) func(*Config) {
+	return func(c *Config) {
+		c.notify = notify
+	}
+}
+
+func withPreferences(prefs *preferencesStore) func(*Config) {
+	return func(c *Config) {
+		c.prefs = prefs
+	}
+}
+
+func withViews(views *viewsStore) func(*Config) {
+	return func(c *Config) {
+		c.views = views
+	}
+}
+
+func withAliases(aliases *aliasStore) func(*Config) {
+	return func(c *Config) {
+		c.aliases = aliases
+	}
+}
+
+func withAnomalies(anomalies *anomalyStore) func(*Config) {
+	return func(c *Config) {
+		c.anomalies = anomalies
+	}
+}
+
+func withTokens(tokens *tokenStore) func(*Config) {
+	return func(c *Config) {
+		c.tokens = tokens
+	}
+}
+
+func withShareKey(key []byte) func(*Config) {
+	return func(c *Config) {
+		c.shareKey = key
+	}
+}
+
+func withSlack(slack *SlackConfig) func(*Config) {
+	return func(c *Config) {
+		c.slack = slack
+	}
+}
+
+func withRemoteWrite(rw *RemoteWriteConfig) func(*Config) {
+	return func(c *Config) {
+		c.remoteWrite = rw
+	}
+}
+
+func withAnalyzers(a *analyzers) func(*Config) {
+	return func(c *Config) {
+		c.analyzers = a
+	}
+}
+
+func withReportSchedule(reports *reportSchedule) func(*Config) {
+	return func(c *Config) {
+		c.reports = reports
+	}
+}
+
+func withTemplates(templates *pageTemplates) func(*Config) {
+	return func(c *Config) {
+		c.templates = templates
+	}
+}
+
+func withLanding(defaultJob, defaultView string) func(*Config) {
+	return func(c *Config) {
+		c.defaultJob = strings.Trim(defaultJob, "/")
+		c.defaultView = defaultView
+	}
+}
+
+func withAnalytics(enabled bool) func(*Config) {
+	return func(c *Config) {
+		if enabled {
+			c.analytics = newAnalyticsStore()
+		}
+	}
+}
+
+// landing returns the page / redirects to, the default job or workload, or the default view while it exists, empty
+// to list the jobs
+func (c *Config) landing() string {
+	switch {
+	case c.defaultJob != "":
+		jobName, workloadName, ok := strings.Cut(c.defaultJob, "/")
+		if !ok {
+			return "/job/" + url.PathEscape(jobName)
+		}
+		return fmt.Sprintf("/job/%s/%s", url.PathEscape(jobName), url.PathEscape(workloadName))
+	case c.defaultView != "":
+		if _, ok := c.views.get(c.defaultView); ok {
+			return "/views/" + url.PathEscape(c.defaultView)
+		}
 	}
+	return ""
+}
 
-	err = t.Execute(w, jobs)
+func (c *Config) jobListHandler(w http.ResponseWriter, r *http.Request) {
+	// The job list stays at /jobs when / lands on a default page, the query parameters, such as tz, carry over
+	if landing := c.landing(); r.URL.Path == "/" && landing != "" {
+		if r.URL.RawQuery != "" {
+			landing += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, landing, http.StatusFound)
+		return
+	}
+	jobs, err := c.visibleJobs(r)
 	if err != nil {
+		fmt.Println("Error loading jobs:", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+
+	type TemplateData struct {
+		Jobs        []Job
+		Pinned      []Workload
+		ShowRetired bool
+		SQL         bool
+		// Logout is offered to the users logged in through LDAP
+		Logout bool
+	}
+	for i := range jobs {
+		jobs[i].StaleWorkloads = addStaleness(jobs[i].Descriptor, jobs[i].Workloads)
+	}
+	data := TemplateData{
+		Jobs:        jobs,
+		Pinned:      pinnedWorkloads(c.pins(r), jobs),
+		ShowRetired: showRetired(r),
+		SQL:         c.analytics != nil,
+		Logout:      c.policy != nil && c.policy.LDAP != nil,
+	}
+	c.addStability(data.Pinned)
+	c.addAnomalies(data.Pinned)
+	if !data.ShowRetired {
+		data.Jobs = activeJobs(jobs)
+	}
+	c.renderTemplate(w, r, "jobs.html", data)
 }
 
 func (c *Config) jobDetailHandler(w http.ResponseWriter, r *http.Request) {
@@ -176,6 +937,63 @@ func (c *Config) jobDetailHandler(w http.ResponseWriter, r *http.Request) {
 	if len(pathParts) >= 2 {
 		workloadName = pathParts[1]
 	}
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.preferences(r).applyChartDefaults(r.URL.Query(), &opts)
+	// Workload charts split the kube-burner jobs unless told otherwise, the other views look their groups up by metric
+	opts.GroupByJob = opts.GroupByJob || r.URL.Query().Get("group") == ""
+	names := []string{jobName}
+	if workloadName != "" {
+		names = append(names, workloadName)
+	}
+	if _, err := resultsPath(c.resultsDir, names...); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	if len(pathParts) >= 3 && workloadName != "" {
+		switch pathParts[2] {
+		case "overlay":
+			c.overlayHandler(w, r, jobName, workloadName)
+			return
+		case "distribution":
+			c.distributionHandler(w, r, jobName, workloadName)
+			return
+		case "boxplot":
+			c.boxPlotHandler(w, r, jobName, workloadName)
+			return
+		case "heatmap":
+			c.heatmapHandler(w, r, jobName, workloadName)
+			return
+		case "compare":
+			c.compareHandler(w, r, jobName, workloadName)
+			return
+		case "bisect":
+			c.bisectHandler(w, r, jobName, workloadName)
+			return
+		case "timeline":
+			c.timelineHandler(w, r, jobName, workloadName)
+			return
+		case "logs":
+			c.logsHandler(w, r, jobName, workloadName)
+			return
+		case "cost":
+			c.costHandler(w, r, jobName, workloadName)
+			return
+		case "slos":
+			c.slosHandler(w, r, jobName, workloadName)
+			return
+		case "print":
+			c.printHandler(w, r, jobName, workloadName)
+			return
+		}
+	}
 
 	job := Job{
 		Name: jobName,
@@ -187,13 +1005,28 @@ func (c *Config) jobDetailHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		fmt.Printf("Error loading workloads for job %s: %v\n", jobName, err)
 	}
+	job.Retired = isRetired(job.Path)
+	job.Descriptor = loadDescriptor(job.Path)
+	if !showRetired(r) {
+		job.Workloads = activeWorkloads(job.Workloads, workloadName)
+	}
+	if workloadName == "" {
+		c.addStability(job.Workloads)
+		c.addAnomalies(job.Workloads)
+		addStaleness(job.Descriptor, job.Workloads)
+	}
 
 	// Determine the path to load runs from
 	var runsPath string
 	var displayName string
+	// descriptor describes the page, the one of the workload or of the job
+	var descriptor Descriptor
 	if workloadName != "" {
 		runsPath = filepath.Join(job.Path, workloadName)
-		displayName = fmt.Sprintf("%s / %s", jobName, workloadName)
+		if i := slices.IndexFunc(job.Workloads, func(w Workload) bool { return w.Name == workloadName }); i >= 0 {
+			descriptor = job.Workloads[i].Descriptor
+		}
+		displayName = fmt.Sprintf("%s / %s", cmp.Or(job.DisplayName, jobName), cmp.Or(descriptor.DisplayName, workloadName))
 	} else {
 		// If no workload specified, check if there are workloads
 		// If there's only one workload, redirect to it
@@ -203,50 +1036,164 @@ func (c *Config) jobDetailHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		// Otherwise, show workload selection (we'll handle this in the template)
 		runsPath = job.Path
-		displayName = jobName
+		descriptor = job.Descriptor
+		displayName = cmp.Or(job.DisplayName, jobName)
 	}
+	var stale *Staleness
 	if workloadName != "" {
 		job.Runs, err = loadRuns(runsPath)
+		if cadence, every := descriptor.workloadCadence(job.Descriptor); every > 0 && len(job.Runs) > 0 && !job.Retired {
+			stale = staleness(cadence, every, job.Runs[len(job.Runs)-1].Started(), time.Now())
+		}
 	}
 
-	metricGroups := prepareChartData(&job)
+	metricGroups := prepareChartData(&job, opts)
 	type TemplateData struct {
 		Job              Job
 		WorkloadName     string
 		DisplayName      string
+		Descriptor       Descriptor
 		MetricGroups     []MetricGroup
 		MetricGroupsJSON template.JS
+		// FragmentsURL is where the fragments of the workload are loaded from, and ChartQuery the chart options
+		// they're filtered with
+		FragmentsURL string
+		ChartQuery   template.URL
+		// TableView renders the charts as tables when the page is viewed as a table, TableURL being the page viewed
+		// as one otherwise
+		TableView     *TableView
+		TableURL      template.URL
+		Percentiles   []string
+		XAxis         string
+		Duplicates    []string
+		Unverified    []string
+		Modified      []string
+		Promoted      []string
+		Findings      []AnalyzerResult
+		Promote       bool
+		Readmes       []Readme
+		Pinned        bool
+		Retired       bool
+		Stale         *Staleness
+		ShowRetired   bool
+		Phases        []PhaseSummary
+		Phase         string
+		RunLinks      map[string][]RunLink
+		RunSignatures map[string]*RunSignature
+		RunCosts      map[string]RunCost
+		RunAliases    map[string]string
+		HasSLOs       bool
+		SplitBy       string
+		SplitFields   []string
+		Bucket        string
 	}
 
 	metricGroupsJSON, _ := json.Marshal(metricGroups)
+	var duplicates []string
+	for _, run := range job.Runs {
+		for _, name := range run.Duplicates {
+			duplicates = append(duplicates, fmt.Sprintf("%s (duplicate of %s)", name, run.Name()))
+		}
+	}
+	var unverified, modified, promoted []string
+	for _, run := range job.Runs {
+		if p := run.Promotion; p != nil {
+			promoted = append(promoted, fmt.Sprintf("%s (from %s/%s)", run.Name(), p.Job, p.Workload))
+		}
+		if run.Signature != nil && !run.Signature.Verified {
+			unverified = append(unverified, fmt.Sprintf("%s (%s)", run.Name(), run.Signature.Error))
+		}
+		if run.Modified != "" {
+			modified = append(modified, fmt.Sprintf("%s (%s)", run.Name(), run.Modified))
+		}
+	}
 
 	data := TemplateData{
 		Job:              job,
 		WorkloadName:     workloadName,
 		DisplayName:      displayName,
+		Descriptor:       descriptor,
+		Stale:            stale,
 		MetricGroups:     metricGroups,
 		MetricGroupsJSON: template.JS(metricGroupsJSON),
+		FragmentsURL:     fragmentsURL(jobName, workloadName),
+		ChartQuery:       template.URL(r.URL.Query().Encode()),
+		Percentiles:      opts.selectedPercentiles(),
+		XAxis:            opts.xAxis(),
+		Duplicates:       duplicates,
+		Unverified:       unverified,
+		Modified:         modified,
+		Promoted:         promoted,
+		Findings:         c.findings(jobName, workloadName),
+		Promote:          workloadName != "" && c.canPromote(r),
+		Readmes:          loadReadmes(c.resultsDir, jobName, workloadName),
+		Pinned:           slices.Contains(c.pins(r), Pin{Job: jobName, Workload: workloadName}),
+		Retired:          job.Retired,
+		ShowRetired:      showRetired(r),
+		Phases:           phaseSummaries(opts.filterRuns(job.Runs)),
+		Phase:            opts.Phase,
+		RunLinks:         runLinks(job.Runs),
+		RunSignatures:    runSignatures(job.Runs),
+		RunCosts:         runCosts(job.Runs),
+		RunAliases:       c.runAliases(job.Runs),
+		HasSLOs:          len(c.workloadSLOs(jobName, workloadName)) > 0,
+		SplitBy:          opts.SplitBy,
+		Bucket:           opts.Bucket,
+		SplitFields:      splitFields(job.Runs),
+	}
+	if query := r.URL.Query(); workloadName != "" && query.Get("view") == viewTable {
+		if data.TableView, err = newTableView(metricGroups, opts, query); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else {
+		query.Set("view", viewTable)
+		data.TableURL = template.URL("?" + query.Encode())
+	}
+	if opts.SplitBy != "" && !slices.Contains(data.SplitFields, opts.SplitBy) {
+		data.SplitFields = append(data.SplitFields, opts.SplitBy)
+	}
+	if i := slices.IndexFunc(job.Workloads, func(w Workload) bool { return w.Name == workloadName }); i >= 0 {
+		data.Retired = data.Retired || job.Workloads[i].Retired
 	}
 
-	templateFS, err := fs.Sub(templateFiles, "templates")
+	c.renderTemplate(w, r, "job_detail.html", data)
+}
+
+// renderTemplate executes the layout with the blocks of the given page template and data, with the helpers of
+// requestFuncs bound to the request
+func (c *Config) renderTemplate(w http.ResponseWriter, r *http.Request, name string, data any) {
+	page, err := c.templates.page(name)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	c.executeTemplate(w, r, page, layoutTemplate, data)
+}
 
-	templateData, err := fs.ReadFile(templateFS, "job_detail.html")
+// renderFragment executes the given partial alone, for the pages to swap it in
+func (c *Config) renderFragment(w http.ResponseWriter, r *http.Request, name string, data any) {
+	partials, err := c.templates.fragment(name)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	c.executeTemplate(w, r, partials, name, data)
+}
 
-	t, err := template.New("job_detail.html").Parse(string(templateData))
+func (c *Config) executeTemplate(w http.ResponseWriter, r *http.Request, templates *template.Template, name string, data any) {
+	loc, err := c.timezone(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	t, err := templates.Clone()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	err = t.Execute(w, data)
+	err = t.Funcs(requestFuncs(c, r, loc)).ExecuteTemplate(w, name, data)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -255,7 +1202,7 @@ func (c *Config) jobDetailHandler(w http.ResponseWriter, r *http.Request) {
 
 func loadJobs(resultsDir string) ([]Job, error) {
 
-	entries, err := os.ReadDir(resultsDir)
+	entries, err := storage.ReadDir(resultsDir)
 	if err != nil {
 		return nil, err
 	}
@@ -269,6 +1216,8 @@ func loadJobs(resultsDir string) ([]Job, error) {
 			}
 			// Load workloads for each job
 			job.Workloads, _ = loadWorkloads(job.Path, job.Name)
+			job.Retired = isRetired(job.Path)
+			job.Descriptor = loadDescriptor(job.Path)
 			jobs = append(jobs, job)
 		}
 	}
@@ -277,7 +1226,7 @@ func loadJobs(resultsDir string) ([]Job, error) {
 }
 
 func loadWorkloads(jobPath string, jobName string) ([]Workload, error) {
-	entries, err := os.ReadDir(jobPath)
+	entries, err := storage.ReadDir(jobPath)
 	if err != nil {
 		return nil, err
 	}
@@ -289,10 +1238,12 @@ func loadWorkloads(jobPath string, jobName string) ([]Workload, error) {
 			// Count runs without loading all the data
 			runCount := countRuns(workloadPath)
 			workloads = append(workloads, Workload{
-				Name:     entry.Name(),
-				Path:     workloadPath,
-				Job:      jobName,
-				RunCount: runCount,
+				Name:       entry.Name(),
+				Path:       workloadPath,
+				Job:        jobName,
+				RunCount:   runCount,
+				Retired:    isRetired(workloadPath),
+				Descriptor: loadDescriptor(workloadPath),
 			})
 		}
 	}
@@ -301,70 +1252,168 @@ func loadWorkloads(jobPath string, jobName string) ([]Workload, error) {
 }
 
 func countRuns(workloadPath string) int {
-	entries, err := os.ReadDir(workloadPath)
+	if manifest, err := loadRunManifest(workloadPath); err == nil && manifest != nil {
+		return len(manifest.Runs)
+	}
+	entries, err := storage.ReadDir(workloadPath)
 	if err != nil {
 		return 0
 	}
-	return len(entries)
+	var count int
+	for _, entry := range entries {
+		if entry.IsDir() {
+			count++
+		}
+	}
+	return count
 }
 
+// loadRuns loads the runs of a workload, from its run manifest when there's one
 func loadRuns(jobPath string) ([]Run, error) {
-	entries, err := os.ReadDir(jobPath)
+	manifest, err := loadRunManifest(jobPath)
+	if err != nil {
+		fmt.Fprintf(loadLog, "Error loading run manifest, listing runs instead: %v\n", err)
+	}
+	if manifest != nil {
+		return orderRuns(dedupRuns(manifest.loadRuns(jobPath))), nil
+	}
+	runs, err := scanRuns(jobPath)
+	if err != nil {
+		return nil, err
+	}
+	return orderRuns(dedupRuns(runs)), nil
+}
+
+// orderRuns sorts the runs by start time, rather than by directory name, and numbers them accordingly
+func orderRuns(runs []Run) []Run {
+	slices.SortStableFunc(runs, func(a, b Run) int {
+		if c := a.Started().Compare(b.Started()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name(), b.Name())
+	})
+	for i := range runs {
+		runs[i].Seq = i + 1
+	}
+	return runs
+}
+
+// dedupRuns drops the runs sharing the UUID of a previous run, such as runs uploaded twice, which would otherwise
+// show up as duplicate datapoints. The directories of the dropped copies are recorded in the run kept
+func dedupRuns(runs []Run) []Run {
+	seen := make(map[string]int)
+	var unique []Run
+	for _, run := range runs {
+		uuid := run.UUID()
+		if i, ok := seen[uuid]; ok && uuid != "" {
+			fmt.Fprintf(loadLog, "Ignoring run %s, duplicate of %s with UUID %s\n", run.Path, unique[i].Path, uuid)
+			unique[i].Duplicates = append(slices.Clip(unique[i].Duplicates), run.Name())
+			continue
+		}
+		seen[uuid] = len(unique)
+		unique = append(unique, run)
+	}
+	return unique
+}
+
+// scanRuns loads the runs found listing the workload directory
+func scanRuns(jobPath string) ([]Run, error) {
+	entries, err := storage.ReadDir(jobPath)
 	if err != nil {
 		return nil, err
 	}
 
 	var runs []Run
-	fmt.Printf("Loading %d runs from %s\n", len(entries), jobPath)
+	fmt.Fprintf(loadLog, "Loading %d runs from %s\n", len(entries), jobPath)
 	for _, entry := range entries {
 		if entry.IsDir() {
-			runPath := filepath.Join(jobPath, entry.Name())
-			measurements, err := loadMeasurements(runPath)
-			if err != nil {
-				fmt.Printf("Error loading job data: %s %v\n", runPath, err)
-				continue
-			}
-
-			jobSummary, err := loadJobSummary(runPath)
+			run, err := loadRun(filepath.Join(jobPath, entry.Name()))
 			if err != nil {
-				fmt.Printf("Error loading job summary: %s %v\n", runPath, err)
+				fmt.Fprintln(loadLog, err)
 				continue
 			}
-
-			run := Run{
-				Measurements: measurements,
-				Summary:      jobSummary,
-				Path:         runPath,
-			}
 			runs = append(runs, run)
 		}
 	}
 	return runs, nil
 }
 
-func loadMeasurements(runPath string) ([]Measurement, error) {
-	var allMeasurements []Measurement
-	files, err := filepath.Glob(filepath.Join(runPath, "*QuantilesMeasurement*.json"))
+// loadRun loads the measurements and job summary of a run directory, reusing the cached run when unchanged
+func loadRun(runPath string) (Run, error) {
+	entries, err := storage.ReadDir(runPath)
 	if err != nil {
-		return nil, err
+		diagnostics.report(runPath, "", readError, err)
+		return Run{}, fmt.Errorf("error reading run %s: %v", runPath, err)
+	}
+	return loadRunFiles(runPath, entries)
+}
+
+// loadRunFiles loads a run from its known files, without listing the run directory
+func loadRunFiles(runPath string, files []fs.DirEntry) (Run, error) {
+	fp, err := fingerprintEntries(files)
+	if err != nil {
+		return Run{}, fmt.Errorf("error reading run %s: %v", runPath, err)
+	}
+	if run, ok := runsCache.get(runPath, fp); ok {
+		return run, nil
+	}
+	// Problems found the last time the run was parsed are reported again if they're still there
+	diagnostics.clearDir(runPath)
+
+	measurements, err := loadMeasurements(runPath, files)
+	if err != nil {
+		return Run{}, fmt.Errorf("error loading job data %s: %v", runPath, err)
+	}
+
+	summaries, err := loadJobSummaries(runPath)
+	if err != nil {
+		return Run{}, fmt.Errorf("error loading job summary %s: %v", runPath, err)
+	}
+
+	run := Run{
+		Measurements: deriveMeasurements(runPath, measurements),
+		Summary:      summaries[0],
+		Summaries:    summaries,
+		Path:         runPath,
+		Signature:    verifyRunSignature(runPath, files),
+		Promotion:    loadRunPromotion(runPath, files),
+	}
+	// Signed runs were checked against their checksums already
+	if runChecksums && run.Signature == nil {
+		run.Modified = verifyRunChecksums(runPath, files)
 	}
+	runsCache.put(runPath, fp, run)
+	return run, nil
+}
 
+func loadMeasurements(runPath string, runFiles []fs.DirEntry) ([]Measurement, error) {
+	var allMeasurements []Measurement
+	files := matchMeasurementFiles(runPath, runFiles, quantilesParser)
 	if len(files) == 0 {
-		return nil, fmt.Errorf("no *QuantilesMeasurement*.json files found")
+		err := fmt.Errorf("no quantile measurement files found matching %s", measurementPatterns(quantilesParser))
+		diagnostics.report(runPath, "", missingData, err)
+		return nil, err
 	}
 
-	// Load all QuantilesMeasurement files
+	// Load all quantile measurement files
 	for _, file := range files {
-		data, err := os.ReadFile(file)
+		data, err := storage.ReadFile(file)
 		if err != nil {
-			fmt.Printf("Error reading file %s: %v\n", file, err)
+			diagnostics.reportFile(file, readError, err)
+			fmt.Fprintf(loadLog, "Error reading file %s: %v\n", file, err)
+			continue
+		}
+
+		if err := validateDocument(measurementDocument, file, data); err != nil {
+			fmt.Fprintln(loadLog, err)
 			continue
 		}
 
 		var measurements []Measurement
 		err = json.Unmarshal(data, &measurements)
 		if err != nil {
-			fmt.Printf("Error unmarshaling file %s: %v\n", file, err)
+			diagnostics.reportFile(file, parseError, err)
+			fmt.Fprintf(loadLog, "Error unmarshaling file %s: %v\n", file, err)
 			continue
 		}
 
@@ -374,68 +1423,116 @@ func loadMeasurements(runPath string) ([]Measurement, error) {
 	return allMeasurements, nil
 }
 
-func loadJobSummary(runPath string) (burner.JobSummary, error) {
+// loadJobSummaries loads the summaries of the jobs of the run, kube-burner writes one per job of its config
+func loadJobSummaries(runPath string) ([]burner.JobSummary, error) {
 	var summaries []burner.JobSummary
 	summaryPath := filepath.Join(runPath, "jobSummary.json")
 
-	data, err := os.ReadFile(summaryPath)
+	data, err := storage.ReadFile(summaryPath)
 	if err != nil {
-		return burner.JobSummary{}, err
+		diagnostics.reportFile(summaryPath, readError, err)
+		return nil, err
+	}
+	if err := validateDocument(metadataDocument, summaryPath, data); err != nil {
+		return nil, err
 	}
 
 	err = json.Unmarshal(data, &summaries)
 	if err != nil {
-		return burner.JobSummary{}, err
+		diagnostics.reportFile(summaryPath, parseError, err)
+		return nil, err
 	}
 
 	if len(summaries) == 0 {
-		return burner.JobSummary{}, fmt.Errorf("no job summary found")
+		err := fmt.Errorf("no job summary found")
+		diagnostics.reportFile(summaryPath, missingData, err)
+		return nil, err
 	}
-	// It's possible that there are multiple job summaries in the same run, we'll use the first one initially
-	return summaries[0], nil
+
+	// kube-burner inlines the metadata of the run, such as the cluster name, in the job summaries
+	var documents []map[string]any
+	if err := json.Unmarshal(data, &documents); err == nil {
+		for i, document := range documents {
+			summaries[i].Metadata = make(map[string]any)
+			for field, value := range document {
+				switch value.(type) {
+				case map[string]any, []any:
+				default:
+					summaries[i].Metadata[field] = value
+				}
+			}
+		}
+	}
+	return summaries, nil
 }
 
-func prepareChartData(job *Job) []MetricGroup {
-	// First, group by metricName, then by quantileName
-	// Map structure: metricName -> quantileName -> []DataPoint
-	metricMap := make(map[string]map[string][]DataPoint)
+func prepareChartData(job *Job, opts ChartOptions) []MetricGroup {
+	// First, group by metricName, and jobName when requested, then by quantileName
+	type groupKey struct{ metric, job string }
+	metricMap := make(map[groupKey]map[string][]DataPoint)
+	// Measurements already charted, by group, quantile and UUID, so runs ingested twice aren't plotted twice
+	seen := make(map[[4]string]bool)
+	jobNames := make(map[string]bool)
 
-	for _, run := range job.Runs {
+	for _, run := range opts.filterRuns(job.Runs) {
+		series := opts.series(run)
 		for _, measurement := range run.Measurements {
-			metricName := measurement.MetricName
+			if opts.Phase != "" && measurement.JobName != opts.Phase {
+				continue
+			}
+			key := groupKey{metric: measurement.MetricName}
+			if opts.GroupByJob {
+				key.job = measurement.JobName
+				jobNames[key.job] = true
+			}
 			quantileName := measurement.QuantileName
+			if measurement.UUID != "" {
+				seenKey := [4]string{key.metric, key.job, quantileName, measurement.UUID}
+				if seen[seenKey] {
+					continue
+				}
+				seen[seenKey] = true
+			}
 
 			// Initialize metric map if needed
-			if metricMap[metricName] == nil {
-				metricMap[metricName] = make(map[string][]DataPoint)
+			if metricMap[key] == nil {
+				metricMap[key] = make(map[string][]DataPoint)
 			}
 
 			dataPoint := DataPoint{
-				Timestamp:  measurement.Timestamp,
-				P99:        measurement.P99,
-				P95:        measurement.P95,
-				P50:        measurement.P50,
-				Min:        measurement.Min,
-				Max:        measurement.Max,
-				Avg:        measurement.Avg,
-				JobSummary: run.Summary,
+				Timestamp:   measurement.Timestamp,
+				Seq:         run.Seq,
+				P99:         measurement.P99,
+				P95:         measurement.P95,
+				P50:         measurement.P50,
+				Min:         measurement.Min,
+				Max:         measurement.Max,
+				Avg:         measurement.Avg,
+				JobSummary:  run.PhaseSummary(measurement.JobName),
+				Series:      series,
+				percentiles: opts.Percentiles,
 			}
-			metricMap[metricName][quantileName] = append(metricMap[metricName][quantileName], dataPoint)
+			dataPoint.X = opts.x(dataPoint)
+			metricMap[key][quantileName] = append(metricMap[key][quantileName], dataPoint)
 		}
 	}
 
-	// Create MetricGroup for each metricName
+	// Create MetricGroup for each metricName and jobName
 	var metricGroups []MetricGroup
-	for metricName, quantileMap := range metricMap {
+	for key, quantileMap := range metricMap {
 		var charts []ChartData
 		for quantileName, datapoints := range quantileMap {
-			slices.SortFunc(datapoints, func(a, b DataPoint) int {
-				return a.Timestamp.Compare(b.Timestamp)
+			slices.SortStableFunc(datapoints, func(a, b DataPoint) int {
+				return cmp.Compare(a.Seq, b.Seq)
 			})
+			if opts.Bucket == bucketWeek {
+				datapoints = opts.bucketDatapoints(datapoints)
+			}
 
 			charts = append(charts, ChartData{
-				MetricName:   metricName,
+				MetricName:   key.metric,
 				QuantileName: quantileName,
+				Description:  metricDocs.describe(key.metric, quantileName),
 				Datapoints:   datapoints,
 			})
 		}
@@ -445,15 +1542,21 @@ func prepareChartData(job *Job) []MetricGroup {
 			return strings.Compare(a.QuantileName, b.QuantileName)
 		})
 
-		metricGroups = append(metricGroups, MetricGroup{
-			MetricName: metricName,
-			Charts:     charts,
-		})
+		group := MetricGroup{
+			MetricName:  key.metric,
+			Description: metricDocs.describe(key.metric, ""),
+			Charts:      charts,
+		}
+		// The job is only worth telling apart when there are several
+		if len(jobNames) > 1 {
+			group.JobName = key.job
+		}
+		metricGroups = append(metricGroups, group)
 	}
 
-	// Sort metric groups by metricName
+	// Sort metric groups by metricName, then jobName
 	slices.SortFunc(metricGroups, func(a, b MetricGroup) int {
-		return strings.Compare(a.MetricName, b.MetricName)
+		return cmp.Or(strings.Compare(a.MetricName, b.MetricName), strings.Compare(a.JobName, b.JobName))
 	})
 
 	return metricGroups
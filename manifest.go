@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runManifestFile lists the runs of a workload, it lives in the workload directory and is generated by the
+// index subcommand
+const runManifestFile = "manifest.json"
+
+// RunManifest lists the runs of a workload along with their files, so runs are loaded without listing every run
+// directory, which is slow in remote backends
+type RunManifest struct {
+	GeneratedAt time.Time     `json:"generatedAt"`
+	Runs        []ManifestRun `json:"runs"`
+}
+
+type ManifestRun struct {
+	Name      string         `json:"name"`
+	UUID      string         `json:"uuid"`
+	Timestamp time.Time      `json:"timestamp"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+	Files     []ManifestFile `json:"files"`
+}
+
+type ManifestFile struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// loadRunManifest returns the run manifest of the workload, nil when there's none
+func loadRunManifest(workloadPath string) (*RunManifest, error) {
+	data, err := storage.ReadFile(filepath.Join(workloadPath, runManifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		diagnostics.clear(filepath.Join(workloadPath, runManifestFile))
+		return nil, nil
+	}
+	if err != nil {
+		diagnostics.report(workloadPath, runManifestFile, readError, err)
+		return nil, err
+	}
+	var manifest RunManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		diagnostics.report(workloadPath, runManifestFile, parseError, err)
+		return nil, fmt.Errorf("error parsing run manifest %s: %v", filepath.Join(workloadPath, runManifestFile), err)
+	}
+	diagnostics.clear(filepath.Join(workloadPath, runManifestFile))
+	return &manifest, nil
+}
+
+// loadRuns loads the runs listed in the manifest
+func (m *RunManifest) loadRuns(workloadPath string) []Run {
+	var runs []Run
+	fmt.Fprintf(loadLog, "Loading %d runs from the manifest of %s\n", len(m.Runs), workloadPath)
+	for _, r := range m.Runs {
+		files := make([]remoteEntry, len(r.Files))
+		for i, f := range r.Files {
+			files[i] = remoteEntry{name: f.Name, size: f.Size, modTime: f.ModTime}
+		}
+		run, err := loadRunFiles(filepath.Join(workloadPath, r.Name), sortedEntries(files))
+		if err != nil {
+			fmt.Fprintln(loadLog, err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs
+}
+
+// newRunManifest lists the runs found in the workload directory
+func newRunManifest(workloadPath string) (RunManifest, error) {
+	manifest := RunManifest{GeneratedAt: time.Now().UTC()}
+	runs, err := scanRuns(workloadPath)
+	if err != nil {
+		return manifest, err
+	}
+	for _, run := range (ChartOptions{}).filterRuns(runs) {
+		entries, err := storage.ReadDir(run.Path)
+		if err != nil {
+			return manifest, err
+		}
+		if run.Modified != "" {
+			fmt.Fprintf(os.Stderr, "The files of %s changed since their checksums were computed: %s\n", run.Path, run.Modified)
+		}
+		mr := ManifestRun{
+			Name:      run.Name(),
+			UUID:      run.Summary.UUID,
+			Timestamp: run.Started(),
+			Metadata:  run.Metadata(),
+		}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil {
+				return manifest, err
+			}
+			if entry.IsDir() {
+				continue
+			}
+			mr.Files = append(mr.Files, ManifestFile{Name: entry.Name(), Size: info.Size(), ModTime: info.ModTime()})
+		}
+		manifest.Runs = append(manifest.Runs, mr)
+	}
+	return manifest, nil
+}
+
+func saveRunManifest(workloadPath string, manifest RunManifest) error {
+	if !localResults() {
+		return errRemoteResults
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so readers never see a partial manifest
+	tmp := filepath.Join(workloadPath, "."+runManifestFile)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(workloadPath, runManifestFile))
+}
+
+// indexCommand implements the index subcommand, which writes the run manifest of every workload of the results
+// directory, or of the given job and workload
+func indexCommand(args []string) int {
+	flags := flag.NewFlagSet("index", flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path to the directory holding results")
+	jobName := flags.String("job", "", "Only index the workloads of this job")
+	workloadName := flags.String("workload", "", "Only index this workload, requires -job")
+	flags.BoolVar(&runChecksums, "checksums", false, "Write the sha256sums file of the runs without one, and report the runs whose files changed since")
+	flags.Parse(args)
+	if *workloadName != "" && *jobName == "" {
+		fmt.Fprintln(os.Stderr, "-workload requires -job")
+		return 2
+	}
+	loadLog = os.Stderr
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if !localResults() {
+		fmt.Fprintln(os.Stderr, errRemoteResults)
+		return 1
+	}
+	jobs, err := loadJobs(root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading jobs:", err)
+		return 1
+	}
+	status := 0
+	for _, job := range jobs {
+		if *jobName != "" && job.Name != *jobName {
+			continue
+		}
+		for _, workload := range job.Workloads {
+			if *workloadName != "" && workload.Name != *workloadName {
+				continue
+			}
+			manifest, err := newRunManifest(workload.Path)
+			if err == nil {
+				err = saveRunManifest(workload.Path, manifest)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error indexing %s/%s: %v\n", job.Name, workload.Name, err)
+				status = 1
+				continue
+			}
+			fmt.Printf("Indexed %d runs of %s/%s\n", len(manifest.Runs), job.Name, workload.Name)
+		}
+	}
+	return status
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+const (
+	defaultMatrixRuns      = "last:3"
+	defaultMatrixThreshold = 5
+)
+
+// Statuses of the cells of the release matrix
+const (
+	matrixPass = "pass"
+	matrixWarn = "warn"
+	matrixFail = "fail"
+)
+
+// ReleaseMatrix compares the latest runs of every workload of two jobs, typically the same jobs run against two
+// releases, with a cell per workload and metric quantile
+type ReleaseMatrix struct {
+	// Baseline and Current are the names of the jobs compared
+	Baseline   string
+	Current    string
+	Runs       string
+	Percentile string
+	Threshold  float64
+	Test       string
+	Columns    []MatrixColumn
+	Rows       []MatrixRow
+}
+
+type MatrixColumn struct {
+	MetricName   string
+	QuantileName string
+}
+
+type MatrixRow struct {
+	Workload string
+	// Status is the worst status of the cells of the row
+	Status string
+	// Cells are aligned with the columns, null when the workload lacks the quantile
+	Cells []*MatrixCell
+	// Error is set when the workload couldn't be compared, e.g. when it's missing from one of the jobs
+	Error string
+}
+
+type MatrixCell struct {
+	Comparison MetricComparison
+	Status     string
+}
+
+// MetricSpan is a metric along with the number of columns of its quantiles, used by the template to group the
+// column headers
+type MetricSpan struct {
+	MetricName string
+	Columns    int
+}
+
+// matrixStatus classifies a comparison: it fails when the metric regressed beyond the threshold, significantly
+// when a test was run, and warns when it's inconclusive or regressed beyond half the threshold
+func matrixStatus(mc MetricComparison, threshold float64, test string) string {
+	switch {
+	case mc.Delta > threshold && (test == "" || mc.Significant):
+		return matrixFail
+	case mc.InsufficientSamples || mc.Delta > threshold/2:
+		return matrixWarn
+	}
+	return matrixPass
+}
+
+// worseStatus returns the most severe of both statuses
+func worseStatus(a, b string) string {
+	order := []string{"", matrixPass, matrixWarn, matrixFail}
+	if slices.Index(order, b) > slices.Index(order, a) {
+		return b
+	}
+	return a
+}
+
+// MetricSpans groups the columns by metric
+func (m ReleaseMatrix) MetricSpans() []MetricSpan {
+	var spans []MetricSpan
+	for _, col := range m.Columns {
+		if len(spans) > 0 && spans[len(spans)-1].MetricName == col.MetricName {
+			spans[len(spans)-1].Columns++
+			continue
+		}
+		spans = append(spans, MetricSpan{MetricName: col.MetricName, Columns: 1})
+	}
+	return spans
+}
+
+// compareJobs compares the selected runs of every workload found in any of both jobs
+func compareJobs(resultsDir string, matrix ReleaseMatrix, opts compareOptions) (ReleaseMatrix, error) {
+	baselineJob, currentJob := matrix.Baseline, matrix.Current
+	baselineWorkloads, err := loadWorkloads(filepath.Join(resultsDir, baselineJob), baselineJob)
+	if err != nil {
+		return matrix, fmt.Errorf("job %s not found", baselineJob)
+	}
+	currentWorkloads, err := loadWorkloads(filepath.Join(resultsDir, currentJob), currentJob)
+	if err != nil {
+		return matrix, fmt.Errorf("job %s not found", currentJob)
+	}
+	var names []string
+	for _, w := range append(baselineWorkloads, currentWorkloads...) {
+		if !slices.Contains(names, w.Name) {
+			names = append(names, w.Name)
+		}
+	}
+	slices.Sort(names)
+	comparisons := make(map[string]Comparison)
+	columns := make(map[MatrixColumn]bool)
+	for _, name := range names {
+		row := MatrixRow{Workload: name}
+		comparison, err := compareWorkloads(filepath.Join(resultsDir, baselineJob, name), filepath.Join(resultsDir, currentJob, name), matrix.Runs, opts)
+		if err != nil {
+			row.Error = err.Error()
+		}
+		comparisons[name] = comparison
+		for _, mc := range comparison.Metrics {
+			columns[MatrixColumn{MetricName: mc.MetricName, QuantileName: mc.QuantileName}] = true
+		}
+		matrix.Rows = append(matrix.Rows, row)
+	}
+	for col := range columns {
+		matrix.Columns = append(matrix.Columns, col)
+	}
+	slices.SortFunc(matrix.Columns, func(a, b MatrixColumn) int {
+		return cmp.Or(cmp.Compare(a.MetricName, b.MetricName), cmp.Compare(a.QuantileName, b.QuantileName))
+	})
+	for i, row := range matrix.Rows {
+		row.Cells = make([]*MatrixCell, len(matrix.Columns))
+		for _, mc := range comparisons[row.Workload].Metrics {
+			j := slices.Index(matrix.Columns, MatrixColumn{MetricName: mc.MetricName, QuantileName: mc.QuantileName})
+			cell := &MatrixCell{Comparison: mc, Status: matrixStatus(mc, matrix.Threshold, opts.Test)}
+			row.Cells[j] = cell
+			row.Status = worseStatus(row.Status, cell.Status)
+		}
+		matrix.Rows[i] = row
+	}
+	return matrix, nil
+}
+
+// compareWorkloads compares the latest runs of a workload in two jobs
+func compareWorkloads(baselinePath, currentPath, selector string, opts compareOptions) (Comparison, error) {
+	comparison := Comparison{
+		Baseline:     selector,
+		Percentile:   opts.Percentile,
+		Confidence:   opts.Confidence,
+		MinSamples:   opts.MinSamples,
+		Test:         opts.Test,
+		Significance: opts.Significance,
+	}
+	groups := make([][]Run, 2)
+	for i, workloadPath := range []string{baselinePath, currentPath} {
+		runs, err := loadRuns(workloadPath)
+		if err != nil {
+			return comparison, fmt.Errorf("missing from job %s", filepath.Base(filepath.Dir(workloadPath)))
+		}
+		if groups[i], err = selectRuns(ChartOptions{}.filterRuns(runs), selector); err != nil {
+			return comparison, err
+		}
+		if len(groups[i]) == 0 {
+			return comparison, fmt.Errorf("no runs in job %s", filepath.Base(filepath.Dir(workloadPath)))
+		}
+	}
+	for _, run := range groups[0] {
+		comparison.BaselineRuns = append(comparison.BaselineRuns, run.Name())
+	}
+	for _, run := range groups[1] {
+		comparison.CurrentRuns = append(comparison.CurrentRuns, run.Name())
+	}
+	return compareValues(comparison, quantileValues(groups[0], opts.Percentile), quantileValues(groups[1], opts.Percentile), opts), nil
+}
+
+// loadMatrix compares the jobs given by the a (baseline) and b (current) query parameters
+func (c *Config) loadMatrix(r *http.Request) (ReleaseMatrix, int, error) {
+	query := r.URL.Query()
+	matrix := ReleaseMatrix{
+		Baseline:  query.Get("a"),
+		Current:   query.Get("b"),
+		Runs:      cmp.Or(query.Get("runs"), defaultMatrixRuns),
+		Threshold: defaultMatrixThreshold,
+	}
+	opts, err := c.compareOptionsFromRequest(r)
+	if err != nil {
+		return matrix, http.StatusBadRequest, err
+	}
+	matrix.Percentile, matrix.Test = opts.Percentile, opts.Test
+	if t := query.Get("threshold"); t != "" {
+		if matrix.Threshold, err = strconv.ParseFloat(t, 64); err != nil || matrix.Threshold <= 0 {
+			return matrix, http.StatusBadRequest, fmt.Errorf("invalid threshold: must be a positive number")
+		}
+	}
+	if matrix.Baseline == "" || matrix.Current == "" {
+		return matrix, http.StatusBadRequest, fmt.Errorf("select the baseline and current jobs to compare")
+	}
+	for _, job := range []string{matrix.Baseline, matrix.Current} {
+		if !c.jobAllowed(r, job) {
+			return matrix, http.StatusForbidden, fmt.Errorf("access to job %s denied", job)
+		}
+	}
+	if matrix, err = compareJobs(c.resultsDir, matrix, opts); err != nil {
+		return matrix, http.StatusNotFound, err
+	}
+	return matrix, http.StatusOK, nil
+}
+
+func (c *Config) apiMatrixHandler(w http.ResponseWriter, r *http.Request) {
+	matrix, status, err := c.loadMatrix(r)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, matrix)
+}
+
+func (c *Config) matrixHandler(w http.ResponseWriter, r *http.Request) {
+	matrix, status, err := c.loadMatrix(r)
+	if err != nil && status != http.StatusBadRequest && status != http.StatusNotFound {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	jobs, jobsErr := c.visibleJobs(r)
+	if jobsErr != nil {
+		http.Error(w, jobsErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	type TemplateData struct {
+		Matrix      ReleaseMatrix
+		Jobs        []Job
+		Percentiles []string
+		Error       string
+	}
+	data := TemplateData{
+		Matrix:      matrix,
+		Jobs:        jobs,
+		Percentiles: percentiles,
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "matrix.html", data)
+}
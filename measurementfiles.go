@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Parsers measurement files can be assigned to
+const (
+	// quantilesParser reads arrays of quantile documents in the kube-burner format, which the charts are built from
+	quantilesParser = "quantiles"
+	// rawParser reads arrays of raw measurement documents, whose latency fields feed the latency distributions
+	rawParser = "raw"
+)
+
+// MeasurementFiles assigns the files of the runs matching a pattern to a parser
+type MeasurementFiles struct {
+	Pattern string
+	Parser  string
+}
+
+// measurementFiles lists the patterns identifying the measurement files of the runs, in the kube-burner naming by
+// default. Files are assigned to the parser of the first pattern they match
+var measurementFiles = []MeasurementFiles{
+	{Pattern: "*QuantilesMeasurement*.json", Parser: quantilesParser},
+	{Pattern: "*Measurement*.json", Parser: rawParser},
+}
+
+// parseMeasurementFiles parses a comma separated list of pattern=parser assignments, patterns without a parser are
+// assigned to the quantiles one
+func parseMeasurementFiles(spec string) ([]MeasurementFiles, error) {
+	var files []MeasurementFiles
+	for _, item := range splitList(spec) {
+		pattern, parser, ok := strings.Cut(item, "=")
+		if !ok {
+			parser = quantilesParser
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid measurement file pattern %q: %v", pattern, err)
+		}
+		if parser != quantilesParser && parser != rawParser {
+			return nil, fmt.Errorf("unknown measurement parser %q for pattern %q, must be %s or %s", parser, pattern, quantilesParser, rawParser)
+		}
+		files = append(files, MeasurementFiles{Pattern: pattern, Parser: parser})
+	}
+	if !slices.ContainsFunc(files, func(f MeasurementFiles) bool { return f.Parser == quantilesParser }) {
+		return nil, fmt.Errorf("no measurement file pattern is assigned to the %s parser", quantilesParser)
+	}
+	return files, nil
+}
+
+// measurementParser returns the parser the file is assigned to, empty when it isn't a measurement file
+func measurementParser(name string) string {
+	for _, files := range measurementFiles {
+		if ok, _ := path.Match(files.Pattern, name); ok {
+			return files.Parser
+		}
+	}
+	return ""
+}
+
+// matchMeasurementFiles returns the files of the run assigned to the parser
+func matchMeasurementFiles(runPath string, entries []fs.DirEntry, parser string) []string {
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && measurementParser(entry.Name()) == parser {
+			files = append(files, filepath.Join(runPath, entry.Name()))
+		}
+	}
+	return files
+}
+
+// measurementPatterns returns the patterns assigned to the parser, for error messages
+func measurementPatterns(parser string) string {
+	var patterns []string
+	for _, files := range measurementFiles {
+		if files.Parser == parser {
+			patterns = append(patterns, files.Pattern)
+		}
+	}
+	return strings.Join(patterns, ", ")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MetricDoc explains what a metric measures, and every one of its quantiles
+type MetricDoc struct {
+	Description string            `yaml:"description"`
+	Quantiles   map[string]string `yaml:"quantiles"`
+}
+
+// MetricDocs maps metric names to their documentation
+type MetricDocs map[string]MetricDoc
+
+// metricDocs documents the metrics shown by the dashboard, the kube-burner latency measurements unless extended or
+// overridden with -metric-docs
+var metricDocs = MetricDocs{
+	"podLatencyQuantilesMeasurement": {
+		Description: "Latency of the pods created by the job, from their creation until every condition of their lifecycle turned true",
+		Quantiles: map[string]string{
+			"PodScheduled":              "Time from the pod creation until the scheduler bound it to a node",
+			"PodReadyToStartContainers": "Time from the pod creation until its sandbox was created and its network configured",
+			"Initialized":               "Time from the pod creation until all its init containers completed",
+			"ContainersStarted":         "Time from the pod creation until all its containers started",
+			"ContainersReady":           "Time from the pod creation until all its containers passed their readiness probes",
+			"Ready":                     "Time from the pod creation until it was ready to serve traffic",
+		},
+	},
+	"svcLatencyQuantilesMeasurement": {
+		Description: "Latency of the services created by the job, from their creation until they were reachable",
+		Quantiles: map[string]string{
+			"Ready":        "Time from the service creation until its endpoints accepted connections",
+			"LoadBalancer": "Time from the service creation until its load balancer got an ingress address",
+		},
+	},
+	"nodeLatencyQuantilesMeasurement": {
+		Description: "Latency of the nodes added during the job, from their creation until every condition turned true",
+		Quantiles: map[string]string{
+			"NodeMemoryPressure": "Time from the node creation until it reported no memory pressure",
+			"NodeDiskPressure":   "Time from the node creation until it reported no disk pressure",
+			"NodePIDPressure":    "Time from the node creation until it reported no PID pressure",
+			"Ready":              "Time from the node creation until it was ready to run pods",
+		},
+	},
+}
+
+// loadMetricDocs loads the metric descriptions of the YAML file on top of the built-in ones, a metric of the file
+// overrides the built-in description and quantiles it redefines
+func loadMetricDocs(docsPath string) error {
+	data, err := os.ReadFile(docsPath)
+	if err != nil {
+		return err
+	}
+	var docs MetricDocs
+	if err := yaml.Unmarshal(data, &docs); err != nil {
+		return fmt.Errorf("error parsing metric docs %s: %v", docsPath, err)
+	}
+	for metric, doc := range docs {
+		merged := metricDocs[metric]
+		merged.Description = cmp.Or(doc.Description, merged.Description)
+		merged.Quantiles = maps.Clone(merged.Quantiles)
+		if merged.Quantiles == nil {
+			merged.Quantiles = make(map[string]string)
+		}
+		maps.Copy(merged.Quantiles, doc.Quantiles)
+		metricDocs[metric] = merged
+	}
+	return nil
+}
+
+// describe returns the description of the metric, or of its quantile when given, empty when undocumented
+func (d MetricDocs) describe(metric, quantile string) string {
+	if quantile == "" {
+		return d[metric].Description
+	}
+	return d[metric].Quantiles[quantile]
+}
+
+// MetricDocEntry is a documented metric as listed by the docs page and API, with its quantiles sorted
+type MetricDocEntry struct {
+	MetricName  string
+	Description string
+	Quantiles   []QuantileDoc
+}
+
+type QuantileDoc struct {
+	QuantileName string
+	Description  string
+}
+
+// entries lists the documented metrics sorted by name
+func (d MetricDocs) entries() []MetricDocEntry {
+	var entries []MetricDocEntry
+	for _, metric := range slices.Sorted(maps.Keys(d)) {
+		entry := MetricDocEntry{MetricName: metric, Description: d[metric].Description}
+		for _, quantile := range slices.Sorted(maps.Keys(d[metric].Quantiles)) {
+			entry.Quantiles = append(entry.Quantiles, QuantileDoc{QuantileName: quantile, Description: d[metric].Quantiles[quantile]})
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func (c *Config) apiMetricDocsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, metricDocs.entries())
+}
+
+func (c *Config) metricDocsHandler(w http.ResponseWriter, r *http.Request) {
+	c.renderTemplate(w, r, "metrics_docs.html", metricDocs.entries())
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+type corsConfig struct {
+	allowedOrigins []string
+	allowedMethods []string
+	allowedHeaders []string
+}
+
+// corsMiddleware adds the CORS headers to the responses of requests coming from an allowed origin and
+// answers preflight requests. It's a no-op when no origins are configured
+func (c *Config) corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || len(c.cors.allowedOrigins) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if !c.cors.originAllowed(origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if slices.Contains(c.cors.allowedOrigins, "*") {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		// Preflight request
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", strings.Join(c.cors.allowedMethods, ", "))
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.cors.allowedHeaders, ", "))
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (cc corsConfig) originAllowed(origin string) bool {
+	for _, allowed := range cc.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitList splits a comma separated flag value, ignoring empty items
+func splitList(s string) []string {
+	var items []string
+	for item := range strings.SplitSeq(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+type rateLimitConfig struct {
+	rate         float64
+	burst        int
+	maxBodyBytes int64
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter keeps a token bucket per client address
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    rate.Limit
+	burst   int
+	clients map[string]*clientLimiter
+}
+
+func newRateLimiter(r float64, burst int) *rateLimiter {
+	rl := &rateLimiter{
+		rate:    rate.Limit(r),
+		burst:   burst,
+		clients: make(map[string]*clientLimiter),
+	}
+	go rl.cleanup()
+	return rl
+}
+
+func (rl *rateLimiter) allow(client string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	cl, ok := rl.clients[client]
+	if !ok {
+		cl = &clientLimiter{
+			limiter: rate.NewLimiter(rl.rate, rl.burst),
+		}
+		rl.clients[client] = cl
+	}
+	cl.lastSeen = time.Now()
+	return cl.limiter.Allow()
+}
+
+// cleanup periodically forgets the clients that haven't been seen for a while
+func (rl *rateLimiter) cleanup() {
+	for range time.Tick(time.Minute) {
+		rl.mu.Lock()
+		for client, cl := range rl.clients {
+			if time.Since(cl.lastSeen) > 3*time.Minute {
+				delete(rl.clients, client)
+			}
+		}
+		rl.mu.Unlock()
+	}
+}
+
+// rateLimitMiddleware rejects the requests of clients exceeding their request rate. It's a no-op when rate limiting is
+// disabled
+func (c *Config) rateLimitMiddleware(next http.Handler) http.Handler {
+	if c.rateLimit.rate <= 0 {
+		return next
+	}
+	rl := newRateLimiter(c.rateLimit.rate, c.rateLimit.burst)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.features.enabled(featureRateLimit) && !rl.allow(c.rateLimitKey(r)) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, "too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bodyLimitMiddleware caps the size of request bodies, reads beyond the limit fail
+func (c *Config) bodyLimitMiddleware(next http.Handler) http.Handler {
+	if c.rateLimit.maxBodyBytes <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > c.rateLimit.maxBodyBytes {
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, c.rateLimit.maxBodyBytes)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimitKey returns the client the request counts against: the user authenticated by the dashboard or the proxy
+// when there is one, as every user behind the proxy shares its address, and the address of the client otherwise
+func (c *Config) rateLimitKey(r *http.Request) string {
+	if session, ok := requestSession(r); ok {
+		return "user:" + session.User
+	}
+	if user := c.user(r); user != "" {
+		return "user:" + user
+	}
+	return clientAddr(r)
+}
+
+// clientAddr returns the address the request comes from, without the port
+func clientAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// readOnlyMiddleware rejects every request that could mutate the results, regardless of the identity of the
+// client, but the readOnlyRoutes. It's a no-op when read-only mode is disabled
+func (c *Config) readOnlyMiddleware(next http.Handler) http.Handler {
+	if !c.readOnly {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !readOnlyAllowed(r) {
+			http.Error(w, "the dashboard is running in read-only mode", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readOnlyAllowed reports whether the request is allowed in read-only mode
+func readOnlyAllowed(r *http.Request) bool {
+	if safeMethod(r) {
+		return true
+	}
+	return slices.Contains(readOnlyRoutes, r.URL.Path)
+}
+
+// safeMethod reports whether the method of the request only reads
+func safeMethod(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions
+}
+
+// sameOrigin returns false for form submissions coming from other origins, requests without an Origin header are
+// accepted as browsers always send it with cross-origin ones
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// ndjsonContentType is the media type clients accept to get the measurement endpoints as NDJSON, a document per line
+const ndjsonContentType = "application/x-ndjson"
+
+// ndjsonFlushLines is the number of lines written between flushes, so clients get the documents as they're produced
+const ndjsonFlushLines = 100
+
+// wantsNDJSON reports whether the Accept header of the request asks for NDJSON
+func wantsNDJSON(r *http.Request) bool {
+	for _, accept := range r.Header.Values("Accept") {
+		for _, mediaRange := range strings.Split(accept, ",") {
+			if mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange)); err == nil && mediaType == ndjsonContentType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ndjsonEncoder streams documents as NDJSON. Writes block while the client doesn't keep up, which holds the producer
+// back, and fail once it went away, so producers stop at the first error
+type ndjsonEncoder struct {
+	w     http.ResponseWriter
+	rc    *http.ResponseController
+	lines int
+}
+
+func newNDJSONEncoder(w http.ResponseWriter) *ndjsonEncoder {
+	w.Header().Set("Content-Type", ndjsonContentType)
+	return &ndjsonEncoder{w: w, rc: http.NewResponseController(w)}
+}
+
+// encode writes the document as a line
+func (e *ndjsonEncoder) encode(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if _, err := e.w.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	if e.lines++; e.lines%ndjsonFlushLines == 0 {
+		return e.flush()
+	}
+	return nil
+}
+
+// encodeDatapoint writes the datapoint as a line, along with the labels telling what it measures, such as its metric
+// and quantile, as every line must stand on its own
+func (e *ndjsonEncoder) encodeDatapoint(labels map[string]string, dp DataPoint) error {
+	data, err := json.Marshal(dp)
+	if err != nil {
+		return err
+	}
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	for name, value := range labels {
+		if value != "" {
+			doc[name], _ = json.Marshal(value)
+		}
+	}
+	return e.encode(doc)
+}
+
+func (e *ndjsonEncoder) flush() error {
+	return e.rc.Flush()
+}
@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Types of the notifications, notifiers are routed the ones of the types they're configured for
+const (
+	notifyNewRun     = "run"
+	notifyRegression = "regression"
+	notifyLoadError  = "load-error"
+	notifyRule       = "rule"
+	notifyStale      = "stale"
+)
+
+var notificationTypes = []string{notifyNewRun, notifyRegression, notifyLoadError, notifyRule, notifyStale}
+
+// Types of the notifiers
+const (
+	notifierWebhook   = "webhook"
+	notifierSlack     = "slack"
+	notifierEmail     = "email"
+	notifierPagerDuty = "pagerduty"
+	notifierStdout    = "stdout"
+)
+
+const (
+	pagerDutyEventsURL       = "https://events.pagerduty.com/v2/enqueue"
+	defaultPagerDutySeverity = "warning"
+)
+
+var notifierClient = &http.Client{Timeout: httpTimeout}
+
+// Notification is an event of the results sent through the notifiers, such as a run added or a regression
+type Notification struct {
+	Type     string `json:"type"`
+	Job      string `json:"job,omitempty"`
+	Workload string `json:"workload,omitempty"`
+	Run      string `json:"run,omitempty"`
+	// Owner is the team owning the metric the notification is about, such as the metric of a regression
+	Owner string `json:"owner,omitempty"`
+	// Key identifies what the notification is about, such as the regression or the file failing to load
+	Key   string `json:"key"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+	// URL links the notification to the dashboard when its URL is configured
+	URL  string    `json:"url,omitempty"`
+	Time time.Time `json:"time"`
+}
+
+// notifier sends notifications to a destination
+type notifier interface {
+	notify(n Notification) error
+}
+
+// NotifierConfig configures a notifier, along with the notifications routed to it
+type NotifierConfig struct {
+	Name string `yaml:"name"`
+	// Type is webhook, slack, email, pagerduty or stdout
+	Type string `yaml:"type"`
+	// Events are the types of the notifications sent, run, regression, load-error, rule or stale, every type when
+	// empty
+	Events []string `yaml:"events"`
+	// Jobs are the patterns of the jobs notified about, every job when empty
+	Jobs []string `yaml:"jobs"`
+	// Owners are the owners of the metrics notified about, every notification when empty. Notifications about no
+	// owned metric, such as new runs, aren't sent to notifiers restricted to owners
+	Owners []string `yaml:"owners"`
+	// URL is the endpoint of webhooks and the incoming webhook of Slack
+	URL string `yaml:"url"`
+	// Headers are added to the webhook requests, they expand environment variables
+	Headers map[string]string `yaml:"headers"`
+	// Recipients of the emails
+	Recipients []string `yaml:"recipients"`
+	// RoutingKey is the integration key of the PagerDuty service, it expands environment variables
+	RoutingKey string `yaml:"routingKey"`
+	Severity   string `yaml:"severity"`
+
+	notifier notifier
+}
+
+// validate checks the notifier and builds it, emails are sent through the SMTP server
+func (nc NotifierConfig) validate(smtp SMTPConfig) (NotifierConfig, error) {
+	for _, event := range nc.Events {
+		if !slices.Contains(notificationTypes, event) {
+			return nc, fmt.Errorf("unknown event %s, must be one of %s", event, strings.Join(notificationTypes, ", "))
+		}
+	}
+	if err := validJobPatterns(nc.Jobs); err != nil {
+		return nc, err
+	}
+	switch nc.Type {
+	case notifierWebhook, notifierSlack:
+		if nc.URL == "" {
+			return nc, fmt.Errorf("url is required")
+		}
+		headers := make(map[string]string)
+		for name, value := range nc.Headers {
+			headers[name] = os.ExpandEnv(value)
+		}
+		if nc.Type == notifierSlack {
+			nc.notifier = slackNotifier{url: nc.URL}
+		} else {
+			nc.notifier = webhookNotifier{url: nc.URL, headers: headers}
+		}
+	case notifierEmail:
+		if len(nc.Recipients) == 0 {
+			return nc, fmt.Errorf("no recipients")
+		}
+		if smtp.Host == "" || smtp.From == "" {
+			return nc, fmt.Errorf("smtp host and from are required to send emails")
+		}
+		nc.notifier = emailNotifier{smtp: smtp, recipients: nc.Recipients}
+	case notifierPagerDuty:
+		pd := pagerDutyNotifier{url: pagerDutyEventsURL, routingKey: os.ExpandEnv(nc.RoutingKey), severity: defaultPagerDutySeverity}
+		if pd.routingKey == "" {
+			return nc, fmt.Errorf("routingKey is required")
+		}
+		if nc.URL != "" {
+			pd.url = nc.URL
+		}
+		switch nc.Severity {
+		case "":
+		case "critical", "error", "warning", "info":
+			pd.severity = nc.Severity
+		default:
+			return nc, fmt.Errorf("invalid severity %s, must be critical, error, warning or info", nc.Severity)
+		}
+		nc.notifier = pd
+	case notifierStdout:
+		nc.notifier = stdoutNotifier{w: os.Stdout}
+	default:
+		return nc, fmt.Errorf("unknown type %q, must be webhook, slack, email, pagerduty or stdout", nc.Type)
+	}
+	return nc, nil
+}
+
+// routes reports whether the notification is routed to the notifier
+func (nc NotifierConfig) routes(n Notification) bool {
+	return (len(nc.Events) == 0 || slices.Contains(nc.Events, n.Type)) && (n.Job == "" || matchJob(nc.Jobs, n.Job)) &&
+		(len(nc.Owners) == 0 || slices.Contains(nc.Owners, n.Owner))
+}
+
+// routed reports whether any notifier is routed notifications of the type
+func (n *NotifyConfig) routed(notificationType string) bool {
+	return slices.ContainsFunc(n.Notifiers, func(nc NotifierConfig) bool {
+		return len(nc.Events) == 0 || slices.Contains(nc.Events, notificationType)
+	})
+}
+
+// send sends the notification through the notifiers it's routed to
+func (n *NotifyConfig) send(note Notification) {
+	if note.Time.IsZero() {
+		note.Time = time.Now()
+	}
+	for _, nc := range n.Notifiers {
+		if !nc.routes(note) {
+			continue
+		}
+		if err := nc.notifier.notify(note); err != nil {
+			fmt.Printf("Error sending %s notification through %s: %v\n", note.Type, nc, err)
+		}
+	}
+}
+
+// String names the notifier in the logs
+func (nc NotifierConfig) String() string {
+	return cmp.Or(nc.Name, nc.Type)
+}
+
+// notifyRun notifies about a run added to the results, once. Runs that don't load aren't notified, their problems
+// are notified as load errors
+func (c *Config) notifyRun(event Event) {
+	if !c.notify.routed(notifyNewRun) {
+		return
+	}
+	runPath := filepath.Join(c.resultsDir, event.Job, event.Workload, event.Run)
+	run, ok := loadEventRun(runPath)
+	if !ok || !c.notify.markReported(notifyNewRun+"/"+runPath) {
+		return
+	}
+	status := "passed"
+	if !run.Summary.Passed {
+		status = "failed"
+	}
+	note := Notification{
+		Type:     notifyNewRun,
+		Job:      event.Job,
+		Workload: event.Workload,
+		Run:      run.Name(),
+		Key:      strings.Join([]string{event.Job, event.Workload, run.Name()}, "/"),
+		Title:    fmt.Sprintf("New %s run of %s/%s", status, event.Job, event.Workload),
+		Text: fmt.Sprintf("Run %s started at %s and %s, with %d measurements", run.Name(),
+			formatTime(run.Started(), c.location, time.RFC3339), status, len(run.Measurements)),
+	}
+	if c.notify.DashboardURL != "" {
+		note.URL = fmt.Sprintf("%s/job/%s/%s", strings.TrimSuffix(c.notify.DashboardURL, "/"), url.PathEscape(event.Job), url.PathEscape(event.Workload))
+	}
+	c.notify.send(note)
+}
+
+// notifyRegression notifies about a sustained regression confirmed by a watch
+func (n *NotifyConfig) notifyRegression(r Regression, loc *time.Location) {
+	note := Notification{
+		Type:     notifyRegression,
+		Job:      r.Job,
+		Workload: r.Workload,
+		Run:      r.FirstRegressed.Name,
+		Owner:    metricOwners.owner(r.MetricName, r.QuantileName),
+		Key:      r.key(),
+		Title:    "Performance regression: " + r.String(),
+		Text:     r.description(loc),
+	}
+	if n.DashboardURL != "" {
+		note.URL = regressionURL(r, n.DashboardURL)
+	}
+	n.send(note)
+}
+
+// notifyLoadError notifies about a problem found loading the results, located within the results directory
+func (c *Config) notifyLoadError(d Diagnostic) {
+	note := Notification{
+		Type:  notifyLoadError,
+		Key:   filepath.Join(d.Dir, d.File),
+		Title: fmt.Sprintf("Error loading %s", filepath.Join(d.Dir, d.File)),
+		Text:  fmt.Sprintf("%s: %s", d.Kind, d.Error),
+		Time:  d.Time,
+	}
+	if rel, err := filepath.Rel(c.resultsDir, d.Dir); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		for i, field := range []*string{&note.Job, &note.Workload, &note.Run} {
+			if i < len(parts) {
+				*field = parts[i]
+			}
+		}
+	}
+	if c.notify.DashboardURL != "" {
+		note.URL = strings.TrimSuffix(c.notify.DashboardURL, "/") + "/diagnostics"
+	}
+	c.notify.send(note)
+}
+
+// postJSON posts the payload as JSON, failing on error statuses
+func postJSON(endpoint string, headers map[string]string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := notifierClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// webhookNotifier posts the notifications as JSON
+type webhookNotifier struct {
+	url     string
+	headers map[string]string
+}
+
+func (w webhookNotifier) notify(n Notification) error {
+	return postJSON(w.url, w.headers, n)
+}
+
+// slackNotifier posts the notifications to a Slack incoming webhook
+type slackNotifier struct {
+	url string
+}
+
+func (s slackNotifier) notify(n Notification) error {
+	text := fmt.Sprintf("*%s*\n%s", n.Title, n.Text)
+	if n.URL != "" {
+		text += fmt.Sprintf("\n<%s|Open in the dashboard>", n.URL)
+	}
+	return postJSON(s.url, nil, map[string]string{"text": text})
+}
+
+// emailNotifier emails the notifications to its recipients
+type emailNotifier struct {
+	smtp       SMTPConfig
+	recipients []string
+}
+
+func (e emailNotifier) notify(n Notification) error {
+	body := n.Text
+	if n.URL != "" {
+		body += "\n\n" + n.URL
+	}
+	return e.smtp.sendMail(e.recipients, n.Title, body+"\n")
+}
+
+// pagerDutyNotifier triggers PagerDuty incidents through the Events API v2, deduplicated by the key of the
+// notifications
+type pagerDutyNotifier struct {
+	url        string
+	routingKey string
+	severity   string
+}
+
+func (p pagerDutyNotifier) notify(n Notification) error {
+	event := map[string]any{
+		"routing_key":  p.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    n.Type + "/" + n.Key,
+		"payload": map[string]any{
+			"summary":   n.Title,
+			"source":    "ocp-perf-dash",
+			"severity":  p.severity,
+			"timestamp": n.Time.Format(time.RFC3339),
+			"component": n.Job,
+			"group":     n.Workload,
+			"class":     n.Type,
+			"custom_details": map[string]string{
+				"text":  n.Text,
+				"run":   n.Run,
+				"owner": n.Owner,
+			},
+		},
+	}
+	if n.URL != "" {
+		event["links"] = []map[string]string{{"href": n.URL, "text": "Open in the dashboard"}}
+	}
+	return postJSON(p.url, nil, event)
+}
+
+// stdoutNotifier prints the notifications, a line each
+type stdoutNotifier struct {
+	w io.Writer
+}
+
+func (s stdoutNotifier) notify(n Notification) error {
+	line := fmt.Sprintf("[%s] %s: %s", n.Type, n.Title, n.Text)
+	if n.URL != "" {
+		line += " " + n.URL
+	}
+	_, err := fmt.Fprintln(s.w, line)
+	return err
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NotifyConfig configures the notifications sent by the dashboard, loaded from the YAML file given with
+// -notify-config
+type NotifyConfig struct {
+	// DashboardURL is the base URL of the dashboard, used to link notifications to its pages
+	DashboardURL string     `yaml:"dashboardURL"`
+	SMTP         SMTPConfig `yaml:"smtp"`
+	Digests      []Digest   `yaml:"digests"`
+	// Regressions are the metrics checked for sustained regressions as runs are added
+	Regressions []RegressionWatch `yaml:"regressions"`
+	// Jira opens an issue for every sustained regression when set
+	Jira *JiraConfig `yaml:"jira"`
+	// Alertmanager receives an alert for every sustained regression when set
+	Alertmanager *AlertmanagerConfig `yaml:"alertmanager"`
+	// Rules are evaluated over every run added, notifying the runs they fire for
+	Rules []AlertRule `yaml:"rules"`
+	// Notifiers are sent the notifications of the events routed to them: new runs, regressions, load errors, rules
+	// fired and workloads missing runs
+	Notifiers []NotifierConfig `yaml:"notifiers"`
+
+	mu sync.Mutex
+	// reported are the keys of the regressions already notified
+	reported map[string]bool
+}
+
+// SMTPConfig is the mail server emails are sent through
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username"`
+	// Password expands environment variables, such as ${SMTP_PASSWORD}, to keep it out of the file
+	Password string `yaml:"password"`
+	From     string `yaml:"from"`
+}
+
+func loadNotifyConfig(configPath string) (*NotifyConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	config := NotifyConfig{
+		SMTP: SMTPConfig{Port: 587},
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing notify config %s: %v", configPath, err)
+	}
+	config.SMTP.Password = os.ExpandEnv(config.SMTP.Password)
+	if len(config.Digests) > 0 && (config.SMTP.Host == "" || config.SMTP.From == "") {
+		return nil, fmt.Errorf("smtp host and from are required to send digests")
+	}
+	for i, digest := range config.Digests {
+		if digest, err = digest.validate(); err != nil {
+			return nil, fmt.Errorf("invalid digest %s: %v", digest.Name, err)
+		}
+		config.Digests[i] = digest
+	}
+	for i, watch := range config.Regressions {
+		if watch, err = watch.validate(); err != nil {
+			return nil, fmt.Errorf("invalid regression watch %s %s: %v", watch.Metric, watch.Quantile, err)
+		}
+		config.Regressions[i] = watch
+	}
+	if config.Jira != nil {
+		if err := config.Jira.validate(); err != nil {
+			return nil, fmt.Errorf("invalid jira config: %v", err)
+		}
+	}
+	if config.Alertmanager != nil {
+		if err := config.Alertmanager.validate(); err != nil {
+			return nil, fmt.Errorf("invalid alertmanager config: %v", err)
+		}
+	}
+	for i, rule := range config.Rules {
+		if config.Rules[i], err = rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid rule %s: %v", rule.Name, err)
+		}
+	}
+	for i, nc := range config.Notifiers {
+		if config.Notifiers[i], err = nc.validate(config.SMTP); err != nil {
+			return nil, fmt.Errorf("invalid notifier %s: %v", nc, err)
+		}
+	}
+	config.reported = make(map[string]bool)
+	return &config, nil
+}
+
+// markReported records the regression as notified, returning false when it already was
+func (n *NotifyConfig) markReported(key string) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.reported[key] {
+		return false
+	}
+	n.reported[key] = true
+	return true
+}
+
+func (n *NotifyConfig) unmarkReported(key string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.reported, key)
+}
+
+// matchJob returns true when the job matches any of the patterns, every job matches an empty list
+func matchJob(patterns []string, job string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, job); matched {
+			return true
+		}
+	}
+	return false
+}
+
+func validJobPatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid job pattern %q: %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+// sendMail sends a plain text email, authenticating when a username is configured. The connection is upgraded
+// with STARTTLS when the server supports it
+func (s SMTPConfig) sendMail(to []string, subject, body string) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprint(&msg, "MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return smtp.SendMail(net.JoinHostPort(s.Host, strconv.Itoa(s.Port)), auth, s.From, to, msg.Bytes())
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Pod lifecycle phases overlaid by default, the most common correlation analysis
+var defaultOverlayQuantiles = []string{"PodScheduled", "ContainersReady", "Ready"}
+
+const defaultOverlayMetric = "podLatencyQuantilesMeasurement"
+
+// OverlayChart holds a single percentile of several quantiles of a metric, aligned on the runs they belong to
+type OverlayChart struct {
+	MetricName string
+	Percentile string
+	Timestamps []time.Time
+	Series     []OverlaySeries
+}
+
+// OverlaySeries holds the values of a quantile, null where the quantile is missing in a run
+type OverlaySeries struct {
+	QuantileName string
+	Values       []*float64
+}
+
+// overlayParams are the overlay selection parsed from the query parameters
+type overlayParams struct {
+	metric     string
+	percentile string
+	quantiles  []string
+}
+
+func overlayParamsFromRequest(r *http.Request) (overlayParams, error) {
+	params := overlayParams{
+		metric:     r.URL.Query().Get("metric"),
+		percentile: "P99",
+		quantiles:  splitList(r.URL.Query().Get("quantiles")),
+	}
+	if params.metric == "" {
+		params.metric = defaultOverlayMetric
+	}
+	if p := r.URL.Query().Get("percentile"); p != "" {
+		percentile, err := parsePercentile(p)
+		if err != nil {
+			return params, err
+		}
+		params.percentile = percentile
+	}
+	return params, nil
+}
+
+// prepareOverlayChart overlays the selected percentile of the requested quantiles of a metric. When no quantiles are
+// requested the pod lifecycle phases available in the metric are used, or every quantile if none of them is present
+func prepareOverlayChart(metricGroups []MetricGroup, params overlayParams) (OverlayChart, error) {
+	overlay := OverlayChart{
+		MetricName: params.metric,
+		Percentile: params.percentile,
+	}
+	i := slices.IndexFunc(metricGroups, func(mg MetricGroup) bool { return mg.MetricName == params.metric })
+	if i < 0 {
+		return overlay, fmt.Errorf("metric %s not found", params.metric)
+	}
+	charts := metricGroups[i].Charts
+	quantiles := params.quantiles
+	if len(quantiles) == 0 {
+		for _, q := range defaultOverlayQuantiles {
+			if slices.ContainsFunc(charts, func(c ChartData) bool { return c.QuantileName == q }) {
+				quantiles = append(quantiles, q)
+			}
+		}
+	}
+	if len(quantiles) == 0 {
+		for _, chart := range charts {
+			quantiles = append(quantiles, chart.QuantileName)
+		}
+	}
+
+	// Align every series on the runs, the measurements of a run don't share the exact same timestamp
+	var selected []ChartData
+	runTimestamps := make(map[string]time.Time)
+	for _, q := range quantiles {
+		j := slices.IndexFunc(charts, func(c ChartData) bool { return c.QuantileName == q })
+		if j < 0 {
+			return overlay, fmt.Errorf("quantile %s not found in metric %s", q, params.metric)
+		}
+		selected = append(selected, charts[j])
+		for _, dp := range charts[j].Datapoints {
+			key := runKey(dp)
+			if ts, ok := runTimestamps[key]; !ok || dp.Timestamp.Before(ts) {
+				runTimestamps[key] = dp.Timestamp
+			}
+		}
+	}
+	var runs []string
+	for key := range runTimestamps {
+		runs = append(runs, key)
+	}
+	slices.SortFunc(runs, func(a, b string) int {
+		return runTimestamps[a].Compare(runTimestamps[b])
+	})
+	index := make(map[string]int, len(runs))
+	for k, key := range runs {
+		index[key] = k
+		overlay.Timestamps = append(overlay.Timestamps, runTimestamps[key])
+	}
+	for _, chart := range selected {
+		series := OverlaySeries{
+			QuantileName: chart.QuantileName,
+			Values:       make([]*float64, len(runs)),
+		}
+		for _, dp := range chart.Datapoints {
+			v := dp.value(params.percentile)
+			series.Values[index[runKey(dp)]] = &v
+		}
+		overlay.Series = append(overlay.Series, series)
+	}
+	return overlay, nil
+}
+
+// loadOverlay loads the runs of a workload and overlays the quantiles requested
+func (c *Config) loadOverlay(r *http.Request, jobName, workloadName string) (OverlayChart, []MetricGroup, int, error) {
+	params, err := overlayParamsFromRequest(r)
+	if err != nil {
+		return OverlayChart{}, nil, http.StatusBadRequest, err
+	}
+	job := Job{
+		Name: jobName,
+	}
+	job.Runs, err = loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		return OverlayChart{}, nil, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	metricGroups := prepareChartData(&job, ChartOptions{})
+	overlay, err := prepareOverlayChart(metricGroups, params)
+	if err != nil {
+		return overlay, metricGroups, http.StatusNotFound, err
+	}
+	return overlay, metricGroups, http.StatusOK, nil
+}
+
+func (c *Config) apiOverlayHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	overlay, _, status, err := c.loadOverlay(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, overlay)
+}
+
+func (c *Config) overlayHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	overlay, metricGroups, status, err := c.loadOverlay(r, jobName, workloadName)
+	if err != nil && status != http.StatusNotFound {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	type TemplateData struct {
+		JobName      string
+		WorkloadName string
+		Overlay      OverlayChart
+		OverlayJSON  template.JS
+		MetricGroups []MetricGroup
+		Percentiles  []string
+		Error        string
+	}
+	overlayJSON, _ := json.Marshal(overlay)
+	data := TemplateData{
+		JobName:      jobName,
+		WorkloadName: workloadName,
+		Overlay:      overlay,
+		OverlayJSON:  template.JS(overlayJSON),
+		MetricGroups: metricGroups,
+		Percentiles:  percentiles,
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "overlay.html", data)
+}
+
+// Selected reports whether the quantile is part of the overlay, used by the template to check the quantile boxes
+func (o OverlayChart) Selected(quantile string) bool {
+	return slices.ContainsFunc(o.Series, func(s OverlaySeries) bool { return s.QuantileName == quantile })
+}
+
+// runKey identifies the run a datapoint belongs to
+func runKey(dp DataPoint) string {
+	if dp.JobSummary.UUID != "" {
+		return dp.JobSummary.UUID
+	}
+	return dp.Timestamp.String()
+}
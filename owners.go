@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// unownedMetrics groups the metrics no owner claims in the reports
+const unownedMetrics = "unowned"
+
+// MetricOwner is a team owning the metrics matching its patterns, e.g. etcd metrics owned by the control plane team
+type MetricOwner struct {
+	Owner string `yaml:"owner"`
+	// Metrics are patterns matched against the metric names, or against metric/quantile when they hold a slash,
+	// such as etcd* or podLatencyQuantilesMeasurement/PodScheduled
+	Metrics []string `yaml:"metrics"`
+}
+
+// MetricOwners maps metrics to their owners, the first owner matching a metric owns it
+type MetricOwners []MetricOwner
+
+// metricOwners are the owners of the metrics, loaded from -metric-owners, none when empty
+var metricOwners MetricOwners
+
+// loadMetricOwners loads the owners of the metrics from the YAML file
+func loadMetricOwners(ownersPath string) error {
+	data, err := os.ReadFile(ownersPath)
+	if err != nil {
+		return err
+	}
+	var owners MetricOwners
+	if err := yaml.Unmarshal(data, &owners); err != nil {
+		return fmt.Errorf("error parsing metric owners %s: %v", ownersPath, err)
+	}
+	for _, owner := range owners {
+		if owner.Owner == "" || owner.Owner == unownedMetrics {
+			return fmt.Errorf("invalid owner %q in %s", owner.Owner, ownersPath)
+		}
+		for _, pattern := range owner.Metrics {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid metric pattern %q of owner %s: %v", pattern, owner.Owner, err)
+			}
+		}
+	}
+	metricOwners = owners
+	return nil
+}
+
+// owner returns the owner of the metric quantile, empty when no owner claims it
+func (o MetricOwners) owner(metric, quantile string) string {
+	for _, owner := range o {
+		for _, pattern := range owner.Metrics {
+			name := metric
+			if strings.Contains(pattern, "/") {
+				name = metric + "/" + quantile
+			}
+			if matched, _ := path.Match(pattern, name); matched {
+				return owner.Owner
+			}
+		}
+	}
+	return ""
+}
+
+// ownerOrder returns the owners given in the order they're configured in, the unowned metrics last
+func (o MetricOwners) ownerOrder(owners []string) []string {
+	var order []string
+	for _, owner := range o {
+		if slices.Contains(owners, owner.Owner) && !slices.Contains(order, owner.Owner) {
+			order = append(order, owner.Owner)
+		}
+	}
+	if slices.Contains(owners, unownedMetrics) {
+		order = append(order, unownedMetrics)
+	}
+	return order
+}
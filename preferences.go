@@ -0,0 +1,349 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// pinsCookie holds the workloads pinned by anonymous users before they had sessions, as a comma separated list of
+// job/workload. It's read until the preferences are saved in a session
+const pinsCookie = "pins"
+
+// sessionCookie identifies the session anonymous users keep their preferences in, created when they first save them
+const sessionCookie = "session"
+
+// sessionPrefix keys the preferences of the sessions in the store, apart from the ones of the authenticated users
+const sessionPrefix = "session:"
+
+// Themes of the pages, light when none is picked
+const (
+	themeLight = "light"
+	themeDark  = "dark"
+)
+
+// Pin is a workload pinned to the top of the job list
+type Pin struct {
+	Job      string `json:"job"`
+	Workload string `json:"workload"`
+}
+
+// UserPreferences are the defaults of a user, applied to the pages unless their query parameters say otherwise
+type UserPreferences struct {
+	Pins []Pin `json:"pins"`
+	// Percentiles are the series charted by default, all of them when empty
+	Percentiles []string `json:"percentiles,omitempty"`
+	// Last is the number of most recent runs charted by default, all of them when zero
+	Last  int    `json:"last,omitempty"`
+	Theme string `json:"theme,omitempty"`
+	// Locale formats the numbers and dates, the one of the browser when empty
+	Locale string `json:"locale,omitempty"`
+}
+
+// applyChartDefaults fills the chart options the query parameters leave unset with the preferences. The run window
+// only applies when no time range is given either
+func (p UserPreferences) applyChartDefaults(values url.Values, opts *ChartOptions) {
+	if !values.Has("percentiles") && len(p.Percentiles) > 0 {
+		opts.Percentiles = p.Percentiles
+	}
+	if !values.Has("last") && !values.Has("from") && !values.Has("to") && p.Last > 0 {
+		opts.Last = p.Last
+	}
+}
+
+// preferencesStore keeps the preferences of the users authenticated by the proxy, and the ones of the sessions of
+// anonymous users. Preferences are persisted to a JSON file when configured, and kept in memory otherwise
+type preferencesStore struct {
+	mu    sync.Mutex
+	path  string
+	users map[string]UserPreferences
+}
+
+func loadPreferences(path string) (*preferencesStore, error) {
+	store := &preferencesStore{
+		path:  path,
+		users: make(map[string]UserPreferences),
+	}
+	if path == "" {
+		return store, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.users); err != nil {
+		return nil, fmt.Errorf("error parsing preferences file %s: %v", path, err)
+	}
+	return store, nil
+}
+
+func (s *preferencesStore) get(user string) UserPreferences {
+	prefs, _ := s.lookup(user)
+	return prefs
+}
+
+// lookup returns the preferences stored under the key, and whether there are any
+func (s *preferencesStore) lookup(key string) (UserPreferences, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefs, ok := s.users[key]
+	return prefs, ok
+}
+
+// set stores the preferences of the user, replacing the preferences file atomically
+func (s *preferencesStore) set(user string, prefs UserPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[user] = prefs
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.users, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// writeFileAtomic replaces the file with the data through a temporary file, so readers never see it half written
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// user returns the user authenticated by the proxy, empty for anonymous users or when no policy is configured
+func (c *Config) user(r *http.Request) string {
+	if c.policy == nil {
+		return ""
+	}
+	return c.policy.identity(r).User
+}
+
+// preferencesKey returns the key the preferences of the client of the request are stored under, the authenticated
+// user or the session, empty for anonymous users without a session yet
+func (c *Config) preferencesKey(r *http.Request) string {
+	if user := c.user(r); user != "" {
+		return user
+	}
+	if cookie, err := r.Cookie(sessionCookie); err == nil && cookie.Value != "" {
+		return sessionPrefix + cookie.Value
+	}
+	return ""
+}
+
+// preferences returns the preferences of the client of the request
+func (c *Config) preferences(r *http.Request) UserPreferences {
+	if key := c.preferencesKey(r); key != "" {
+		if prefs, ok := c.prefs.lookup(key); ok {
+			return prefs
+		}
+	}
+	if c.user(r) != "" {
+		return UserPreferences{}
+	}
+	return UserPreferences{Pins: cookiePins(r)}
+}
+
+// savePreferences stores the preferences of the client of the request, starting a session for anonymous users
+// without one
+func (c *Config) savePreferences(w http.ResponseWriter, r *http.Request, prefs UserPreferences) error {
+	key := c.preferencesKey(r)
+	if key == "" {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+		key = sessionPrefix + hex.EncodeToString(id)
+		http.SetCookie(w, &http.Cookie{
+			Name:     sessionCookie,
+			Value:    strings.TrimPrefix(key, sessionPrefix),
+			Path:     "/",
+			MaxAge:   365 * 24 * 60 * 60,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	if _, err := r.Cookie(pinsCookie); err == nil {
+		// The pins now live in the session
+		http.SetCookie(w, &http.Cookie{Name: pinsCookie, Path: "/", MaxAge: -1})
+	}
+	return c.prefs.set(key, prefs)
+}
+
+// pins returns the workloads pinned by the client of the request
+func (c *Config) pins(r *http.Request) []Pin {
+	return c.preferences(r).Pins
+}
+
+// cookiePins returns the workloads pinned in the cookie of anonymous users without a session
+func cookiePins(r *http.Request) []Pin {
+	cookie, err := r.Cookie(pinsCookie)
+	if err != nil {
+		return nil
+	}
+	value, err := url.QueryUnescape(cookie.Value)
+	if err != nil {
+		return nil
+	}
+	var pins []Pin
+	for _, item := range splitList(value) {
+		if job, workload, ok := strings.Cut(item, "/"); ok {
+			pins = append(pins, Pin{Job: job, Workload: workload})
+		}
+	}
+	return pins
+}
+
+// savePins stores the pinned workloads in the preferences of the client of the request
+func (c *Config) savePins(w http.ResponseWriter, r *http.Request, pins []Pin) error {
+	prefs := c.preferences(r)
+	prefs.Pins = pins
+	return c.savePreferences(w, r, prefs)
+}
+
+// pinnedWorkloads returns the pinned workloads found among the given jobs, in the order they were pinned
+func pinnedWorkloads(pins []Pin, jobs []Job) []Workload {
+	var workloads []Workload
+	for _, pin := range pins {
+		i := slices.IndexFunc(jobs, func(job Job) bool { return job.Name == pin.Job })
+		if i < 0 {
+			continue
+		}
+		j := slices.IndexFunc(jobs[i].Workloads, func(w Workload) bool { return w.Name == pin.Workload })
+		if j >= 0 {
+			workloads = append(workloads, jobs[i].Workloads[j])
+		}
+	}
+	return workloads
+}
+
+// pinHandler pins or unpins the workload given in the form, and goes back to its page
+func (c *Config) pinHandler(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	pin := Pin{Job: r.FormValue("job"), Workload: r.FormValue("workload")}
+	pinned, err := strconv.ParseBool(r.FormValue("pinned"))
+	if err != nil || pin.Job == "" || pin.Workload == "" || strings.ContainsAny(pin.Job+pin.Workload, "/,") {
+		http.Error(w, "job, workload and pinned are required", http.StatusBadRequest)
+		return
+	}
+	if !c.jobAllowed(r, pin.Job) {
+		jobForbidden(w, pin.Job)
+		return
+	}
+	pins := slices.DeleteFunc(c.pins(r), func(p Pin) bool { return p == pin })
+	if pinned {
+		pins = append(pins, pin)
+	}
+	if err := c.savePins(w, r, pins); err != nil {
+		http.Error(w, fmt.Sprintf("error saving pins: %v", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/job/%s/%s", url.PathEscape(pin.Job), url.PathEscape(pin.Workload)), http.StatusSeeOther)
+}
+
+// preferencesHandler shows the preferences of the client of the request
+func (c *Config) preferencesHandler(w http.ResponseWriter, r *http.Request) {
+	type PercentileOption struct {
+		Name     string
+		Selected bool
+	}
+	type TemplateData struct {
+		Preferences UserPreferences
+		Percentiles []PercentileOption
+		Locales     []*Locale
+		User        string
+	}
+	prefs := c.preferences(r)
+	data := TemplateData{Preferences: prefs, Locales: locales, User: c.user(r)}
+	for _, p := range percentiles {
+		data.Percentiles = append(data.Percentiles, PercentileOption{
+			Name:     p,
+			Selected: len(prefs.Percentiles) == 0 || slices.Contains(prefs.Percentiles, p),
+		})
+	}
+	c.renderTemplate(w, r, "preferences.html", data)
+}
+
+// savePreferencesHandler saves the preferences posted in the form, and goes back to the preferences page
+func (c *Config) savePreferencesHandler(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	prefs := c.preferences(r)
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	prefs.Percentiles = nil
+	for _, name := range r.Form["percentiles"] {
+		percentile, err := parsePercentile(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		prefs.Percentiles = append(prefs.Percentiles, percentile)
+	}
+	// Every percentile is the same as no default
+	if len(prefs.Percentiles) == len(percentiles) {
+		prefs.Percentiles = nil
+	}
+	prefs.Last = 0
+	if last := r.FormValue("last"); last != "" {
+		var err error
+		if prefs.Last, err = strconv.Atoi(last); err != nil || prefs.Last < 0 {
+			http.Error(w, "invalid last: must be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+	switch prefs.Theme = r.FormValue("theme"); prefs.Theme {
+	case "", themeLight, themeDark:
+	default:
+		http.Error(w, fmt.Sprintf("invalid theme: must be %s or %s", themeLight, themeDark), http.StatusBadRequest)
+		return
+	}
+	if prefs.Locale = r.FormValue("locale"); prefs.Locale != "" {
+		locale, err := parseLocale(prefs.Locale)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		prefs.Locale = locale.String()
+	}
+	if r.FormValue("clear-pins") != "" {
+		prefs.Pins = nil
+	}
+	if err := c.savePreferences(w, r, prefs); err != nil {
+		http.Error(w, fmt.Sprintf("error saving preferences: %v", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/preferences", http.StatusSeeOther)
+}
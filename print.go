@@ -0,0 +1,103 @@
+package main
+
+import (
+	"cmp"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"path/filepath"
+	"time"
+)
+
+// PrintPage is the workload laid out for printing: every chart as an image, a metric group per page
+type PrintPage struct {
+	JobName      string
+	WorkloadName string
+	Percentile   string
+	// Runs is the number of runs charted, run from First to Last
+	Runs        int
+	First, Last time.Time
+	Generated   time.Time
+	Groups      []PrintGroup
+}
+
+// PrintGroup holds the charts of a metric group, a chart per quantile
+type PrintGroup struct {
+	MetricName  string
+	JobName     string
+	Description string
+	Charts      []PrintChart
+}
+
+// PrintChart is the chart of the percentile of a quantile, rendered server side as a PNG data URL since its
+// image has no text, the range of its values is printed along it
+type PrintChart struct {
+	QuantileName string
+	Description  string
+	Image        template.URL
+	Datapoints   int
+	Latest       float64
+	Min          float64
+	Max          float64
+	Median       float64
+}
+
+// printHandler renders the charts of the workload as images, for printing or saving as a PDF from the browser. It
+// charts the percentile query parameter, P99 by default, of the runs matching the chart filters
+func (c *Config) printHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	percentile, err := parsePercentile(cmp.Or(r.URL.Query().Get("percentile"), "P99"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The images plot a single series, split by kube-burner job like the workload charts
+	opts.SplitBy = ""
+	opts.GroupByJob = opts.GroupByJob || r.URL.Query().Get("group") == ""
+	job := Job{Name: jobName}
+	if job.Runs, err = loadRuns(filepath.Join(c.resultsDir, jobName, workloadName)); err != nil {
+		http.Error(w, fmt.Sprintf("workload %s/%s not found", jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	page := PrintPage{JobName: jobName, WorkloadName: workloadName, Percentile: percentile, Generated: time.Now()}
+	if runs := opts.filterRuns(job.Runs); len(runs) > 0 {
+		page.Runs, page.First, page.Last = len(runs), runs[0].Started(), runs[len(runs)-1].Started()
+	}
+	for _, group := range prepareChartData(&job, opts) {
+		printGroup := PrintGroup{MetricName: group.MetricName, JobName: group.JobName, Description: group.Description}
+		for _, chart := range group.Charts {
+			if len(chart.Datapoints) == 0 {
+				continue
+			}
+			values := make([]float64, len(chart.Datapoints))
+			for i, dp := range chart.Datapoints {
+				values[i] = dp.value(percentile)
+			}
+			image, err := lineChart(values, len(values))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("error rendering chart: %v", err), http.StatusInternalServerError)
+				return
+			}
+			sorted := sortedCopy(values)
+			printGroup.Charts = append(printGroup.Charts, PrintChart{
+				QuantileName: chart.QuantileName,
+				Description:  chart.Description,
+				Image:        template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(image)),
+				Datapoints:   len(values),
+				Latest:       values[len(values)-1],
+				Min:          sorted[0],
+				Max:          sorted[len(sorted)-1],
+				Median:       quantile(sorted, 0.5),
+			})
+		}
+		if len(printGroup.Charts) > 0 {
+			page.Groups = append(page.Groups, printGroup)
+		}
+	}
+	c.renderTemplate(w, r, "print.html", page)
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// promotionFile records where a promoted run was copied from, within the run directory of the copy
+const promotionFile = "promotion.json"
+
+// RunPromotion is the provenance of a run promoted from a scratch job into a curated one
+type RunPromotion struct {
+	Job        string    `json:"job"`
+	Workload   string    `json:"workload"`
+	Run        string    `json:"run"`
+	PromotedBy string    `json:"promotedBy,omitempty"`
+	PromotedAt time.Time `json:"promotedAt"`
+}
+
+// loadRunPromotion returns the provenance of the run, nil when it wasn't promoted
+func loadRunPromotion(runPath string, files []fs.DirEntry) *RunPromotion {
+	if !slices.ContainsFunc(files, func(f fs.DirEntry) bool { return f.Name() == promotionFile }) {
+		return nil
+	}
+	data, err := storage.ReadFile(filepath.Join(runPath, promotionFile))
+	if err != nil {
+		diagnostics.report(runPath, promotionFile, readError, err)
+		return nil
+	}
+	var promotion RunPromotion
+	if err := json.Unmarshal(data, &promotion); err != nil {
+		diagnostics.report(runPath, promotionFile, parseError, err)
+		return nil
+	}
+	return &promotion
+}
+
+// canPromote returns true when the client of the request can promote runs: admins, or anyone when no policy is
+// configured, as long as results are local
+func (c *Config) canPromote(r *http.Request) bool {
+	return localResults() && (c.policy == nil || c.isAdmin(r))
+}
+
+// errRunExists is returned when the target workload already holds a run of the same name
+var errRunExists = errors.New("run already exists")
+
+// promoteRun copies the run into the workload of the target job, along with its provenance. The copy is made in a
+// hidden directory renamed once complete, so the watcher never loads a partial run
+func promoteRun(resultsDir string, source RunLocation, job, workload, user string) (string, error) {
+	if !localResults() {
+		return "", errRemoteResults
+	}
+	for _, name := range []string{job, workload} {
+		if !validName(name) {
+			return "", fmt.Errorf("invalid job or workload name %q", name)
+		}
+	}
+	if job == source.Job && workload == source.Workload {
+		return "", fmt.Errorf("run %s is already in %s/%s", source.Run.Name(), job, workload)
+	}
+	workloadPath := filepath.Join(resultsDir, job, workload)
+	dest := filepath.Join(workloadPath, source.Run.Name())
+	if _, err := os.Stat(dest); err == nil {
+		return "", fmt.Errorf("%w: %s/%s/%s", errRunExists, job, workload, source.Run.Name())
+	}
+	if err := os.MkdirAll(workloadPath, 0o755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(workloadPath, ".promote-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+	if err := os.CopyFS(tmp, os.DirFS(source.Run.Path)); err != nil {
+		return "", fmt.Errorf("error copying run %s: %v", source.Run.Path, err)
+	}
+	promotion := RunPromotion{
+		Job:        source.Job,
+		Workload:   source.Workload,
+		Run:        source.Run.Name(),
+		PromotedBy: user,
+		PromotedAt: time.Now().UTC(),
+	}
+	data, err := json.MarshalIndent(promotion, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(tmp, promotionFile), data, 0o644); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp, 0o755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		return "", err
+	}
+	fmt.Printf("Run %s of %s/%s promoted to %s/%s by %q\n", source.Run.Name(), source.Job, source.Workload, job, workload, user)
+	return dest, nil
+}
+
+// promote promotes the run of the UUID given in the request into the job and workload of its form, the workload of
+// the run when none is given. It returns the HTTP status and the location of the promoted run
+func (c *Config) promote(r *http.Request, uuid string) (RunLocation, int, error) {
+	if !c.canPromote(r) {
+		return RunLocation{}, http.StatusForbidden, errors.New("promoting runs requires admin access to local results")
+	}
+	jobs, err := c.visibleJobs(r)
+	if err != nil {
+		return RunLocation{}, http.StatusInternalServerError, err
+	}
+	job, workload := r.FormValue("job"), r.FormValue("workload")
+	// Runs promoted already share the UUID of their source, which is looked up outside of the target workload
+	for i := range jobs {
+		if jobs[i].Name == job {
+			jobs[i].Workloads = slices.DeleteFunc(slices.Clone(jobs[i].Workloads), func(w Workload) bool { return w.Name == workload })
+		}
+	}
+	source, ok := findRun(jobs, uuid)
+	if !ok {
+		return RunLocation{}, http.StatusNotFound, fmt.Errorf("run %s not found", uuid)
+	}
+	if workload == "" {
+		workload = source.Workload
+	}
+	if !c.jobAllowed(r, job) {
+		return RunLocation{}, http.StatusForbidden, fmt.Errorf("access to job %s denied", job)
+	}
+	dest, err := promoteRun(c.resultsDir, source, job, workload, c.user(r))
+	switch {
+	case errors.Is(err, errRunExists):
+		return RunLocation{}, http.StatusConflict, err
+	case err != nil:
+		return RunLocation{}, http.StatusBadRequest, err
+	}
+	run, err := loadRun(dest)
+	if err != nil {
+		return RunLocation{}, http.StatusInternalServerError, err
+	}
+	return RunLocation{Job: job, Workload: workload, Run: run, Links: run.Links()}, http.StatusCreated, nil
+}
+
+// apiPromoteHandler promotes a run, returning the promoted copy
+func (c *Config) apiPromoteHandler(w http.ResponseWriter, r *http.Request) {
+	location, status, err := c.promote(r, r.PathValue("uuid"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	writeJSON(w, location)
+}
+
+// promoteHandler promotes the run selected on the workload page, and redirects to the curated workload
+func (c *Config) promoteHandler(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	location, status, err := c.promote(r, r.FormValue("uuid"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	http.Redirect(w, r, fmt.Sprintf("/job/%s/%s", url.PathEscape(location.Job), url.PathEscape(location.Workload)), http.StatusSeeOther)
+}
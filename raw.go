@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+)
+
+// Formats the raw measurement documents are streamed in
+const (
+	// rawFormatJSON is a JSON array of the documents, as found in the measurement files
+	rawFormatJSON = "json"
+	// rawFormatNDJSON is a document per line
+	rawFormatNDJSON = "ndjson"
+)
+
+// findWorkloadRun returns the run of the given kube-burner UUID or directory name
+func findWorkloadRun(runs []Run, id string) (Run, bool) {
+	i := slices.IndexFunc(runs, func(run Run) bool {
+		return run.UUID() == id || run.Name() == id
+	})
+	if i < 0 {
+		return Run{}, false
+	}
+	return runs[i], true
+}
+
+// runMeasurementFiles returns the measurement files of the run, quantile and raw ones, in name order
+func runMeasurementFiles(run Run) ([]string, error) {
+	entries, err := storage.ReadDir(run.Path)
+	if err != nil {
+		return nil, err
+	}
+	files := matchMeasurementFiles(run.Path, entries, quantilesParser)
+	files = append(files, matchMeasurementFiles(run.Path, entries, rawParser)...)
+	slices.Sort(files)
+	return files, nil
+}
+
+// rawDocuments returns the documents of the measurement file, only the ones of the metric when given. Documents are
+// kept as found in the file, so clients get the exact source data
+func rawDocuments(file, metric string) ([]json.RawMessage, error) {
+	data, err := storage.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var docs []json.RawMessage
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return nil, fmt.Errorf("error unmarshaling file %s: %v", file, err)
+	}
+	if metric == "" {
+		return docs, nil
+	}
+	return slices.DeleteFunc(docs, func(doc json.RawMessage) bool {
+		var fields struct {
+			MetricName string `json:"metricName"`
+		}
+		return json.Unmarshal(doc, &fields) != nil || fields.MetricName != metric
+	}), nil
+}
+
+// streamRawDocuments writes the documents of the files as they're read, flushing them file by file so large runs
+// aren't buffered. Files that can't be read are skipped, as the response is already underway
+func streamRawDocuments(w http.ResponseWriter, r *http.Request, files []string, metric, format string) {
+	if format == rawFormatNDJSON {
+		enc := newNDJSONEncoder(w)
+		for _, file := range files {
+			docs, err := rawDocuments(file, metric)
+			if err != nil {
+				fmt.Printf("Error reading raw documents of %s: %v\n", file, err)
+				continue
+			}
+			for _, doc := range docs {
+				if err := enc.encode(doc); err != nil {
+					return
+				}
+			}
+			enc.flush()
+		}
+		return
+	}
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, "[")
+	first := true
+	for _, file := range files {
+		if r.Context().Err() != nil {
+			return
+		}
+		docs, err := rawDocuments(file, metric)
+		if err != nil {
+			fmt.Printf("Error reading raw documents of %s: %v\n", file, err)
+			continue
+		}
+		for _, doc := range docs {
+			if !first {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprint(w, "\n")
+			w.Write(doc)
+			first = false
+		}
+		rc.Flush()
+	}
+	fmt.Fprint(w, "\n]\n")
+}
+
+// apiRawHandler streams the original measurement documents of a run, the ones of a metric with metric=, as a JSON
+// array or as NDJSON with format=ndjson, or when the client accepts NDJSON
+func (c *Config) apiRawHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName, id := r.PathValue("job"), r.PathValue("workload"), r.PathValue("run")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	switch format {
+	case "":
+		if wantsNDJSON(r) {
+			format = rawFormatNDJSON
+		}
+	case rawFormatJSON, rawFormatNDJSON:
+	default:
+		http.Error(w, fmt.Sprintf("invalid format: must be %s or %s", rawFormatJSON, rawFormatNDJSON), http.StatusBadRequest)
+		return
+	}
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("workload %s/%s not found", jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	run, ok := findWorkloadRun(runs, id)
+	if !ok {
+		http.Error(w, fmt.Sprintf("run %s not found in %s/%s", id, jobName, workloadName), http.StatusNotFound)
+		return
+	}
+	files, err := runMeasurementFiles(run)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading run %s: %v", run.Name(), err), http.StatusInternalServerError)
+		return
+	}
+	streamRawDocuments(w, r, files, r.URL.Query().Get("metric"), format)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// readmeFile describes the job or workload directory holding it, e.g. its test environment, cadence or owner
+const readmeFile = "README.md"
+
+var (
+	// READMEs are GitHub flavored Markdown, raw HTML included as it's sanitized afterwards
+	markdown = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		goldmark.WithRendererOptions(html.WithUnsafe()),
+	)
+	// readmePolicy strips the scripts, styles, forms and event handlers of the rendered READMEs, keeping the
+	// formatting, links and images
+	readmePolicy = bluemonday.UGCPolicy()
+)
+
+// Readme is the rendered README of a job or workload
+type Readme struct {
+	Title string
+	HTML  template.HTML
+	// Open readmes are shown expanded
+	Open bool
+}
+
+// loadReadme renders the README of the directory as sanitized HTML, nil when the directory has none
+func loadReadme(dir, title string) (*Readme, error) {
+	data, err := storage.ReadFile(filepath.Join(dir, readmeFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := markdown.Convert(data, &buf); err != nil {
+		return nil, fmt.Errorf("error rendering %s: %v", filepath.Join(dir, readmeFile), err)
+	}
+	return &Readme{Title: title, HTML: template.HTML(readmePolicy.SanitizeBytes(buf.Bytes()))}, nil
+}
+
+// loadReadmes returns the READMEs shown on the page of a job, or of one of its workloads when given. Workload pages
+// show the README of the workload expanded, and the one of its job collapsed
+func loadReadmes(resultsDir, jobName, workloadName string) []Readme {
+	dirs := []struct{ path, title string }{{filepath.Join(resultsDir, jobName), jobName}}
+	if workloadName != "" {
+		dirs = append(dirs, struct{ path, title string }{filepath.Join(resultsDir, jobName, workloadName), workloadName})
+	}
+	var readmes []Readme
+	for i, dir := range dirs {
+		readme, err := loadReadme(dir.path, dir.title)
+		if err != nil {
+			fmt.Println("Error loading README:", err)
+		}
+		if readme == nil {
+			continue
+		}
+		readme.Open = i == len(dirs)-1
+		readmes = append(readmes, *readme)
+	}
+	return readmes
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+)
+
+// resultsScan maps every job, workload and run directory, relative to the results directory, to the
+// fingerprint of its files. Only run directories are fingerprinted
+type resultsScan map[string]runFingerprint
+
+// pollResults rescans the results directory every interval, refreshing the cached runs and publishing an event
+// for every job, workload or run added, removed or modified since the previous scan. It replaces watchResults
+// where filesystem notifications aren't delivered, such as network mounts and remote backends
+func (c *Config) pollResults(interval time.Duration) {
+	c.rescan = make(chan struct{}, 1)
+	go func() {
+		previous, _ := c.scanResults()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+			case <-c.rescan:
+			}
+			if r, ok := storage.(refresher); ok {
+				if err := r.refresh(); err != nil {
+					fmt.Println("Error refreshing results:", err)
+					continue
+				}
+			}
+			current, err := c.scanResults()
+			if err != nil {
+				// Keep the previous scan, a partial one would report missing runs as removed
+				fmt.Println("Error scanning results directory:", err)
+				continue
+			}
+			for _, event := range c.scanEvents(previous, current) {
+				c.publish(event)
+			}
+			previous = current
+		}
+	}()
+}
+
+// scanResults walks the results directory down to the run level
+func (c *Config) scanResults() (resultsScan, error) {
+	scan := make(resultsScan)
+	return scan, c.scanDir(scan, c.resultsDir)
+}
+
+func (c *Config) scanDir(scan resultsScan, dir string) error {
+	entries, err := storage.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		rel, err := filepath.Rel(c.resultsDir, path)
+		if err != nil {
+			return err
+		}
+		if depth(c.resultsDir, path) == 3 {
+			if fp, err := fingerprint(path); err == nil {
+				scan[rel] = fp
+			}
+			continue
+		}
+		scan[rel] = runFingerprint{}
+		if err := c.scanDir(scan, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scanEvents compares two scans, reloading the runs that changed so the cache is warm by the time clients
+// request them, and returns one event per changed directory
+func (c *Config) scanEvents(previous, current resultsScan) []Event {
+	var events []Event
+	for rel, fp := range current {
+		if old, ok := previous[rel]; ok && old == fp {
+			continue
+		}
+		path := filepath.Join(c.resultsDir, rel)
+		if depth(c.resultsDir, path) == 3 {
+			if _, err := loadRun(path); err != nil {
+				fmt.Fprintln(loadLog, err)
+			}
+		}
+		if event, ok := c.eventFromPath(path); ok {
+			events = append(events, event)
+		}
+	}
+	for rel := range previous {
+		if _, ok := current[rel]; ok {
+			continue
+		}
+		path := filepath.Join(c.resultsDir, rel)
+		runsCache.delete(path)
+		if event, ok := c.eventFromPath(path); ok {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// requestRescan triggers an immediate scan of the results directory, it's a no-op when a scan is already pending
+func (c *Config) requestRescan() {
+	select {
+	case c.rescan <- struct{}{}:
+	default:
+	}
+}
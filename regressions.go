@@ -0,0 +1,155 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RegressionWatch checks a metric quantile of the workloads of the matching jobs for sustained regressions every
+// time a run is added, notifying the regressions confirmed by the given number of consecutive runs
+type RegressionWatch struct {
+	// Jobs are the patterns of the jobs watched, every job when empty
+	Jobs        []string `yaml:"jobs"`
+	Metric      string   `yaml:"metric"`
+	Quantile    string   `yaml:"quantile"`
+	Percentile  string   `yaml:"percentile"`
+	Threshold   float64  `yaml:"threshold"`
+	Consecutive int      `yaml:"consecutive"`
+}
+
+// Regression is a sustained regression of a workload confirmed by a watch
+type Regression struct {
+	Job      string
+	Workload string
+	BisectResult
+}
+
+// validate checks the watch and fills in its defaults, the same as the bisect view
+func (w RegressionWatch) validate() (RegressionWatch, error) {
+	if err := validJobPatterns(w.Jobs); err != nil {
+		return w, err
+	}
+	w.Metric = cmp.Or(w.Metric, defaultOverlayMetric)
+	w.Quantile = cmp.Or(w.Quantile, defaultTrendQuantile)
+	var err error
+	if w.Percentile, err = parsePercentile(cmp.Or(w.Percentile, "P99")); err != nil {
+		return w, err
+	}
+	if w.Threshold < 0 || w.Consecutive < 0 {
+		return w, fmt.Errorf("threshold and consecutive must be positive")
+	}
+	w.Threshold = cmp.Or(w.Threshold, defaultBisectThreshold)
+	w.Consecutive = cmp.Or(w.Consecutive, defaultBisectConsecutive)
+	return w, nil
+}
+
+func (w RegressionWatch) bisectOptions() bisectOptions {
+	return bisectOptions{
+		MetricName:   w.Metric,
+		QuantileName: w.Quantile,
+		Percentile:   w.Percentile,
+		Threshold:    w.Threshold,
+		Consecutive:  w.Consecutive,
+	}
+}
+
+// key identifies the regression by the run it started at, so it's notified once
+func (r Regression) key() string {
+	return strings.Join([]string{r.Job, r.Workload, r.MetricName, r.QuantileName, r.Percentile, r.FirstRegressed.Name}, "/")
+}
+
+// String describes the regression in a line
+func (r Regression) String() string {
+	return fmt.Sprintf("%s %s %s %+.1f%% in %s/%s", r.MetricName, r.QuantileName, r.Percentile, r.FirstRegressed.Delta, r.Job, r.Workload)
+}
+
+// description explains the regression and the run it started at
+func (r Regression) description(loc *time.Location) string {
+	return fmt.Sprintf("The %s of %s %s in %s/%s exceeded the reference of %.0f ms by more than %g%% in the latest %d runs, since run #%d %s started at %s",
+		r.Percentile, r.MetricName, r.QuantileName, r.Job, r.Workload, r.Reference, r.Threshold, r.Sustained,
+		r.FirstRegressed.Seq, r.FirstRegressed.Name, formatTime(r.FirstRegressed.Timestamp, loc, time.RFC3339))
+}
+
+// regressionURL links the regression to the bisect view of the dashboard
+func regressionURL(r Regression, dashboardURL string) string {
+	return fmt.Sprintf("%s/job/%s/%s/bisect?%s", strings.TrimSuffix(dashboardURL, "/"), url.PathEscape(r.Job), url.PathEscape(r.Workload),
+		url.Values{"metric": {r.MetricName}, "quantile": {r.QuantileName}, "percentile": {r.Percentile},
+			"threshold": {fmt.Sprint(r.Threshold)}, "consecutive": {fmt.Sprint(r.Consecutive)}}.Encode())
+}
+
+// checkRegressions checks the workload against the watches matching its job, and notifies the sustained
+// regressions that weren't notified yet
+func (c *Config) checkRegressions(job, workload string) {
+	workloadPath := filepath.Join(c.resultsDir, job, workload)
+	var runs []Run
+	var snapshot *BaselineSnapshot
+	for _, watch := range c.notify.Regressions {
+		if !matchJob(watch.Jobs, job) {
+			continue
+		}
+		if runs == nil {
+			var err error
+			if runs, err = loadRuns(workloadPath); err != nil {
+				fmt.Printf("Error loading runs for %s/%s: %v\n", job, workload, err)
+				return
+			}
+			if snapshot, err = loadBaseline(workloadPath); err != nil {
+				fmt.Println(err)
+				return
+			}
+		}
+		result, err := sustainedRegression(runs, snapshot, watch.bisectOptions())
+		if err != nil {
+			continue
+		}
+		regression := Regression{Job: job, Workload: workload, BisectResult: result}
+		// Alerts are updated with every run, so they're resolved once the regression recovers
+		if c.notify.Alertmanager != nil {
+			if err := c.notify.Alertmanager.update(regression, c.notify.DashboardURL, c.location); err != nil {
+				fmt.Println(err)
+			}
+		}
+		if result.FirstRegressed == nil {
+			continue
+		}
+		if c.notify.routed(notifyRegression) && c.notify.markReported(notifyRegression+"/"+regression.key()) {
+			c.notify.notifyRegression(regression, c.location)
+		}
+		// Issues are tracked apart from the notifications, so failing to open one doesn't notify the regression again
+		if c.notify.Jira == nil || !c.notify.markReported(regression.key()) {
+			continue
+		}
+		if err := c.notify.Jira.openIssue(regression, runs, c.notify.DashboardURL, c.location); err != nil {
+			fmt.Printf("Error opening issue of regression %s: %v\n", regression, err)
+			// Retried with the next run
+			c.notify.unmarkReported(regression.key())
+		}
+	}
+}
+
+// watchRegressions checks every workload of the watched jobs at startup, so the ongoing regressions are notified
+// without waiting for their next run
+func (c *Config) watchRegressions() {
+	if c.notify.Alertmanager != nil {
+		c.notify.Alertmanager.resendAlerts()
+	}
+	if len(c.notify.Regressions) == 0 {
+		return
+	}
+	go func() {
+		jobs, err := loadJobs(c.resultsDir)
+		if err != nil {
+			fmt.Println("Error loading jobs:", err)
+			return
+		}
+		for _, job := range jobs {
+			for _, workload := range job.Workloads {
+				c.checkRegressions(job.Name, workload.Name)
+			}
+		}
+	}()
+}
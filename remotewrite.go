@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+	"gopkg.in/yaml.v3"
+)
+
+// remoteWriteMetric is the name of the series the quantile values are written as, in milliseconds like kube-burner
+// measures them
+const remoteWriteMetric = "kube_burner_quantile_milliseconds"
+
+var remoteWriteClient = &http.Client{Timeout: httpTimeout}
+
+// labelNamePattern matches the valid Prometheus label names
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// remoteWriteLabels are the labels identifying the series of the measurements, which the configured labels can't reuse
+var remoteWriteLabels = []string{"ci_job", "workload", "metric", "quantile", "phase", "percentile"}
+
+// RemoteWriteConfig writes the quantile values of the runs ingested into a Prometheus remote-write endpoint, such as
+// Prometheus itself, Thanos Receive or Mimir, for long-term analysis with PromQL
+type RemoteWriteConfig struct {
+	URL string `yaml:"url"`
+	// Token is sent as a bearer token when set, it expands environment variables
+	Token string `yaml:"token"`
+	// Headers are added to every request, e.g. THANOS-TENANT or X-Scope-OrgID to pick the tenant
+	Headers map[string]string `yaml:"headers"`
+	// Labels are added to every series, e.g. to tell the dashboard instances apart
+	Labels map[string]string `yaml:"labels"`
+	// MetadataLabels maps metadata fields of the runs to the labels they're written as, e.g. ocpVersion: ocp_version
+	MetadataLabels map[string]string `yaml:"metadataLabels"`
+
+	mu sync.Mutex
+	// written holds the keys of the measurements already written, by run path, phase, metric and quantile
+	written map[string]bool
+}
+
+func loadRemoteWriteConfig(configPath string) (*RemoteWriteConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var config RemoteWriteConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing remote write config %s: %v", configPath, err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid remote write config %s: %v", configPath, err)
+	}
+	return &config, nil
+}
+
+func (rw *RemoteWriteConfig) validate() error {
+	if rw.URL == "" {
+		return fmt.Errorf("url is required")
+	}
+	rw.Token = os.ExpandEnv(rw.Token)
+	for name, value := range rw.Headers {
+		rw.Headers[name] = os.ExpandEnv(value)
+	}
+	names := slices.Collect(maps.Keys(rw.Labels))
+	for _, name := range rw.MetadataLabels {
+		names = append(names, name)
+	}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		switch {
+		case !labelNamePattern.MatchString(name) || strings.HasPrefix(name, "__"):
+			return fmt.Errorf("invalid label name %q", name)
+		case slices.Contains(remoteWriteLabels, name):
+			return fmt.Errorf("label %s is reserved, the series are labeled with %s already", name, strings.Join(remoteWriteLabels, ", "))
+		case seen[name]:
+			return fmt.Errorf("label %s is set more than once by labels and metadataLabels", name)
+		}
+		seen[name] = true
+	}
+	rw.written = make(map[string]bool)
+	return nil
+}
+
+// promLabel is a label of a remote-write series
+type promLabel struct {
+	name, value string
+}
+
+// promSeries is a remote-write series with its single sample, at a Unix time in milliseconds
+type promSeries struct {
+	labels    []promLabel
+	value     float64
+	timestamp int64
+}
+
+// runSeries returns the series of the measurements of the run not written yet, along with their keys
+func (rw *RemoteWriteConfig) runSeries(jobName, workloadName string, run Run) ([]promSeries, []string) {
+	base := []promLabel{
+		{"__name__", remoteWriteMetric},
+		{"ci_job", jobName},
+		{"workload", workloadName},
+	}
+	for name, value := range rw.Labels {
+		base = append(base, promLabel{name, value})
+	}
+	metadata := run.metadataFields()
+	for field, name := range rw.MetadataLabels {
+		if value, ok := metadata[field]; ok {
+			base = append(base, promLabel{name, fmt.Sprint(value)})
+		}
+	}
+	var series []promSeries
+	var keys []string
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	for _, m := range run.Measurements {
+		key := strings.Join([]string{run.Path, m.JobName, m.MetricName, m.QuantileName}, "/")
+		if rw.written[key] || slices.Contains(keys, key) {
+			continue
+		}
+		keys = append(keys, key)
+		labels := append(slices.Clone(base),
+			promLabel{"metric", m.MetricName},
+			promLabel{"quantile", m.QuantileName},
+		)
+		if m.JobName != "" {
+			labels = append(labels, promLabel{"phase", m.JobName})
+		}
+		dp := DataPoint{P99: m.P99, P95: m.P95, P50: m.P50, Min: m.Min, Max: m.Max, Avg: m.Avg}
+		for _, percentile := range percentiles {
+			s := promSeries{
+				labels:    append(slices.Clone(labels), promLabel{"percentile", percentile}),
+				value:     dp.value(percentile),
+				timestamp: m.Timestamp.UnixMilli(),
+			}
+			// Receivers require the labels of a series sorted by name
+			slices.SortFunc(s.labels, func(a, b promLabel) int { return strings.Compare(a.name, b.name) })
+			series = append(series, s)
+		}
+	}
+	return series, keys
+}
+
+// writeRun writes the measurements of the run not written yet. Measurements landing later in the run are written by
+// the next calls, and the ones that failed to be written are retried
+func (rw *RemoteWriteConfig) writeRun(runPath, jobName, workloadName string) {
+	run, ok := loadEventRun(runPath)
+	if !ok {
+		return
+	}
+	series, keys := rw.runSeries(jobName, workloadName, run)
+	if len(series) == 0 {
+		return
+	}
+	rw.setWritten(keys, true)
+	if err := rw.send(series); err != nil {
+		fmt.Printf("Error writing run %s to %s: %v\n", run.Name(), rw.URL, err)
+		rw.setWritten(keys, false)
+		return
+	}
+	fmt.Printf("Wrote %d series of run %s/%s/%s to %s\n", len(series), jobName, workloadName, run.Name(), rw.URL)
+}
+
+func (rw *RemoteWriteConfig) setWritten(keys []string, written bool) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	for _, key := range keys {
+		if written {
+			rw.written[key] = true
+		} else {
+			delete(rw.written, key)
+		}
+	}
+}
+
+// send posts the series as a snappy compressed WriteRequest of the remote-write protocol
+func (rw *RemoteWriteConfig) send(series []promSeries) error {
+	req, err := http.NewRequest(http.MethodPost, rw.URL, bytes.NewReader(snappy.Encode(nil, encodeWriteRequest(series))))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	req.Header.Set("User-Agent", "ocp-perf-dash")
+	for name, value := range rw.Headers {
+		req.Header.Set(name, value)
+	}
+	if rw.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+rw.Token)
+	}
+	resp, err := remoteWriteClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// encodeWriteRequest encodes the series as a prometheus.WriteRequest protobuf message
+func encodeWriteRequest(series []promSeries) []byte {
+	var req []byte
+	for _, s := range series {
+		var ts []byte
+		for _, l := range s.labels {
+			var label []byte
+			label = protowire.AppendTag(label, 1, protowire.BytesType)
+			label = protowire.AppendString(label, l.name)
+			label = protowire.AppendTag(label, 2, protowire.BytesType)
+			label = protowire.AppendString(label, l.value)
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, label)
+		}
+		var sample []byte
+		sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+		sample = protowire.AppendFixed64(sample, math.Float64bits(s.value))
+		sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+		sample = protowire.AppendVarint(sample, uint64(s.timestamp))
+		ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+		ts = protowire.AppendBytes(ts, sample)
+		req = protowire.AppendTag(req, 1, protowire.BytesType)
+		req = protowire.AppendBytes(req, ts)
+	}
+	return req
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const defaultReportTop = 5
+
+// Report summarizes the comparison of the latest runs of the workloads of a job against their baseline
+type Report struct {
+	Job       string
+	Workloads []WorkloadReport
+	// Threshold is the percent change above which a delta is reported as a regression or an improvement
+	Threshold float64
+}
+
+type WorkloadReport struct {
+	Workload   string
+	Comparison Comparison
+	// Error is set when the workload couldn't be compared, e.g. when it lacks baseline runs
+	Error string
+}
+
+func newReport(resultsDir, jobName string, workloadNames []string, opts compareOptions, threshold float64) Report {
+	report := Report{
+		Job:       jobName,
+		Threshold: threshold,
+	}
+	for _, workloadName := range workloadNames {
+		wr := WorkloadReport{
+			Workload: workloadName,
+		}
+		workloadPath := filepath.Join(resultsDir, jobName, workloadName)
+		runs, err := loadRuns(workloadPath)
+		if err == nil {
+			var snapshot *BaselineSnapshot
+			if snapshot, err = loadBaseline(workloadPath); err == nil {
+				wr.Comparison, err = compareRuns(runs, snapshot, opts)
+			}
+		}
+		if err != nil {
+			wr.Error = err.Error()
+		}
+		report.Workloads = append(report.Workloads, wr)
+	}
+	return report
+}
+
+// changes returns the metrics that changed beyond the threshold in the given direction, largest change first.
+// When a significance test was run, only significant changes are returned
+func (wr WorkloadReport) changes(threshold float64, regressions bool) []MetricComparison {
+	var changed []MetricComparison
+	for _, mc := range wr.Comparison.Metrics {
+		if wr.Comparison.Test != "" && !mc.Significant {
+			continue
+		}
+		if (regressions && mc.Delta > threshold) || (!regressions && mc.Delta < -threshold) {
+			changed = append(changed, mc)
+		}
+	}
+	slices.SortFunc(changed, func(a, b MetricComparison) int {
+		return cmp.Compare(math.Abs(b.Delta), math.Abs(a.Delta))
+	})
+	return changed
+}
+
+// writeMarkdown prints the report as Markdown, fit for merge request comments. Links to the dashboard are only
+// added when its URL is given
+func (r Report) writeMarkdown(w io.Writer, top int, dashboardURL string) error {
+	fmt.Fprintf(w, "## Performance report: %s\n\n", r.Job)
+	fmt.Fprintln(w, "| Workload | Baseline | Current runs | Regressions | Improvements |")
+	fmt.Fprintln(w, "|----------|----------|-------------:|------------:|-------------:|")
+	for _, wr := range r.Workloads {
+		if wr.Error != "" {
+			fmt.Fprintf(w, "| %s | :warning: %s | | | |\n", wr.Workload, wr.Error)
+			continue
+		}
+		fmt.Fprintf(w, "| %s | %s (%d runs) | %d | %d | %d |\n", wr.Workload, wr.Comparison.Baseline, len(wr.Comparison.BaselineRuns),
+			len(wr.Comparison.CurrentRuns), len(wr.changes(r.Threshold, true)), len(wr.changes(r.Threshold, false)))
+	}
+	r.writeMarkdownWins(w)
+	r.writeMarkdownOwners(w)
+	for _, wr := range r.Workloads {
+		if wr.Error != "" {
+			continue
+		}
+		fmt.Fprintf(w, "\n### %s\n\n", wr.Workload)
+		regressions, improvements := wr.changes(r.Threshold, true), wr.changes(r.Threshold, false)
+		if len(regressions)+len(improvements) == 0 {
+			fmt.Fprintf(w, "No change beyond %g%%.\n", r.Threshold)
+		}
+		for _, section := range []struct {
+			title   string
+			metrics []MetricComparison
+		}{
+			{":red_circle: Top regressions", regressions},
+			{":green_circle: Top improvements", improvements},
+		} {
+			if len(section.metrics) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "**%s**\n\n", section.title)
+			writeMarkdownComparisons(w, wr.Comparison, section.metrics[:min(top, len(section.metrics))])
+			fmt.Fprintln(w)
+		}
+		if insufficient := wr.Comparison.Insufficient(); len(insufficient) > 0 {
+			fmt.Fprintf(w, ":warning: Fewer than %d runs in a group, deltas are not conclusive.\n\n", wr.Comparison.MinSamples)
+		}
+		fmt.Fprintf(w, "<details><summary>All metrics (%s)</summary>\n\n", wr.Comparison.Percentile)
+		writeMarkdownComparisons(w, wr.Comparison, wr.Comparison.Metrics)
+		fmt.Fprintln(w, "\n</details>")
+		if dashboardURL != "" {
+			link := fmt.Sprintf("%s/job/%s/%s/compare?%s", strings.TrimSuffix(dashboardURL, "/"), url.PathEscape(r.Job), url.PathEscape(wr.Workload),
+				url.Values{"baseline": {wr.Comparison.Baseline}, "current": {strings.Join(wr.Comparison.CurrentRuns, ",")}, "percentile": {wr.Comparison.Percentile}}.Encode())
+			fmt.Fprintf(w, "\n[View in the dashboard](%s)\n", link)
+		}
+	}
+	return nil
+}
+
+// writeMarkdownWins lists the significant improvements of every workload, when a significance test was run
+func (r Report) writeMarkdownWins(w io.Writer) {
+	var test string
+	var significance float64
+	type win struct {
+		workload string
+		MetricComparison
+	}
+	var wins []win
+	for _, wr := range r.Workloads {
+		for _, mc := range wr.Comparison.Improvements() {
+			wins = append(wins, win{wr.Workload, mc})
+		}
+		if wr.Comparison.Test != "" {
+			test, significance = wr.Comparison.Test, wr.Comparison.Significance
+		}
+	}
+	if len(wins) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n### :trophy: Wins\n\n%d significant improvements (%s, p < %g)\n\n", len(wins), test, significance)
+	fmt.Fprintln(w, "| Workload | Metric | Quantile | Baseline | Current | Delta | p-value |")
+	fmt.Fprintln(w, "|----------|--------|----------|---------:|--------:|------:|--------:|")
+	for _, win := range wins {
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %+.1f%% | %s |\n", win.workload, win.MetricName, win.QuantileName,
+			win.Baseline.format(), win.Current.format(), win.Delta, win.FormatPValue())
+	}
+}
+
+// writeMarkdownOwners groups the regressions of every workload by the owner of their metric, when owners are
+// configured, so every team finds its own
+func (r Report) writeMarkdownOwners(w io.Writer) {
+	if len(metricOwners) == 0 {
+		return
+	}
+	type regression struct {
+		workload string
+		MetricComparison
+	}
+	owned := make(map[string][]regression)
+	for _, wr := range r.Workloads {
+		for _, mc := range wr.changes(r.Threshold, true) {
+			owner := cmp.Or(mc.Owner, unownedMetrics)
+			owned[owner] = append(owned[owner], regression{wr.Workload, mc})
+		}
+	}
+	if len(owned) == 0 {
+		return
+	}
+	fmt.Fprint(w, "\n### Regressions by owner\n")
+	for _, owner := range metricOwners.ownerOrder(slices.Collect(maps.Keys(owned))) {
+		fmt.Fprintf(w, "\n**%s** (%d)\n\n", owner, len(owned[owner]))
+		fmt.Fprintln(w, "| Workload | Metric | Quantile | Baseline | Current | Delta |")
+		fmt.Fprintln(w, "|----------|--------|----------|---------:|--------:|------:|")
+		for _, reg := range owned[owner] {
+			fmt.Fprintf(w, "| %s | %s | %s | %s | %s | %+.1f%% |\n", reg.workload, reg.MetricName, reg.QuantileName,
+				reg.Baseline.format(), reg.Current.format(), reg.Delta)
+		}
+	}
+}
+
+func writeMarkdownComparisons(w io.Writer, c Comparison, metrics []MetricComparison) {
+	header, separator := "| Metric | Quantile | Baseline | Current | Delta |", "|--------|----------|---------:|--------:|------:|"
+	if c.Test != "" {
+		header, separator = header+" p-value |", separator+"--------:|"
+	}
+	fmt.Fprintln(w, header)
+	fmt.Fprintln(w, separator)
+	for _, mc := range metrics {
+		fmt.Fprintf(w, "| %s | %s | %s | %s | %+.1f%% |", mc.MetricName, mc.QuantileName, mc.Baseline.format(), mc.Current.format(), mc.Delta)
+		if c.Test != "" {
+			fmt.Fprintf(w, " %s |", mc.FormatPValue())
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// reportCommand implements the report subcommand, summarizing the latest runs of a job for merge requests
+func reportCommand(args []string) int {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path or URL of the directory holding results")
+	jobName := flags.String("job", "", "Job to report on")
+	workloads := flags.String("workloads", "", "Comma separated list of workloads to report on, all the workloads of the job when empty")
+	baseline := flags.String("baseline", "", "Baseline runs, last:N, a comma separated list of run directories or recorded for the baseline snapshot. Defaults to the snapshot when recorded, last:5 otherwise")
+	current := flags.String("current", defaultCurrentSelector, "Runs to report on, last:N or a comma separated list of run directories")
+	percentile := flags.String("percentile", "P99", "Percentile to compare")
+	test := flags.String("test", "", "Significance test to run, welch or mann-whitney. When set, only significant changes are reported")
+	minSamples := flags.Int("min-samples", defaultMinSamples, "Minimum number of runs per group for a comparison to be conclusive")
+	threshold := flags.Float64("threshold", 5, "Percent change reported as a regression or an improvement")
+	top := flags.Int("top", defaultReportTop, "Number of regressions and improvements listed per workload")
+	dashboardURL := flags.String("dashboard-url", "", "Base URL of the dashboard, used to link every workload to its comparison page")
+	format := flags.String("format", "markdown", "Output format, markdown or json")
+	ownersFile := flags.String("metric-owners", "", "Path to a YAML file mapping metric patterns to their owners, grouping the regressions by owner")
+	flags.Parse(args)
+	if *jobName == "" {
+		fmt.Fprintln(os.Stderr, "-job is required")
+		return 2
+	}
+	opts := compareOptions{
+		Baseline:     *baseline,
+		Current:      *current,
+		Confidence:   defaultConfidence,
+		MinSamples:   *minSamples,
+		Significance: defaultSignificance,
+	}
+	var err error
+	if opts.Percentile, err = parsePercentile(*percentile); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if opts.Test, err = parseTest(*test); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if *ownersFile != "" {
+		if err := loadMetricOwners(*ownersFile); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	loadLog = os.Stderr
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	workloadNames := splitList(*workloads)
+	if len(workloadNames) == 0 {
+		all, err := loadWorkloads(filepath.Join(root, *jobName), *jobName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "Error loading workloads:", err)
+			return 1
+		}
+		for _, workload := range all {
+			workloadNames = append(workloadNames, workload.Name)
+		}
+	}
+	report := newReport(root, *jobName, workloadNames, opts, *threshold)
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	case "markdown":
+		err = report.writeMarkdown(os.Stdout, *top, *dashboardURL)
+	default:
+		fmt.Fprintf(os.Stderr, "Invalid format %s: must be markdown or json\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/robfig/cron/v3"
+)
+
+// reportLatest is the name the latest report of a job is also published as, next to the dated ones
+const reportLatest = "latest.md"
+
+// reportTarget is where the scheduled reports are published
+type reportTarget interface {
+	// publish stores the report of the job under the name, ignored by targets not storing files such as emails
+	publish(jobName, name string, report []byte) error
+}
+
+// reportSchedule renders the Markdown report of the matching jobs on a cron schedule, and publishes them
+type reportSchedule struct {
+	schedule cron.Schedule
+	// jobs are the patterns of the jobs reported on, every active job when empty
+	jobs   []string
+	target reportTarget
+}
+
+// newReportSchedule parses the cron spec, such as "0 7 * * 1", and the target the reports are published to: a
+// directory, s3://bucket/prefix or mailto:recipient,... Emails are sent through the SMTP server of the notifications
+func newReportSchedule(spec string, jobs []string, target string, notify *NotifyConfig) (*reportSchedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report schedule %q: %v", spec, err)
+	}
+	if err := validJobPatterns(jobs); err != nil {
+		return nil, err
+	}
+	rs := &reportSchedule{schedule: schedule, jobs: jobs}
+	u, err := url.Parse(target)
+	switch {
+	case target == "":
+		return nil, fmt.Errorf("-report-target is required to schedule reports")
+	case err == nil && u.Scheme == "s3":
+		if rs.target, err = newS3ReportTarget(u.Host, strings.Trim(u.Path, "/")); err != nil {
+			return nil, err
+		}
+	case err == nil && u.Scheme == "mailto":
+		if notify == nil || notify.SMTP.Host == "" || notify.SMTP.From == "" {
+			return nil, fmt.Errorf("emailing reports requires the smtp server of -notify-config")
+		}
+		rs.target = emailReportTarget{emailNotifier{smtp: notify.SMTP, recipients: splitList(u.Opaque)}}
+	default:
+		if err := os.MkdirAll(target, 0o755); err != nil {
+			return nil, fmt.Errorf("error creating report directory: %v", err)
+		}
+		rs.target = dirReportTarget{dir: target}
+	}
+	return rs, nil
+}
+
+// scheduleReports publishes the reports every time the schedule fires, in the -timezone time zone
+func (c *Config) scheduleReports() {
+	loc := cmp.Or(c.location, time.Local)
+	go func() {
+		for {
+			next := c.reports.schedule.Next(time.Now().In(loc))
+			time.Sleep(time.Until(next))
+			c.publishReports(next)
+		}
+	}()
+}
+
+// publishReports renders the report of every matching job and publishes it, named after the date
+func (c *Config) publishReports(at time.Time) {
+	jobs, err := loadJobs(c.resultsDir)
+	if err != nil {
+		fmt.Println("Error loading jobs for the scheduled reports:", err)
+		return
+	}
+	opts := compareOptions{
+		Current:      defaultCurrentSelector,
+		Percentile:   "P99",
+		Confidence:   defaultConfidence,
+		MinSamples:   c.minSamples,
+		Significance: defaultSignificance,
+	}
+	var dashboardURL string
+	if c.notify != nil {
+		dashboardURL = c.notify.DashboardURL
+	}
+	for _, job := range activeJobs(jobs) {
+		if !matchJob(c.reports.jobs, job.Name) {
+			continue
+		}
+		var workloadNames []string
+		for _, workload := range job.Workloads {
+			workloadNames = append(workloadNames, workload.Name)
+		}
+		var report bytes.Buffer
+		if err := newReport(c.resultsDir, job.Name, workloadNames, opts, defaultDigestThreshold).writeMarkdown(&report, defaultReportTop, dashboardURL); err != nil {
+			fmt.Printf("Error rendering the report of %s: %v\n", job.Name, err)
+			continue
+		}
+		if err := c.reports.target.publish(job.Name, at.Format(time.DateOnly)+".md", report.Bytes()); err != nil {
+			fmt.Printf("Error publishing the report of %s: %v\n", job.Name, err)
+			continue
+		}
+		fmt.Printf("Published the report of %s\n", job.Name)
+	}
+}
+
+// dirReportTarget writes the reports to a directory, in a subdirectory per job
+type dirReportTarget struct {
+	dir string
+}
+
+func (t dirReportTarget) publish(jobName, name string, report []byte) error {
+	dir := filepath.Join(t.dir, jobName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, file := range []string{name, reportLatest} {
+		if err := os.WriteFile(filepath.Join(dir, file), report, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// s3ReportTarget uploads the reports to an S3 bucket, under a prefix per job. It authenticates with the default
+// credentials chain of the AWS SDK, and AWS_ENDPOINT_URL_S3 points it at S3 compatible stores
+type s3ReportTarget struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3ReportTarget(bucket, prefix string) (*s3ReportTarget, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("error loading AWS configuration: %v", err)
+	}
+	return &s3ReportTarget{client: s3.NewFromConfig(cfg), bucket: bucket, prefix: prefix}, nil
+}
+
+func (t *s3ReportTarget) publish(jobName, name string, report []byte) error {
+	for _, file := range []string{name, reportLatest} {
+		_, err := t.client.PutObject(context.Background(), &s3.PutObjectInput{
+			Bucket:      aws.String(t.bucket),
+			Key:         aws.String(path.Join(t.prefix, jobName, file)),
+			Body:        bytes.NewReader(report),
+			ContentType: aws.String("text/markdown; charset=utf-8"),
+		})
+		if err != nil {
+			return fmt.Errorf("error uploading to s3://%s: %v", t.bucket, err)
+		}
+	}
+	return nil
+}
+
+// emailReportTarget emails the reports, one per job
+type emailReportTarget struct {
+	email emailNotifier
+}
+
+func (t emailReportTarget) publish(jobName, _ string, report []byte) error {
+	return t.email.notify(Notification{Title: "Performance report: " + jobName, Text: string(report)})
+}
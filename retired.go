@@ -0,0 +1,53 @@
+package main
+
+import (
+	"io/fs"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// retiredMarker marks the job or workload directory holding it as retired. Retired jobs and workloads are hidden from
+// the listings unless requested with retired=true, their pages and data are still reachable by URL
+const retiredMarker = ".retired"
+
+// isRetired returns true when the directory holds the retired marker
+func isRetired(dir string) bool {
+	entries, err := storage.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(entries, func(entry fs.DirEntry) bool {
+		return !entry.IsDir() && entry.Name() == retiredMarker
+	})
+}
+
+// showRetired returns true when the request asks to list the retired jobs and workloads
+func showRetired(r *http.Request) bool {
+	show, _ := strconv.ParseBool(r.URL.Query().Get("retired"))
+	return show
+}
+
+// activeJobs drops the retired jobs, and the retired workloads of the remaining ones
+func activeJobs(jobs []Job) []Job {
+	var active []Job
+	for _, job := range jobs {
+		if job.Retired {
+			continue
+		}
+		job.Workloads = activeWorkloads(job.Workloads, "")
+		active = append(active, job)
+	}
+	return active
+}
+
+// activeWorkloads drops the retired workloads, but the current one so it can still be selected from its own page
+func activeWorkloads(workloads []Workload, current string) []Workload {
+	var active []Workload
+	for _, workload := range workloads {
+		if !workload.Retired || workload.Name == current {
+			active = append(active, workload)
+		}
+	}
+	return active
+}
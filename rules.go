@@ -0,0 +1,177 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"cel.dev/cel-go/cel"
+	"cel.dev/cel-go/common/types"
+	"cel.dev/cel-go/common/types/ref"
+)
+
+// durationLiteral matches the durations written as literals in the rule expressions, such as 15s or 1m30s, which
+// CEL lacks
+var durationLiteral = regexp.MustCompile(`(^|[^\w.])((?:\d+(?:\.\d+)?(?:ms|s|m|h))+)\b`)
+
+// AlertRule is a CEL expression evaluated over every run added to the workloads of the matching jobs, notifying the
+// runs it's true for, such as P99("podLatencyQuantilesMeasurement", "Ready") > 15s && metadata.platform == "AWS"
+type AlertRule struct {
+	Name       string `yaml:"name"`
+	Expression string `yaml:"expression"`
+	// Jobs are the patterns of the jobs the rule applies to, every job when empty
+	Jobs []string `yaml:"jobs"`
+	// Description is the text of the notifications, the expression when empty
+	Description string `yaml:"description"`
+
+	ast *cel.Ast
+}
+
+// ruleEnv declares the variables and functions of the rule expressions. The percentile functions look the
+// measurements of the run up with quantile, which returns false when the run lacks them
+func ruleEnv(quantile func(metric, quantileName, percentile string) (float64, bool)) (*cel.Env, error) {
+	opts := []cel.EnvOption{
+		cel.Variable("job", cel.StringType),
+		cel.Variable("workload", cel.StringType),
+		cel.Variable("run", cel.StringType),
+		cel.Variable("uuid", cel.StringType),
+		cel.Variable("passed", cel.BoolType),
+		cel.Variable("metadata", cel.MapType(cel.StringType, cel.DynType)),
+	}
+	for _, percentile := range percentiles {
+		opts = append(opts, cel.Function(percentile, cel.Overload(percentile+"_string_string", []*cel.Type{cel.StringType, cel.StringType}, cel.DurationType,
+			cel.BinaryBinding(func(metric, quantileName ref.Val) ref.Val {
+				m, q := fmt.Sprint(metric.Value()), fmt.Sprint(quantileName.Value())
+				value, ok := quantile(m, q, percentile)
+				if !ok {
+					return types.NewErr("run has no %s %s measurement", m, q)
+				}
+				return types.Duration{Duration: time.Duration(math.Round(value * float64(time.Millisecond)))}
+			}))))
+	}
+	return cel.NewEnv(opts...)
+}
+
+// expandDurations rewrites the duration literals outside of the string literals of the expression as CEL durations
+func expandDurations(expression string) string {
+	var b strings.Builder
+	var quote byte
+	start := 0
+	for i := 0; i < len(expression); i++ {
+		switch c := expression[i]; {
+		case quote == 0 && (c == '"' || c == '\''):
+			b.WriteString(durationLiteral.ReplaceAllString(expression[start:i], `${1}duration("${2}")`))
+			quote, start = c, i
+		case quote != 0 && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			b.WriteString(expression[start : i+1])
+			quote, start = 0, i+1
+		}
+	}
+	if quote != 0 {
+		b.WriteString(expression[start:])
+	} else {
+		b.WriteString(durationLiteral.ReplaceAllString(expression[start:], `${1}duration("${2}")`))
+	}
+	return b.String()
+}
+
+// validate compiles the expression, which must be a boolean
+func (r AlertRule) validate() (AlertRule, error) {
+	if r.Name == "" || r.Expression == "" {
+		return r, fmt.Errorf("name and expression are required")
+	}
+	if err := validJobPatterns(r.Jobs); err != nil {
+		return r, err
+	}
+	env, err := ruleEnv(func(string, string, string) (float64, bool) { return 0, false })
+	if err != nil {
+		return r, err
+	}
+	ast, issues := env.Compile(expandDurations(r.Expression))
+	if issues.Err() != nil {
+		return r, issues.Err()
+	}
+	if ast.OutputType() != cel.BoolType {
+		return r, fmt.Errorf("expression must be a boolean, not %s", ast.OutputType())
+	}
+	r.ast = ast
+	return r, nil
+}
+
+// eval evaluates the rule over the run, the percentile functions returning the value of the first kube-burner job
+// holding the measurement
+func (r AlertRule) eval(job, workload string, run Run) (bool, error) {
+	env, err := ruleEnv(func(metric, quantileName, percentile string) (float64, bool) {
+		for _, m := range run.Measurements {
+			if m.MetricName == metric && m.QuantileName == quantileName {
+				return DataPoint{P99: m.P99, P95: m.P95, P50: m.P50, Min: m.Min, Max: m.Max, Avg: m.Avg}.value(percentile), true
+			}
+		}
+		return 0, false
+	})
+	if err != nil {
+		return false, err
+	}
+	program, err := env.Program(r.ast)
+	if err != nil {
+		return false, err
+	}
+	out, _, err := program.Eval(map[string]any{
+		"job":      job,
+		"workload": workload,
+		"run":      run.Name(),
+		"uuid":     run.UUID(),
+		"passed":   run.Summary.Passed,
+		"metadata": run.metadataFields(),
+	})
+	if err != nil {
+		return false, err
+	}
+	fired, ok := out.Value().(bool)
+	return fired && ok, nil
+}
+
+// checkRules evaluates the rules matching the job of the run added, notifying the ones it fires, once
+func (c *Config) checkRules(event Event) {
+	if len(c.notify.Rules) == 0 || !c.notify.routed(notifyRule) {
+		return
+	}
+	runPath := filepath.Join(c.resultsDir, event.Job, event.Workload, event.Run)
+	run, ok := loadEventRun(runPath)
+	if !ok {
+		return
+	}
+	for _, rule := range c.notify.Rules {
+		if !matchJob(rule.Jobs, event.Job) {
+			continue
+		}
+		fired, err := rule.eval(event.Job, event.Workload, run)
+		if err != nil {
+			fmt.Printf("Error evaluating rule %s over run %s: %v\n", rule.Name, runPath, err)
+			continue
+		}
+		if !fired || !c.notify.markReported(notifyRule+"/"+rule.Name+"/"+runPath) {
+			continue
+		}
+		note := Notification{
+			Type:     notifyRule,
+			Job:      event.Job,
+			Workload: event.Workload,
+			Run:      run.Name(),
+			Key:      strings.Join([]string{rule.Name, event.Job, event.Workload, run.Name()}, "/"),
+			Title:    fmt.Sprintf("Rule %s fired for run %s of %s/%s", rule.Name, run.Name(), event.Job, event.Workload),
+			Text:     cmp.Or(rule.Description, rule.Expression),
+		}
+		if c.notify.DashboardURL != "" {
+			note.URL = fmt.Sprintf("%s/job/%s/%s", strings.TrimSuffix(c.notify.DashboardURL, "/"), url.PathEscape(event.Job), url.PathEscape(event.Workload))
+		}
+		c.notify.send(note)
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Scales runs are placed at by default, the job iterations recorded in the job summary
+	scaleIterations = "iterations"
+	scaleNodes      = "nodes"
+	// Metadata key runs are split in series by default
+	defaultScalabilityGroup = "ocpVersion"
+)
+
+// Metadata keys the node count is read from, as set by the different CI setups
+var nodeMetadataKeys = []string{"nodes", "workerNodesCount", "workerNodes", "nodeCount"}
+
+// ScalabilityCurve holds a metric quantile against the scale runs were executed at, with a series per version
+type ScalabilityCurve struct {
+	JobName      string
+	MetricName   string
+	QuantileName string
+	Percentile   string
+	// Scale is what runs are placed at on the x axis: iterations, nodes or a numeric metadata key
+	Scale string
+	// GroupBy is the metadata key runs are split in series by
+	GroupBy string
+	Series  []ScalabilitySeries
+	// Runs holding the quantile but lacking the scale, left out of the curve
+	Skipped int
+}
+
+type ScalabilitySeries struct {
+	Version string
+	Points  []ScalabilityPoint
+}
+
+type ScalabilityPoint struct {
+	Scale float64
+	// Value is the median of the runs executed at the scale
+	Value float64
+	Runs  int
+}
+
+// runScale returns the scale the run was executed at
+func runScale(run Run, scale string) (float64, bool) {
+	if scale == scaleIterations && run.Summary.JobConfig.JobIterations > 0 {
+		return float64(run.Summary.JobConfig.JobIterations), true
+	}
+	keys := []string{scale}
+	switch scale {
+	case scaleIterations:
+		keys = []string{"jobIterations", "iterations"}
+	case scaleNodes:
+		keys = nodeMetadataKeys
+	}
+	metadata := run.Metadata()
+	for _, key := range keys {
+		switch v := metadata[key].(type) {
+		case float64:
+			return v, true
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// compareVersions orders versions such as 4.9 and 4.10 numerically, comparing every dot-separated field as a
+// number when both are
+func compareVersions(a, b string) int {
+	fa, fb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := range min(len(fa), len(fb)) {
+		na, errA := strconv.Atoi(fa[i])
+		nb, errB := strconv.Atoi(fb[i])
+		if errA == nil && errB == nil {
+			if c := cmp.Compare(na, nb); c != 0 {
+				return c
+			}
+			continue
+		}
+		if c := strings.Compare(fa[i], fb[i]); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(fa), len(fb))
+}
+
+// scalabilityCurve places the runs at their scale, and aggregates the runs of every version executed at the same
+// scale with their median
+func scalabilityCurve(runs []Run, curve ScalabilityCurve) ScalabilityCurve {
+	values := make(map[string]map[float64][]float64)
+	for _, run := range runs {
+		v, ok := measurementValue(run, curve.MetricName, curve.QuantileName, curve.Percentile)
+		if !ok {
+			continue
+		}
+		scale, ok := runScale(run, curve.Scale)
+		if !ok {
+			curve.Skipped++
+			continue
+		}
+		version := "unknown"
+		if md, ok := run.Metadata()[curve.GroupBy]; ok {
+			version = fmt.Sprint(md)
+		}
+		if values[version] == nil {
+			values[version] = make(map[float64][]float64)
+		}
+		values[version][scale] = append(values[version][scale], v)
+	}
+	for _, version := range slices.SortedFunc(maps.Keys(values), compareVersions) {
+		series := ScalabilitySeries{Version: version}
+		for _, scale := range slices.Sorted(maps.Keys(values[version])) {
+			series.Points = append(series.Points, ScalabilityPoint{
+				Scale: scale,
+				Value: quantile(sortedCopy(values[version][scale]), 0.5),
+				Runs:  len(values[version][scale]),
+			})
+		}
+		curve.Series = append(curve.Series, series)
+	}
+	return curve
+}
+
+// loadScalability loads the runs of every workload of the job, or of the workloads requested, and builds the
+// scalability curve of the requested metric quantile. The metric groups of the runs are returned for the selectors
+func (c *Config) loadScalability(r *http.Request, jobName string) (ScalabilityCurve, []MetricGroup, int, error) {
+	query := r.URL.Query()
+	curve := ScalabilityCurve{
+		JobName:      jobName,
+		MetricName:   cmp.Or(query.Get("metric"), defaultOverlayMetric),
+		QuantileName: cmp.Or(query.Get("quantile"), defaultTrendQuantile),
+		Percentile:   "P99",
+		Scale:        cmp.Or(query.Get("scale"), scaleIterations),
+		GroupBy:      cmp.Or(query.Get("group"), defaultScalabilityGroup),
+	}
+	if p := query.Get("percentile"); p != "" {
+		percentile, err := parsePercentile(p)
+		if err != nil {
+			return curve, nil, http.StatusBadRequest, err
+		}
+		curve.Percentile = percentile
+	}
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		return curve, nil, http.StatusBadRequest, err
+	}
+	workloads, err := loadWorkloads(filepath.Join(c.resultsDir, jobName), jobName)
+	if err != nil {
+		return curve, nil, http.StatusNotFound, fmt.Errorf("job %s not found", jobName)
+	}
+	selected := splitList(query.Get("workloads"))
+	job := Job{Name: jobName}
+	for _, workload := range workloads {
+		if len(selected) > 0 && !slices.Contains(selected, workload.Name) {
+			continue
+		}
+		runs, err := loadRuns(workload.Path)
+		if err != nil {
+			return curve, nil, http.StatusInternalServerError, fmt.Errorf("error loading workload %s/%s: %v", jobName, workload.Name, err)
+		}
+		job.Runs = append(job.Runs, opts.filterRuns(runs)...)
+	}
+	metricGroups := prepareChartData(&job, ChartOptions{})
+	curve = scalabilityCurve(job.Runs, curve)
+	if len(curve.Series) == 0 {
+		return curve, metricGroups, http.StatusNotFound, fmt.Errorf("no run of job %s holds %s %s along with its %s", jobName, curve.MetricName, curve.QuantileName, curve.Scale)
+	}
+	return curve, metricGroups, http.StatusOK, nil
+}
+
+func (c *Config) apiScalabilityHandler(w http.ResponseWriter, r *http.Request) {
+	jobName := r.PathValue("job")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	curve, _, status, err := c.loadScalability(r, jobName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, curve)
+}
+
+func (c *Config) scalabilityHandler(w http.ResponseWriter, r *http.Request) {
+	jobName := r.PathValue("job")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	curve, metricGroups, status, err := c.loadScalability(r, jobName)
+	if err != nil && status != http.StatusNotFound {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	type TemplateData struct {
+		JobName      string
+		Curve        ScalabilityCurve
+		CurveJSON    template.JS
+		MetricGroups []MetricGroup
+		Percentiles  []string
+		Error        string
+	}
+	curveJSON, _ := json.Marshal(curve)
+	data := TemplateData{
+		JobName:      jobName,
+		Curve:        curve,
+		CurveJSON:    template.JS(curveJSON),
+		MetricGroups: metricGroups,
+		Percentiles:  percentiles,
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "scalability.html", data)
+}
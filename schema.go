@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// Kinds of the documents validated against a schema, and of the diagnostics their violations raise
+const (
+	metadataDocument    = "metadata"
+	measurementDocument = "measurement"
+)
+
+// schemas validates the documents of the runs as they're loaded, documents violating them are rejected so malformed
+// uploads don't make it to the charts. Kinds without a schema aren't validated
+var schemas = map[string]*jsonschema.Schema{}
+
+// loadSchema compiles the JSON Schema of a document kind
+func loadSchema(kind, schemaPath string) error {
+	schema, err := jsonschema.NewCompiler().Compile(schemaPath)
+	if err != nil {
+		return fmt.Errorf("error compiling %s schema %s: %v", kind, schemaPath, err)
+	}
+	schemas[kind] = schema
+	return nil
+}
+
+// validateDocument validates the document against the schema of its kind, reporting the violations to the
+// diagnostics page
+func validateDocument(kind, path string, data []byte) error {
+	schema := schemas[kind]
+	if schema == nil {
+		return nil
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		diagnostics.reportFile(path, parseError, err)
+		return fmt.Errorf("error parsing %s: %v", path, err)
+	}
+	if err := schema.Validate(doc); err != nil {
+		diagnostics.reportFile(path, kind+" schema", err)
+		return fmt.Errorf("%s violates the %s schema: %v", path, kind, err)
+	}
+	return nil
+}
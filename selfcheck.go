@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Number of errors printed by the self-check, the rest are left for the diagnostics page
+const maxSelfCheckErrors = 20
+
+// selfCheck loads every run of the results directory at startup, warming the cache, and prints what it found. It
+// fails when the results directory is missing or can't be read, rather than serving empty pages
+func selfCheck(resultsDir string) error {
+	jobs, err := loadJobs(resultsDir)
+	if err != nil {
+		return fmt.Errorf("error reading results directory %s: %v", resultsDir, err)
+	}
+	var workloads, runs int
+	for _, job := range jobs {
+		for _, workload := range job.Workloads {
+			workloads++
+			workloadRuns, err := loadRuns(workload.Path)
+			if err != nil {
+				diagnostics.report(workload.Path, "", readError, err)
+				continue
+			}
+			runs += len(workloadRuns)
+		}
+	}
+	problems := diagnostics.list()
+	fmt.Printf("Self-check of %s: %d jobs, %d workloads, %d runs and %d errors\n", resultsDir, len(jobs), workloads, runs, len(problems))
+	for i, diagnostic := range problems {
+		if i == maxSelfCheckErrors {
+			fmt.Printf("  ... and %d more, see /diagnostics\n", len(problems)-i)
+			break
+		}
+		fmt.Printf("  %s: %s: %s\n", filepath.Join(diagnostic.Dir, diagnostic.File), diagnostic.Kind, diagnostic.Error)
+	}
+	return nil
+}
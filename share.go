@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Expiries of the share links, the default one is used when the form doesn't choose any
+const (
+	defaultShareExpiry = 7 * 24 * time.Hour
+	maxShareExpiry     = 30 * 24 * time.Hour
+)
+
+// ShareExpiry is an expiry offered by the share page
+type ShareExpiry struct {
+	Expiry time.Duration
+	Label  string
+}
+
+var shareExpiries = []ShareExpiry{
+	{Expiry: 24 * time.Hour, Label: "1 day"},
+	{Expiry: defaultShareExpiry, Label: "7 days"},
+	{Expiry: maxShareExpiry, Label: "30 days"},
+}
+
+// ShareClaims are the page a share link grants access to, and until when
+type ShareClaims struct {
+	Path    string `json:"p"`
+	Expires int64  `json:"e"`
+}
+
+// sharedContextKey flags the requests served through a share link
+type sharedContextKey struct{}
+
+// sharedRequest reports whether the request is served through a valid share link, which grants access to its page
+// regardless of the policy
+func sharedRequest(r *http.Request) bool {
+	shared, _ := r.Context().Value(sharedContextKey{}).(bool)
+	return shared
+}
+
+// randomShareKey generates the key signing the share links when none is configured, the links don't survive restarts
+func randomShareKey() []byte {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return key
+}
+
+// loadShareKey reads the key signing the share links, a random one is generated when no file is given
+func loadShareKey(path string) ([]byte, error) {
+	if path == "" {
+		return randomShareKey(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key := []byte(strings.TrimSpace(string(data)))
+	if len(key) < 16 {
+		return nil, fmt.Errorf("share key in %s is too short, it must be at least 16 bytes long", path)
+	}
+	return key, nil
+}
+
+// signShare returns the token of a share link, the claims encoded and signed with the share key
+func (c *Config) signShare(claims ShareClaims) string {
+	payload, _ := json.Marshal(claims)
+	mac := hmac.New(sha256.New, c.shareKey)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifyShare returns the claims of the token, failing when its signature is invalid or it expired
+func (c *Config) verifyShare(token string) (ShareClaims, error) {
+	var claims ShareClaims
+	encodedPayload, encodedSignature, _ := strings.Cut(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return claims, fmt.Errorf("invalid share link")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil {
+		return claims, fmt.Errorf("invalid share link")
+	}
+	mac := hmac.New(sha256.New, c.shareKey)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return claims, fmt.Errorf("invalid share link")
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, fmt.Errorf("invalid share link")
+	}
+	if time.Now().Unix() > claims.Expires {
+		return claims, fmt.Errorf("share link expired on %s", time.Unix(claims.Expires, 0).UTC().Format(time.RFC3339))
+	}
+	return claims, nil
+}
+
+// sharedJobs returns the jobs whose data the page shows, the only pages that can be shared: the job of workload
+// pages, which cover a single run when given its UUID, and of run comparisons, their permalinks included, and the
+// jobs of every chart of custom views. It fails for any other page
+func (c *Config) sharedJobs(r *http.Request, u *url.URL) ([]string, error) {
+	if runs, ok := strings.CutPrefix(u.Path, "/compare/"); ok {
+		jobs, err := c.visibleJobs(r)
+		if err != nil {
+			return nil, err
+		}
+		baseline, _, _, err := findComparedRuns(jobs, runs)
+		if err != nil {
+			return nil, err
+		}
+		return []string{baseline.Job}, nil
+	}
+	if name, ok := strings.CutPrefix(u.Path, "/views/"); ok && !strings.Contains(name, "/") {
+		view, ok := c.views.get(name)
+		if !ok {
+			return nil, fmt.Errorf("view %s not found", name)
+		}
+		var jobs []string
+		for _, chart := range view.Charts {
+			jobs = append(jobs, chart.Job)
+		}
+		return jobs, nil
+	}
+	if path, ok := strings.CutPrefix(u.Path, "/job/"); ok {
+		parts := strings.Split(path, "/")
+		if len(parts) == 2 && parts[0] != "" && parts[1] != "" || len(parts) == 3 && parts[2] == "compare" {
+			return parts[:1], nil
+		}
+	}
+	return nil, fmt.Errorf("%s can't be shared, only workload, comparison and custom view pages can", u.Path)
+}
+
+// shareURL returns the absolute URL of the share link of the token
+func shareURL(r *http.Request, token string) string {
+	return baseURL(r) + "/share/" + token
+}
+
+// baseURL returns the scheme and host the client reached the dashboard at
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
+// createShareHandler signs a share link to the page given in the form, provided the client can access its data
+func (c *Config) createShareHandler(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	target, err := url.Parse(r.FormValue("path"))
+	if err != nil || target.IsAbs() || target.Host != "" {
+		http.Error(w, "path must be the path of a dashboard page", http.StatusBadRequest)
+		return
+	}
+	expiry := defaultShareExpiry
+	if e := r.FormValue("expires"); e != "" {
+		if expiry, err = time.ParseDuration(e); err != nil || expiry <= 0 || expiry > maxShareExpiry {
+			http.Error(w, fmt.Sprintf("invalid expires: must be a duration up to %s", maxShareExpiry), http.StatusBadRequest)
+			return
+		}
+	}
+	jobs, err := c.sharedJobs(r, target)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, job := range jobs {
+		if !c.jobAllowed(r, job) {
+			jobForbidden(w, job)
+			return
+		}
+	}
+	claims := ShareClaims{
+		Path:    target.RequestURI(),
+		Expires: time.Now().Add(expiry).Unix(),
+	}
+	type TemplateData struct {
+		Path     string
+		URL      string
+		Expires  time.Time
+		Expiry   time.Duration
+		Expiries []ShareExpiry
+	}
+	c.renderTemplate(w, r, "share.html", TemplateData{
+		Path:     claims.Path,
+		URL:      shareURL(r, c.signShare(claims)),
+		Expires:  time.Unix(claims.Expires, 0),
+		Expiry:   expiry,
+		Expiries: shareExpiries,
+	})
+}
+
+// shareHandler serves the page of a share link, in read-only fashion and regardless of the identity of the client
+func (c *Config) shareHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := c.verifyShare(r.PathValue("token"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	target, err := url.Parse(claims.Path)
+	if err != nil {
+		http.Error(w, "invalid share link", http.StatusForbidden)
+		return
+	}
+	shared := r.Clone(context.WithValue(r.Context(), sharedContextKey{}, true))
+	shared.URL = target
+	shared.RequestURI = claims.Path
+	http.DefaultServeMux.ServeHTTP(w, shared)
+}
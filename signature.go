@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// Detached signatures of the checksums of a run, made by cosign sign-blob or gpg --detach-sign --armor
+const (
+	cosignSignatureFile = checksumsFile + ".sig"
+	gpgSignatureFile    = checksumsFile + ".asc"
+)
+
+// signatureError is the kind of the diagnostics of the runs failing signature verification
+const signatureError = "signature"
+
+// RunSignature is the outcome of the verification of the signature of a run
+type RunSignature struct {
+	Verified bool
+	// Signer is the identity of the GPG key or the fingerprint of the cosign key that signed the run
+	Signer string `json:",omitempty"`
+	Error  string `json:",omitempty"`
+}
+
+// signingKeys are the keys trusted to sign runs, runs aren't verified when there are none
+var signingKeys struct {
+	cosign []crypto.PublicKey
+	gpg    openpgp.EntityList
+}
+
+// loadCosignKeys reads the PEM public keys of cosign, such as cosign.pub generated by cosign generate-key-pair
+func loadCosignKeys(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return fmt.Errorf("error parsing cosign key %s: %v", path, err)
+		}
+		signingKeys.cosign = append(signingKeys.cosign, key)
+	}
+	if len(signingKeys.cosign) == 0 {
+		return fmt.Errorf("no public key found in %s", path)
+	}
+	return nil
+}
+
+// loadGPGKeyring reads the armored GPG public keys, as exported by gpg --export --armor
+func loadGPGKeyring(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	keyring, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return fmt.Errorf("error reading GPG keyring %s: %v", path, err)
+	}
+	signingKeys.gpg = keyring
+	return nil
+}
+
+// verifyRunSignature verifies the signature of the run checksums with the trusted keys, and the checksums of the
+// files of the run, every file having to be listed. It's nil when no keys are trusted or the run isn't signed
+func verifyRunSignature(runPath string, files []fs.DirEntry) *RunSignature {
+	if len(signingKeys.cosign) == 0 && len(signingKeys.gpg) == 0 {
+		return nil
+	}
+	signed := func(name string) bool {
+		return slices.ContainsFunc(files, func(f fs.DirEntry) bool { return f.Name() == name })
+	}
+	signatureFile := cosignSignatureFile
+	if !signed(signatureFile) {
+		signatureFile = gpgSignatureFile
+		if !signed(signatureFile) {
+			return nil
+		}
+	}
+	signer, err := verifySignedChecksums(runPath, signatureFile, runFileNames(files))
+	if err != nil {
+		diagnostics.report(runPath, checksumsFile, signatureError, err)
+		return &RunSignature{Error: err.Error()}
+	}
+	return &RunSignature{Verified: true, Signer: signer}
+}
+
+// runSignatures returns the signature verifications of the signed runs, by UUID
+func runSignatures(runs []Run) map[string]*RunSignature {
+	signatures := make(map[string]*RunSignature)
+	for _, run := range runs {
+		if run.Signature != nil {
+			signatures[run.UUID()] = run.Signature
+		}
+	}
+	return signatures
+}
+
+// verifySignedChecksums returns the signer of the run checksums, failing when the signature or any checksum doesn't
+// match
+func verifySignedChecksums(runPath, signatureFile string, names []string) (string, error) {
+	checksums, err := storage.ReadFile(filepath.Join(runPath, checksumsFile))
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", checksumsFile, err)
+	}
+	signature, err := storage.ReadFile(filepath.Join(runPath, signatureFile))
+	if err != nil {
+		return "", fmt.Errorf("error reading %s: %v", signatureFile, err)
+	}
+	var signer string
+	if signatureFile == gpgSignatureFile {
+		signer, err = verifyGPGSignature(checksums, signature)
+	} else {
+		signer, err = verifyCosignSignature(checksums, signature)
+	}
+	if err != nil {
+		return "", err
+	}
+	sums, err := parseChecksums(checksums)
+	if err != nil {
+		return "", err
+	}
+	if err := checkRunFiles(runPath, sums, names); err != nil {
+		return "", err
+	}
+	return signer, nil
+}
+
+// verifyGPGSignature returns the identity of the trusted key that made the armored signature of the data
+func verifyGPGSignature(data, signature []byte) (string, error) {
+	entity, err := openpgp.CheckArmoredDetachedSignature(signingKeys.gpg, bytes.NewReader(data), bytes.NewReader(signature), nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid GPG signature: %v", err)
+	}
+	if identity := entity.PrimaryIdentity(); identity != nil {
+		return identity.Name, nil
+	}
+	return fmt.Sprintf("GPG key %X", entity.PrimaryKey.Fingerprint), nil
+}
+
+// verifyCosignSignature returns the fingerprint of the trusted key that made the base64 signature of the data, the
+// format of cosign sign-blob with a key
+func verifyCosignSignature(data, signature []byte) (string, error) {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return "", fmt.Errorf("invalid cosign signature: %v", err)
+	}
+	digest := sha256.Sum256(data)
+	for _, key := range signingKeys.cosign {
+		var ok bool
+		switch key := key.(type) {
+		case *ecdsa.PublicKey:
+			ok = ecdsa.VerifyASN1(key, digest[:], sig)
+		case ed25519.PublicKey:
+			ok = ed25519.Verify(key, data, sig)
+		case *rsa.PublicKey:
+			ok = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil
+		}
+		if ok {
+			der, _ := x509.MarshalPKIXPublicKey(key)
+			fingerprint := sha256.Sum256(der)
+			return fmt.Sprintf("cosign key SHA256:%s", base64.RawStdEncoding.EncodeToString(fingerprint[:])), nil
+		}
+	}
+	return "", fmt.Errorf("invalid cosign signature: not made by a trusted key")
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"cmp"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// slackMaxSkew is how far the timestamps of the requests of Slack can be from now, older ones are rejected as
+	// replays
+	slackMaxSkew = 5 * time.Minute
+	// Runs plotted in the charts of the replies, and how long their images are served
+	slackChartRuns   = 50
+	slackChartExpiry = maxShareExpiry
+	slackChartPath   = "/slack/charts"
+	// slackTopMetrics is the number of metrics the comparison replies list, the ones changing the most
+	slackTopMetrics = 5
+	// slackMaxFields is the number of fields Slack accepts in a section block
+	slackMaxFields = 10
+)
+
+// Emojis of the statuses of the latest runs in the replies
+var slackStatusEmojis = map[string]string{
+	latestFailed:   ":x:",
+	latestViolated: ":warning:",
+	latestStale:    ":hourglass:",
+	latestOK:       ":white_check_mark:",
+}
+
+// SlackConfig configures the slash command of the Slack app of the dashboard, such as /perf, whose request URL is
+// the /slack/commands endpoint
+type SlackConfig struct {
+	// SigningSecret is the signing secret of the Slack app, verifying the requests come from Slack. It expands
+	// environment variables, such as ${SLACK_SIGNING_SECRET}
+	SigningSecret string `yaml:"signingSecret"`
+	// User and Groups are the identity the commands access the jobs as when -policy-file is set, as anyone in the
+	// workspace can run them. No job is accessible without a user
+	User   string   `yaml:"user"`
+	Groups []string `yaml:"groups"`
+}
+
+func loadSlackConfig(configPath string) (*SlackConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	var config SlackConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("error parsing slack config %s: %v", configPath, err)
+	}
+	config.SigningSecret = os.ExpandEnv(config.SigningSecret)
+	if config.SigningSecret == "" {
+		return nil, fmt.Errorf("invalid slack config %s: signingSecret is required", configPath)
+	}
+	return &config, nil
+}
+
+// verify checks the request was signed by Slack: its signature is the HMAC of its timestamp and body
+func (s *SlackConfig) verify(r *http.Request, body []byte) error {
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return errors.New("missing request timestamp")
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > slackMaxSkew || skew < -slackMaxSkew {
+		return errors.New("request timestamp too far from now")
+	}
+	mac := hmac.New(sha256.New, []byte(s.SigningSecret))
+	fmt.Fprintf(mac, "v0:%s:%s", timestamp, body)
+	if !hmac.Equal([]byte("v0="+hex.EncodeToString(mac.Sum(nil))), []byte(r.Header.Get("X-Slack-Signature"))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// slackJobAllowed reports whether the commands can access the job, as the identity of the Slack config
+func (c *Config) slackJobAllowed(job string) bool {
+	return c.policy == nil || c.policy.jobAllowed(Identity{User: c.slack.User, Groups: c.slack.Groups}, job)
+}
+
+// slackJobs loads the jobs the commands can access
+func (c *Config) slackJobs() ([]Job, error) {
+	jobs, err := loadJobs(c.resultsDir)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(jobs, func(job Job) bool { return !c.slackJobAllowed(job.Name) }), nil
+}
+
+// slackMessage is a reply to a command, laid out in Block Kit blocks. Text is the fallback of the notifications
+type slackMessage struct {
+	ResponseType string       `json:"response_type"`
+	Text         string       `json:"text"`
+	Blocks       []slackBlock `json:"blocks,omitempty"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+	Title    *slackText  `json:"title,omitempty"`
+	ImageURL string      `json:"image_url,omitempty"`
+	AltText  string      `json:"alt_text,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func slackSection(text string) slackBlock {
+	return slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: text}}
+}
+
+func slackContext(text string) slackBlock {
+	return slackBlock{Type: "context", Elements: []slackText{{Type: "mrkdwn", Text: text}}}
+}
+
+func slackImage(imageURL, title string) slackBlock {
+	return slackBlock{Type: "image", Title: &slackText{Type: "plain_text", Text: title}, ImageURL: imageURL, AltText: title}
+}
+
+// slackError is the reply to a command that failed, only shown to the user who ran it
+func slackError(format string, a ...any) slackMessage {
+	return slackMessage{ResponseType: "ephemeral", Text: fmt.Sprintf(format, a...)}
+}
+
+// slackEscape escapes the characters Slack gives a meaning to in the texts of the replies
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// slackCommandHandler answers the slash command of the Slack app: latest <workload> sums the latest run of a workload
+// up, and compare <baseline> <current> compares two runs of a workload by UUID. The replies are posted to the
+// channel, with a chart served by /slack/charts
+func (c *Config) slackCommandHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error reading request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err := c.slack.verify(r, body); err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	command := cmp.Or(form.Get("command"), "/perf")
+	args := strings.Fields(form.Get("text"))
+	var reply slackMessage
+	switch {
+	case len(args) == 2 && args[0] == "latest":
+		reply = c.slackLatest(r, args[1])
+	case len(args) == 3 && args[0] == "compare":
+		reply = c.slackCompare(r, args[1], args[2])
+	default:
+		reply = slackMessage{
+			ResponseType: "ephemeral",
+			Text: slackEscape(fmt.Sprintf("Usage:\n`%s latest <workload>` sums the latest run of a workload up, given as job/workload when several jobs run it\n"+
+				"`%s compare <baseline-uuid> <current-uuid>` compares two runs of a workload", command, command)),
+		}
+	}
+	writeJSON(w, reply)
+}
+
+// slackWorkload finds the workload of the command, given as job/workload or by its name alone when a single active
+// job runs it
+func (c *Config) slackWorkload(name string) (string, string, error) {
+	if jobName, workloadName, ok := strings.Cut(name, "/"); ok {
+		for _, name := range []string{jobName, workloadName} {
+			if !validName(name) {
+				return "", "", fmt.Errorf("invalid job or workload name %q", name)
+			}
+		}
+		if !c.slackJobAllowed(jobName) {
+			return "", "", fmt.Errorf("access to job %s denied", jobName)
+		}
+		return jobName, workloadName, nil
+	}
+	jobs, err := c.slackJobs()
+	if err != nil {
+		return "", "", err
+	}
+	var candidates []string
+	for _, job := range activeJobs(jobs) {
+		for _, workload := range job.Workloads {
+			if workload.Name == name {
+				candidates = append(candidates, job.Name+"/"+workload.Name)
+			}
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return "", "", fmt.Errorf("workload %s not found", name)
+	case 1:
+		jobName, workloadName, _ := strings.Cut(candidates[0], "/")
+		return jobName, workloadName, nil
+	}
+	return "", "", fmt.Errorf("workload %s is run by several jobs, pick one of:\n%s", name, strings.Join(candidates, "\n"))
+}
+
+// slackLatest replies with the summary of the latest run of the workload, charting the history of its metric
+// changing the most
+func (c *Config) slackLatest(r *http.Request, name string) slackMessage {
+	jobName, workloadName, err := c.slackWorkload(name)
+	if err != nil {
+		return slackError("%v", err)
+	}
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil || len(runs) == 0 {
+		return slackError("workload %s/%s has no runs", jobName, workloadName)
+	}
+	latest := c.latestRun(jobName, workloadName, runs, time.Now())
+	l := cmp.Or(c.fallbackLocale, defaultLocale)
+	workloadURL := fmt.Sprintf("%s/job/%s/%s", baseURL(r), url.PathEscape(jobName), url.PathEscape(workloadName))
+	title := fmt.Sprintf("%s latest run of %s/%s: %s", slackStatusEmojis[latest.Status], jobName, workloadName, latest.Status)
+	reply := slackMessage{
+		ResponseType: "in_channel",
+		Text:         title,
+		Blocks: []slackBlock{slackSection(fmt.Sprintf("%s *<%s|%s/%s>* latest run is *%s*\n%s `%s` started %s",
+			slackStatusEmojis[latest.Status], workloadURL, slackEscape(jobName), slackEscape(workloadName), latest.Status,
+			slackEscape(cmp.Or(latest.Alias, shortUUID(latest.UUID))), slackEscape(latest.Run),
+			formatTime(latest.Started, c.location, "2006-01-02 15:04 MST")))},
+	}
+	var fields []slackText
+	for _, metric := range latest.Metrics[:min(len(latest.Metrics), slackMaxFields)] {
+		text := fmt.Sprintf("*%s*", slackEscape(metric.MetricName))
+		if metric.JobName != "" {
+			text += fmt.Sprintf(" (%s)", slackEscape(metric.JobName))
+		}
+		text += fmt.Sprintf("\n%s P99 %s", metric.Quantile, l.humanizeMs(metric.P99))
+		if metric.Median != 0 {
+			text += fmt.Sprintf(", %s from the median", l.signedPercent(metric.Delta*100))
+		}
+		fields = append(fields, slackText{Type: "mrkdwn", Text: text})
+	}
+	if len(fields) > 0 {
+		reply.Blocks = append(reply.Blocks, slackBlock{Type: "section", Fields: fields})
+	}
+	var slos []string
+	for _, s := range latest.SLOs {
+		line := fmt.Sprintf("• %s: %s for at most %s", slackEscape(s.Name), l.humanizeMs(s.Value), l.humanizeMs(s.Threshold))
+		if s.Violated {
+			line += ", *violated*"
+		}
+		if s.Exhausted {
+			line += ", error budget exhausted"
+		}
+		slos = append(slos, line)
+	}
+	if len(slos) > 0 {
+		reply.Blocks = append(reply.Blocks, slackSection("*SLOs*\n"+strings.Join(slos, "\n")))
+	}
+	if latest.Stale != nil {
+		reply.Blocks = append(reply.Blocks, slackContext(fmt.Sprintf("Runs are expected %s, the next one was due by %s",
+			cadenceText(latest.Stale.Cadence), formatTime(latest.Stale.Due, c.location, "2006-01-02 15:04 MST"))))
+	}
+	// Chart the metric changing the most from the median of the runs before
+	if len(latest.Metrics) > 0 {
+		metric := slices.MaxFunc(latest.Metrics, func(a, b LatestMetric) int {
+			return cmp.Compare(math.Abs(a.Delta), math.Abs(b.Delta))
+		})
+		chart := url.Values{
+			"job":      {jobName},
+			"workload": {workloadName},
+			"metric":   {metric.MetricName},
+			"group":    {metric.JobName},
+			"quantile": {metric.Quantile},
+			"run":      {latest.UUID},
+		}
+		if metric.Median != 0 {
+			chart.Set("reference", strconv.FormatFloat(metric.Median, 'g', -1, 64))
+		}
+		reply.Blocks = append(reply.Blocks, slackImage(c.slackChartURL(r, chart),
+			fmt.Sprintf("%s %s P99 over the last %d runs", metric.MetricName, metric.Quantile, slackChartRuns)))
+	}
+	return reply
+}
+
+// slackCompare replies with the metrics changing the most between both runs, charting the history of the first one
+// between them
+func (c *Config) slackCompare(r *http.Request, baselineUUID, currentUUID string) slackMessage {
+	jobs, err := c.slackJobs()
+	if err != nil {
+		return slackError("%v", err)
+	}
+	baseline, current, _, err := findComparedRuns(jobs, baselineUUID+"..."+currentUUID)
+	if err != nil {
+		return slackError("%v", err)
+	}
+	workloadPath := filepath.Join(c.resultsDir, baseline.Job, baseline.Workload)
+	runs, err := loadRuns(workloadPath)
+	if err != nil {
+		return slackError("workload %s/%s not found", baseline.Job, baseline.Workload)
+	}
+	snapshot, err := loadBaseline(workloadPath)
+	if err != nil {
+		return slackError("%v", err)
+	}
+	opts := c.runPairOptions(baseline.Run.UUID(), current.Run.UUID())
+	comparison, err := compareRuns(runs, snapshot, opts)
+	if err != nil {
+		return slackError("%v", err)
+	}
+	l := cmp.Or(c.fallbackLocale, defaultLocale)
+	runName := func(run Run) string {
+		return slackEscape(cmp.Or(c.aliases.get(run.UUID()), shortUUID(run.UUID())))
+	}
+	title := fmt.Sprintf("Comparison of %s/%s runs %s and %s", baseline.Job, baseline.Workload, baseline.Run.Name(), current.Run.Name())
+	reply := slackMessage{
+		ResponseType: "in_channel",
+		Text:         title,
+		Blocks: []slackBlock{slackSection(fmt.Sprintf("*<%s|%s/%s>*: <%s|%s against %s>",
+			fmt.Sprintf("%s/job/%s/%s", baseURL(r), url.PathEscape(baseline.Job), url.PathEscape(baseline.Workload)),
+			slackEscape(baseline.Job), slackEscape(baseline.Workload),
+			baseURL(r)+comparePermalink(baseline.Run.UUID(), current.Run.UUID(), opts), runName(current.Run), runName(baseline.Run)))},
+	}
+	metrics := slices.Clone(comparison.Metrics)
+	slices.SortStableFunc(metrics, func(a, b MetricComparison) int {
+		return cmp.Compare(math.Abs(b.Delta), math.Abs(a.Delta))
+	})
+	metrics = metrics[:min(len(metrics), slackTopMetrics)]
+	if len(metrics) == 0 {
+		reply.Blocks = append(reply.Blocks, slackSection("The runs have no metric in common"))
+		return reply
+	}
+	var lines []string
+	for _, mc := range metrics {
+		lines = append(lines, fmt.Sprintf("• *%s* %s P99: %s → %s, %s", slackEscape(mc.MetricName), slackEscape(mc.QuantileName),
+			l.humanizeMs(mc.Baseline.Mean), l.humanizeMs(mc.Current.Mean), l.signedPercent(mc.Delta)))
+	}
+	reply.Blocks = append(reply.Blocks, slackSection("*Largest changes*\n"+strings.Join(lines, "\n")),
+		slackContext("A comparison of single runs doesn't tell noise from regressions, compare more runs in the dashboard"))
+	chart := url.Values{
+		"job":       {baseline.Job},
+		"workload":  {baseline.Workload},
+		"metric":    {metrics[0].MetricName},
+		"quantile":  {metrics[0].QuantileName},
+		"run":       {current.Run.UUID()},
+		"baseline":  {baseline.Run.UUID()},
+		"reference": {strconv.FormatFloat(metrics[0].Baseline.Mean, 'g', -1, 64)},
+	}
+	reply.Blocks = append(reply.Blocks, slackImage(c.slackChartURL(r, chart),
+		fmt.Sprintf("%s %s P99 between both runs", metrics[0].MetricName, metrics[0].QuantileName)))
+	return reply
+}
+
+// slackChartURL returns the URL of the image of the chart, signed like the share links as Slack fetches it without
+// authenticating
+func (c *Config) slackChartURL(r *http.Request, chart url.Values) string {
+	token := c.signShare(ShareClaims{
+		Path:    slackChartPath + "?" + chart.Encode(),
+		Expires: time.Now().Add(slackChartExpiry).Unix(),
+	})
+	return baseURL(r) + slackChartPath + "/" + token
+}
+
+// slackChartHandler serves the chart of a reply as a PNG: the P99 of a metric quantile over the runs up to the run
+// of the reply, from the baseline run for comparisons, with the reference value of the reply, if any, as a dashed
+// line. The run is in red when above it
+func (c *Config) slackChartHandler(w http.ResponseWriter, r *http.Request) {
+	claims, err := c.verifyShare(r.PathValue("token"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	chart, err := url.Parse(claims.Path)
+	if err != nil || chart.Path != slackChartPath {
+		http.Error(w, "invalid chart link", http.StatusForbidden)
+		return
+	}
+	query := chart.Query()
+	runs, err := loadRuns(filepath.Join(c.resultsDir, query.Get("job"), query.Get("workload")))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("workload %s/%s not found", query.Get("job"), query.Get("workload")), http.StatusNotFound)
+		return
+	}
+	runIndex := func(uuid string) int {
+		return slices.IndexFunc(runs, func(run Run) bool { return run.UUID() == uuid })
+	}
+	end := runIndex(query.Get("run"))
+	if end < 0 {
+		http.Error(w, fmt.Sprintf("run %s not found", query.Get("run")), http.StatusNotFound)
+		return
+	}
+	baseline := -1
+	if uuid := query.Get("baseline"); uuid != "" {
+		if baseline = runIndex(uuid); baseline < 0 {
+			http.Error(w, fmt.Sprintf("run %s not found", uuid), http.StatusNotFound)
+			return
+		}
+	}
+	start, last := chartRange(end, baseline, slackChartRuns)
+	reference, err := strconv.ParseFloat(query.Get("reference"), 64)
+	if err != nil {
+		reference = math.NaN()
+	}
+	image, err := metricChart(runs[start:last+1], query.Get("metric"), query.Get("group"), query.Get("quantile"), reference, last == end)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error rendering chart: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(image)
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Defaults of the SLOs not setting them
+const (
+	// defaultSLOWindow is the number of most recent runs the error budget of an SLO covers
+	defaultSLOWindow = 30
+	// defaultSLOBudget is the percentage of the runs of the window allowed to violate an SLO
+	defaultSLOBudget = 10
+)
+
+// SLO is an objective a metric quantile of the workloads must meet, the percentile of the quantile staying under a
+// threshold. Runs over it violate the SLO, consuming its error budget
+type SLO struct {
+	Name string `yaml:"name"`
+	// Job and Workload are glob patterns of the workloads the SLO applies to, any when empty
+	Job        string `yaml:"job"`
+	Workload   string `yaml:"workload"`
+	Metric     string `yaml:"metric"`
+	Quantile   string `yaml:"quantile"`
+	Percentile string `yaml:"percentile"`
+	// Phase restricts the SLO to the measurements of a kube-burner job, when set
+	Phase     string  `yaml:"phase"`
+	Threshold float64 `yaml:"threshold"`
+	// Budget is the percentage of the runs of the window allowed to violate the SLO
+	Budget float64 `yaml:"budget"`
+	Window int     `yaml:"window"`
+}
+
+// slos are the SLOs loaded from -slo-file, workloads aren't tracked against any without it
+var slos []SLO
+
+func loadSLOs(sloPath string) error {
+	data, err := os.ReadFile(sloPath)
+	if err != nil {
+		return err
+	}
+	var loaded []SLO
+	if err := yaml.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("error parsing SLOs %s: %v", sloPath, err)
+	}
+	for i := range loaded {
+		if loaded[i], err = loaded[i].validate(); err != nil {
+			return fmt.Errorf("invalid SLO %d in %s: %v", i+1, sloPath, err)
+		}
+	}
+	slos = loaded
+	return nil
+}
+
+// validate checks the SLO and fills in its defaults
+func (s SLO) validate() (SLO, error) {
+	if s.Name == "" || s.Metric == "" || s.Quantile == "" {
+		return s, fmt.Errorf("name, metric and quantile are required")
+	}
+	if s.Threshold <= 0 {
+		return s, fmt.Errorf("threshold of %s must be positive", s.Name)
+	}
+	for _, pattern := range []string{s.Job, s.Workload} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return s, fmt.Errorf("invalid pattern %q in %s: %v", pattern, s.Name, err)
+		}
+	}
+	var err error
+	if s.Percentile == "" {
+		s.Percentile = "P99"
+	} else if s.Percentile, err = parsePercentile(s.Percentile); err != nil {
+		return s, err
+	}
+	if s.Budget == 0 {
+		s.Budget = defaultSLOBudget
+	}
+	if s.Budget < 0 || s.Budget > 100 {
+		return s, fmt.Errorf("budget of %s must be a percentage", s.Name)
+	}
+	if s.Window == 0 {
+		s.Window = defaultSLOWindow
+	}
+	if s.Window < 0 {
+		return s, fmt.Errorf("window of %s must be a positive number of runs", s.Name)
+	}
+	return s, nil
+}
+
+// applies reports whether the SLO applies to the workload
+func (s SLO) applies(jobName, workloadName string) bool {
+	for _, m := range [][2]string{{s.Job, jobName}, {s.Workload, workloadName}} {
+		if ok, _ := path.Match(m[0], m[1]); m[0] != "" && !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// workloadSLOs returns the SLOs applying to the workload, overridden by the descriptors of its job and then of the
+// workload itself
+func (c *Config) workloadSLOs(jobName, workloadName string) []SLO {
+	var applying []SLO
+	for _, s := range slos {
+		if s.applies(jobName, workloadName) {
+			applying = append(applying, s)
+		}
+	}
+	applying = loadDescriptor(filepath.Join(c.resultsDir, jobName)).overrideSLOs(applying)
+	return loadDescriptor(filepath.Join(c.resultsDir, jobName, workloadName)).overrideSLOs(applying)
+}
+
+// SLOPoint is a run checked against an SLO, along with the error budget left after it
+type SLOPoint struct {
+	Seq       int
+	Timestamp time.Time
+	Value     float64
+	Violated  bool
+	Remaining float64
+}
+
+// SLOStatus is the error budget of an SLO over the window of runs of a workload, burnt down run by run
+type SLOStatus struct {
+	SLO
+	// Runs is the number of runs of the window having the quantile, there may be fewer than the window
+	Runs       int
+	Violations int
+	// Allowed is the number of violations the budget allows over the window, and Remaining the ones left
+	Allowed   float64
+	Remaining float64
+	// Exhausted is set when the runs violated the SLO more often than the budget allows
+	Exhausted bool
+	Points    []SLOPoint
+}
+
+// sloStatus checks the last runs of the window against the SLO
+func sloStatus(s SLO, runs []Run) SLOStatus {
+	status := SLOStatus{
+		SLO:     s,
+		Allowed: s.Budget / 100 * float64(s.Window),
+	}
+	status.Remaining = status.Allowed
+	job := Job{Runs: runs}
+	for _, group := range prepareChartData(&job, ChartOptions{Last: s.Window, Phase: s.Phase}) {
+		if group.MetricName != s.Metric {
+			continue
+		}
+		for _, chart := range group.Charts {
+			if chart.QuantileName != s.Quantile {
+				continue
+			}
+			for _, dp := range chart.Datapoints {
+				point := SLOPoint{
+					Seq:       dp.Seq,
+					Timestamp: dp.Timestamp,
+					Value:     dp.value(s.Percentile),
+				}
+				if point.Value > s.Threshold {
+					point.Violated = true
+					status.Violations++
+					status.Remaining--
+				}
+				point.Remaining = status.Remaining
+				status.Points = append(status.Points, point)
+			}
+		}
+	}
+	status.Runs = len(status.Points)
+	status.Exhausted = status.Remaining < 0
+	return status
+}
+
+// WorkloadSLOs is the error budget of every SLO applying to a workload
+type WorkloadSLOs struct {
+	Job      string
+	Workload string
+	SLOs     []SLOStatus
+}
+
+// loadWorkloadSLOs checks the runs of a workload against the SLOs applying to it
+func (c *Config) loadWorkloadSLOs(jobName, workloadName string) (WorkloadSLOs, int, error) {
+	result := WorkloadSLOs{Job: jobName, Workload: workloadName}
+	applying := c.workloadSLOs(jobName, workloadName)
+	if len(slos) == 0 && len(applying) == 0 {
+		return result, http.StatusNotFound, fmt.Errorf("error budgets are disabled, no SLOs are configured")
+	}
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		return result, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	for _, s := range applying {
+		result.SLOs = append(result.SLOs, sloStatus(s, runs))
+	}
+	return result, http.StatusOK, nil
+}
+
+// apiSLOsHandler returns the error budgets of every visible workload having SLOs, for release dashboards. They can
+// be restricted to the workloads with an exhausted budget with exhausted=true
+func (c *Config) apiSLOsHandler(w http.ResponseWriter, r *http.Request) {
+	jobs, err := c.visibleJobs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	exhausted := r.URL.Query().Get("exhausted") == "true"
+	results := []WorkloadSLOs{}
+	// Without -slo-file, error budgets are only enabled by the descriptors of the workloads
+	tracked := len(slos) > 0
+	for _, job := range activeJobs(jobs) {
+		for _, workload := range job.Workloads {
+			if len(c.workloadSLOs(job.Name, workload.Name)) == 0 {
+				continue
+			}
+			tracked = true
+			result, _, err := c.loadWorkloadSLOs(job.Name, workload.Name)
+			if err != nil {
+				continue
+			}
+			if exhausted {
+				result.SLOs = slices.DeleteFunc(result.SLOs, func(s SLOStatus) bool { return !s.Exhausted })
+				if len(result.SLOs) == 0 {
+					continue
+				}
+			}
+			results = append(results, result)
+		}
+	}
+	if !tracked {
+		http.Error(w, "error budgets are disabled, no SLOs are configured", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, results)
+}
+
+func (c *Config) apiWorkloadSLOsHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	result, status, err := c.loadWorkloadSLOs(jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, result)
+}
+
+func (c *Config) slosHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	result, _, err := c.loadWorkloadSLOs(jobName, workloadName)
+	type TemplateData struct {
+		JobName      string
+		WorkloadName string
+		SLOs         []SLOStatus
+		SLOsJSON     template.JS
+		Error        string
+	}
+	slosJSON, _ := json.Marshal(result.SLOs)
+	data := TemplateData{
+		JobName:      jobName,
+		WorkloadName: workloadName,
+		SLOs:         result.SLOs,
+		SLOsJSON:     template.JS(slosJSON),
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "slos.html", data)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+)
+
+// defaultStabilityRuns is the number of most recent runs the stability of a workload is computed over
+const defaultStabilityRuns = 10
+
+// Stability levels of a workload, by the coefficient of variation of its noisiest key metric
+const (
+	stabilityStable   = "stable"
+	stabilityNoisy    = "noisy"
+	stabilityUnstable = "unstable"
+	// Coefficients of variation from which a workload is noisy or unstable
+	noisyCV    = 0.10
+	unstableCV = 0.25
+)
+
+// MetricStability is the spread of the P99 of a key metric over the recent runs of a workload
+type MetricStability struct {
+	MetricName string
+	// JobName is the kube-burner job of the measurements, when the workload runs several
+	JobName string `json:",omitempty"`
+	Mean    float64
+	StdDev  float64
+	// CV is the coefficient of variation, the standard deviation relative to the mean
+	CV float64
+}
+
+// Stability scores how repeatable the results of a workload are, so the benchmarks whose environment needs
+// investigating stand out. The key metrics are the P99 of the Ready quantile of every metric having one
+type Stability struct {
+	// Runs is the number of runs the score was computed over
+	Runs int
+	// CV is the coefficient of variation of the noisiest key metric, the score of the workload
+	CV      float64
+	Level   string
+	Metrics []MetricStability
+}
+
+// workloadStability computes the stability of the last runs of a workload, nil when there are fewer than minRuns
+// runs or no key metrics
+func workloadStability(runs []Run, last, minRuns int) *Stability {
+	runs = ChartOptions{Last: last}.filterRuns(runs)
+	if len(runs) < max(minRuns, 2) {
+		return nil
+	}
+	job := Job{Runs: runs}
+	stability := &Stability{Runs: len(runs)}
+	for _, group := range prepareChartData(&job, ChartOptions{GroupByJob: true}) {
+		i := slices.IndexFunc(group.Charts, func(chart ChartData) bool {
+			return chart.QuantileName == defaultTrendQuantile
+		})
+		if i < 0 || len(group.Charts[i].Datapoints) < max(minRuns, 2) {
+			continue
+		}
+		var values []float64
+		for _, dp := range group.Charts[i].Datapoints {
+			values = append(values, dp.P99)
+		}
+		m := mean(values)
+		if m <= 0 {
+			continue
+		}
+		metric := MetricStability{
+			MetricName: group.MetricName,
+			JobName:    group.JobName,
+			Mean:       m,
+			StdDev:     stddev(values),
+		}
+		metric.CV = metric.StdDev / m
+		stability.Metrics = append(stability.Metrics, metric)
+		stability.CV = max(stability.CV, metric.CV)
+	}
+	if len(stability.Metrics) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(stability.Metrics, func(a, b MetricStability) int {
+		return cmp.Compare(b.CV, a.CV)
+	})
+	switch {
+	case stability.CV >= unstableCV:
+		stability.Level = stabilityUnstable
+	case stability.CV >= noisyCV:
+		stability.Level = stabilityNoisy
+	default:
+		stability.Level = stabilityStable
+	}
+	return stability
+}
+
+// String describes the score, shown as the tooltip of the stability badges
+func (s Stability) String() string {
+	if len(s.Metrics) == 0 {
+		return ""
+	}
+	noisiest := s.Metrics[0]
+	name := noisiest.MetricName
+	if noisiest.JobName != "" {
+		name += " (" + noisiest.JobName + ")"
+	}
+	return fmt.Sprintf("Coefficient of variation of the P99 %s over the last %d runs, %s being the noisiest key metric",
+		defaultTrendQuantile, s.Runs, name)
+}
+
+// Percent returns the score as a percentage
+func (s Stability) Percent() float64 {
+	return 100 * s.CV
+}
+
+// addStability computes the stability of the workloads, for the listings
+func (c *Config) addStability(workloads []Workload) {
+	for i, workload := range workloads {
+		runs, err := loadRuns(workload.Path)
+		if err != nil {
+			continue
+		}
+		workloads[i].Stability = workloadStability(runs, c.stabilityRuns, c.minSamples)
+	}
+}
@@ -3,11 +3,17 @@ let metricGroups = [];
 let charts = {}; // Map of metricIndex -> Chart instance
 let selectedQuantiles = {}; // Map of metricIndex -> selected quantile index
 let selectedMetrics = {}; // Map of metricIndex -> selected metric
+let xAxis = 'index'; // Either index, runs evenly spaced by sequence number, or time
+let splitBy = ''; // Metadata field the series are split by, none when empty
+let bucket = ''; // Either empty, a datapoint per run, or week, a datapoint per week with the median of its runs
 
 // Initialize the page
 function initializePage() {
     if (typeof window.metricGroups !== 'undefined' && window.metricGroups && window.metricGroups.length > 0) {
         metricGroups = window.metricGroups;
+        xAxis = window.xAxis || xAxis;
+        splitBy = window.splitBy || splitBy;
+        bucket = window.bucket || bucket;
         initializeAllCharts();
         setupModal();
     } else {
@@ -39,6 +45,7 @@ function initializeQuantileDropdown(metricIndex, metricGroup) {
         const option = document.createElement('option');
         option.value = index;
         option.textContent = chart.QuantileName;
+        option.title = chart.Description || '';
         if (index === 0) option.selected = true;
         quantileSelect.appendChild(option);
     });
@@ -56,12 +63,7 @@ function createChart(metricIndex, quantileData, metricGroup) {
 
     const ctx = canvas.getContext('2d');
     const selectedMetric = selectedMetrics[metricIndex] || 'P99';
-    let metricKey = selectedMetric;
-
-    // Handle different metric naming
-    if (selectedMetric === 'min') metricKey = 'Min';
-    else if (selectedMetric === 'max') metricKey = 'Max';
-    else if (selectedMetric === 'avg') metricKey = 'Avg';
+    const metricKey = selectedMetric;
 
     // Limit to most recent 100 datapoints
     const limitedDatapoints = quantileData.Datapoints.slice(-100);
@@ -97,34 +99,51 @@ function createChart(metricIndex, quantileData, metricGroup) {
         }, 50);
     });
 
+    // Positions are computed here rather than taken from the X field, live updates are always built in index mode
+    const xValue = d => xAxis === 'time' ? Date.parse(d.Timestamp) : d.Seq;
+
+    // One line per value of the metadata field the series are split by, a single one otherwise
+    const series = splitSeries(limitedDatapoints);
+    const datasets = series.map((s, i) => splitBy ? {
+        label: s.name,
+        data: s.datapoints.map(d => ({x: xValue(d), y: d[metricKey] || 0})),
+        borderColor: seriesColors[i % seriesColors.length],
+        backgroundColor: seriesColors[i % seriesColors.length],
+        fill: false,
+        tension: 0.1
+    } : {
+        label: quantileData.QuantileName + ' (' + selectedMetric + ')',
+        data: s.datapoints.map(d => ({x: xValue(d), y: d[metricKey] || 0})),
+        borderColor: '#EE0000',
+        backgroundColor: 'rgba(238, 0, 0, 0.2)',
+        fill: true,
+        tension: 0.1,
+        pointBackgroundColor: '#EE0000',
+        pointBorderColor: '#CC0000',
+        pointHoverBackgroundColor: '#CC0000',
+        pointHoverBorderColor: '#AA0000'
+    });
+
     return new Chart(ctx, {
         type: 'line',
         data: {
-            labels: limitedDatapoints.map(d => new Date(d.Timestamp).toLocaleDateString()),
-            datasets: [{
-                label: quantileData.QuantileName + ' (' + selectedMetric + ')',
-                data: limitedDatapoints.map(d => d[metricKey] || 0),
-                borderColor: '#EE0000',
-                backgroundColor: 'rgba(238, 0, 0, 0.2)',
-                fill: true,
-                tension: 0.1,
-                pointBackgroundColor: '#EE0000',
-                pointBorderColor: '#CC0000',
-                pointHoverBackgroundColor: '#CC0000',
-                pointHoverBorderColor: '#AA0000'
-            }]
+            datasets: datasets
         },
         options: {
             responsive: true,
             maintainAspectRatio: false,
             plugins: {
                 legend: {
-                    display: false
+                    display: !!splitBy
                 },
                 tooltip: {
                     callbacks: {
                         title: function(context) {
-                            return context[0].label;
+                            const datapoint = series[context[0].datasetIndex].datapoints[context[0].dataIndex];
+                            if (bucket) {
+                                return 'Week of ' + new Date(datapoint.Timestamp).toLocaleDateString() + ', median of ' + datapoint.Runs + ' runs';
+                            }
+                            return '#' + datapoint.Seq + ' ' + new Date(datapoint.Timestamp).toLocaleString();
                         },
                         label: function(context) {
                             return context.parsed.y + ' ms';
@@ -168,12 +187,16 @@ function createChart(metricIndex, quantileData, metricGroup) {
                     }
                 },
                 x: {
+                    type: 'linear',
                     title: {
-                        display: false
+                        display: true,
+                        text: xAxis === 'time' ? 'Time' : 'Run'
                     },
                     ticks: {
                         maxRotation: 45,
-                        minRotation: 0
+                        minRotation: 0,
+                        precision: 0,
+                        callback: value => xAxis === 'time' ? new Date(value).toLocaleDateString() : '#' + value
                     }
                 }
             },
@@ -198,11 +221,12 @@ function createChart(metricIndex, quantileData, metricGroup) {
                     const activePoints = chart.getElementsAtEventForMode(event.native, 'nearest', { intersect: true }, true);
                     if (activePoints.length > 0) {
                         const pointIndex = activePoints[0].index;
-                        // The data array corresponds to limitedDatapoints
+                        const datapoints = series[activePoints[0].datasetIndex].datapoints;
+                        // The data array corresponds to the datapoints of the series
                         // After zoom, Chart.js still uses the same data array, just shows a subset
-                        if (pointIndex >= 0 && pointIndex < limitedDatapoints.length) {
-                            const datapoint = limitedDatapoints[pointIndex];
-                            showJobSummary(datapoint.JobSummary, datapoint.Timestamp);
+                        if (pointIndex >= 0 && pointIndex < datapoints.length) {
+                            const datapoint = datapoints[pointIndex];
+                            showJobSummary(datapoint.JobSummary, datapoint.Timestamp, datapoint.Seq);
                         }
                     }
                 }
@@ -211,11 +235,65 @@ function createChart(metricIndex, quantileData, metricGroup) {
     });
 }
 
+// Group the datapoints by their series, in order of appearance
+function splitSeries(datapoints) {
+    const series = [];
+    datapoints.forEach(d => {
+        const name = d.Series || '';
+        let s = series.find(s => s.name === name);
+        if (!s) {
+            s = {name: name, datapoints: []};
+            series.push(s);
+        }
+        s.datapoints.push(d);
+    });
+    return series.length > 0 ? series : [{name: '', datapoints: []}];
+}
+
+// Reload the page with the given x axis mode, keeping the rest of the query
+function setXAxis(mode) {
+    const params = new URLSearchParams(window.location.search);
+    params.set('xaxis', mode);
+    window.location.search = params.toString();
+}
+
+function setBucket(value) {
+    const params = new URLSearchParams(window.location.search);
+    if (value) {
+        params.set('bucket', value);
+    } else {
+        params.delete('bucket');
+    }
+    window.location.search = params.toString();
+}
+
+function setSplit(field) {
+    const params = new URLSearchParams(window.location.search);
+    if (field) {
+        params.set('split', field);
+    } else {
+        params.delete('split');
+    }
+    window.location.search = params.toString();
+}
+
+function setPhase(phase) {
+    const params = new URLSearchParams(window.location.search);
+    if (phase) {
+        params.set('phase', phase);
+    } else {
+        params.delete('phase');
+    }
+    window.location.search = params.toString();
+}
+
 // Initialize single chart
 function initializeChart(metricIndex, metricGroup) {
     if (metricGroup.Charts.length > 0) {
         selectedQuantiles[metricIndex] = 0;
-        selectedMetrics[metricIndex] = 'P99';
+        // Default to the first percentile rendered by the server
+        const metricSelect = document.getElementById(`metricSelect-${metricIndex}`);
+        selectedMetrics[metricIndex] = metricSelect ? metricSelect.value : 'P99';
         updateChart(metricIndex);
     }
 }
@@ -259,6 +337,7 @@ function updateChartTitle(metricIndex) {
 
     if (chartTitle && metricGroup && metricGroup.Charts[quantileIndex]) {
         chartTitle.textContent = metricGroup.Charts[quantileIndex].QuantileName + ' (' + selectedMetric + ')';
+        chartTitle.title = metricGroup.Charts[quantileIndex].Description || '';
     }
 }
 
@@ -279,7 +358,14 @@ document.addEventListener('change', function(e) {
     }
 });
 
-function showJobSummary(jobSummary, timestamp) {
+// escapeHTML escapes the text to be inserted as HTML
+function escapeHTML(text) {
+    const div = document.createElement('div');
+    div.textContent = text;
+    return div.innerHTML.replace(/"/g, '&quot;');
+}
+
+function showJobSummary(jobSummary, timestamp, seq) {
     const modal = document.getElementById('jobSummaryModal');
     const modalContent = document.getElementById('modalContent');
 
@@ -297,9 +383,48 @@ function showJobSummary(jobSummary, timestamp) {
 
     let content = '<div class="summary-section">';
     content += '<div class="summary-title">Run Information</div>';
+    content += '<div class="summary-item"><span class="summary-key">Run</span><span class="summary-value">#' + seq + '</span></div>';
+    const alias = (window.runAliases || {})[jobSummary.uuid];
+    if (alias) {
+        content += '<div class="summary-item"><span class="summary-key">Alias</span><span class="summary-value">' + escapeHTML(alias) + '</span></div>';
+    }
     content += '<div class="summary-item"><span class="summary-key">Timestamp</span><span class="summary-value">' + new Date(timestamp).toLocaleString() + '</span></div>';
+    // Outcome of the verification of the signature of the run, when it's signed
+    const signature = (window.runSignatures || {})[jobSummary.uuid];
+    if (signature) {
+        content += '<div class="summary-item"><span class="summary-key">Signature</span><span class="summary-value">';
+        if (signature.Verified) {
+            content += '<span class="signature-badge signature-verified" title="' + escapeHTML(signature.Signer) + '">verified</span> ' + escapeHTML(signature.Signer);
+        } else {
+            content += '<span class="signature-badge signature-invalid">invalid</span> ' + escapeHTML(signature.Error);
+        }
+        content += '</span></div>';
+    }
     content += '</div>';
 
+    // Estimated cost of the run, when a pricing table is configured
+    const cost = (window.runCosts || {})[jobSummary.uuid];
+    if (cost) {
+        content += '<div class="summary-section">';
+        content += '<div class="summary-title">Estimated Cost</div>';
+        content += '<div class="summary-item"><span class="summary-key">Total</span><span class="summary-value">' + cost.Cost.toFixed(2) + ' ' + escapeHTML(cost.Currency) + '</span></div>';
+        for (const node of cost.Nodes || []) {
+            content += '<div class="summary-item"><span class="summary-key">' + escapeHTML(node.Role) + '</span><span class="summary-value">' + node.Count + ' x ' + escapeHTML(node.InstanceType) + ': ' + node.Cost.toFixed(2) + ' ' + escapeHTML(cost.Currency) + '</span></div>';
+        }
+        content += '</div>';
+    }
+
+    // Links of the run into related systems, configured from its metadata
+    const links = (window.runLinks || {})[jobSummary.uuid] || [];
+    if (links.length > 0) {
+        content += '<div class="summary-section">';
+        content += '<div class="summary-title">Links</div>';
+        for (const link of links) {
+            content += '<div class="summary-item"><span class="summary-key">' + escapeHTML(link.Name) + '</span><span class="summary-value"><a href="' + escapeHTML(link.URL) + '" target="_blank" rel="noopener">' + escapeHTML(link.URL) + '</a></span></div>';
+        }
+        content += '</div>';
+    }
+
     let generalSectionStarted = false;
 
     // Display all fields from jobSummary
@@ -346,3 +471,471 @@ function setupModal() {
         }
     }
 }
+
+// Live updates pushed by the server through the websocket channel
+function connectLiveUpdates(job, workload) {
+    const protocol = window.location.protocol === 'https:' ? 'wss://' : 'ws://';
+    const params = new URLSearchParams({ job: job, workload: workload || '' });
+    let retryDelay = 1000;
+
+    function connect() {
+        const socket = new WebSocket(protocol + window.location.host + '/ws?' + params.toString());
+        socket.onopen = function() {
+            retryDelay = 1000;
+        };
+        socket.onmessage = function(message) {
+            const event = JSON.parse(message.data);
+            if (!workload) {
+                // Workload selection page, refresh to pick up new workloads and run counts
+                window.location.reload();
+            } else if (event.workload === workload && event.metricGroups) {
+                applyLiveUpdate(event.metricGroups);
+            }
+        };
+        socket.onclose = function() {
+            // Reconnect with backoff, the server may be restarting
+            setTimeout(connect, retryDelay);
+            retryDelay = Math.min(retryDelay * 2, 30000);
+        };
+    }
+    connect();
+}
+
+function applyLiveUpdate(newMetricGroups) {
+    const sameLayout = newMetricGroups.length === metricGroups.length &&
+        newMetricGroups.every((group, i) => group.MetricName === metricGroups[i].MetricName &&
+            group.JobName === metricGroups[i].JobName &&
+            group.Charts.length === metricGroups[i].Charts.length &&
+            group.Charts.every((chart, j) => chart.QuantileName === metricGroups[i].Charts[j].QuantileName));
+    // New metrics or quantiles need new chart containers, and the pushed series aren't split nor bucketed, let the
+    // server render them
+    if (!sameLayout || splitBy || bucket) {
+        window.location.reload();
+        return;
+    }
+    metricGroups = newMetricGroups;
+    metricGroups.forEach((metricGroup, metricIndex) => {
+        updateChart(metricIndex);
+        setupZoomControls(metricIndex);
+    });
+}
+
+// Colors used for charts with several series
+const seriesColors = ['#EE0000', '#0066CC', '#3E8635', '#F0AB00', '#8476D1', '#009596', '#EC7A08', '#6A6E73'];
+
+// Render several quantiles of a metric on a single chart
+function renderOverlayChart(canvasId, overlay) {
+    const canvas = document.getElementById(canvasId);
+    if (!canvas || !overlay || !overlay.Series) {
+        return null;
+    }
+    return new Chart(canvas.getContext('2d'), {
+        type: 'line',
+        data: {
+            labels: overlay.Timestamps.map(ts => new Date(ts).toLocaleDateString()),
+            datasets: overlay.Series.map((series, i) => ({
+                label: series.QuantileName + ' (' + overlay.Percentile + ')',
+                data: series.Values,
+                borderColor: seriesColors[i % seriesColors.length],
+                backgroundColor: seriesColors[i % seriesColors.length],
+                fill: false,
+                tension: 0.1,
+                spanGaps: true
+            }))
+        },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            interaction: {
+                mode: 'index',
+                intersect: false
+            },
+            plugins: {
+                tooltip: {
+                    callbacks: {
+                        label: function(context) {
+                            return context.dataset.label + ': ' + context.parsed.y + ' ms';
+                        }
+                    }
+                },
+                zoom: {
+                    zoom: {
+                        drag: {
+                            enabled: true
+                        },
+                        mode: 'x'
+                    },
+                    pan: {
+                        enabled: true,
+                        mode: 'x',
+                        modifierKey: 'shift'
+                    }
+                }
+            },
+            scales: {
+                y: {
+                    beginAtZero: true,
+                    title: {
+                        display: true,
+                        text: 'Latency (ms)'
+                    }
+                },
+                x: {
+                    ticks: {
+                        maxRotation: 45,
+                        minRotation: 0
+                    }
+                }
+            }
+        }
+    });
+}
+
+// Render a metric against the scale runs were executed at, with a series per version
+function renderScalabilityChart(canvasId, curve) {
+    const canvas = document.getElementById(canvasId);
+    if (!canvas || !curve || !curve.Series) {
+        return null;
+    }
+    return new Chart(canvas.getContext('2d'), {
+        type: 'line',
+        data: {
+            datasets: curve.Series.map((series, i) => ({
+                label: series.Version,
+                data: series.Points.map(p => ({x: p.Scale, y: p.Value, runs: p.Runs})),
+                borderColor: seriesColors[i % seriesColors.length],
+                backgroundColor: seriesColors[i % seriesColors.length],
+                fill: false,
+                tension: 0.1
+            }))
+        },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            plugins: {
+                tooltip: {
+                    callbacks: {
+                        label: function(context) {
+                            const runs = context.raw.runs;
+                            return context.dataset.label + ': ' + Math.round(context.parsed.y) + ' ms (median of ' + runs + (runs === 1 ? ' run)' : ' runs)');
+                        }
+                    }
+                },
+                zoom: {
+                    zoom: {
+                        drag: {
+                            enabled: true
+                        },
+                        mode: 'x'
+                    },
+                    pan: {
+                        enabled: true,
+                        mode: 'x',
+                        modifierKey: 'shift'
+                    }
+                }
+            },
+            scales: {
+                y: {
+                    beginAtZero: true,
+                    title: {
+                        display: true,
+                        text: 'Latency (ms)'
+                    }
+                },
+                x: {
+                    type: 'linear',
+                    title: {
+                        display: true,
+                        text: curve.Scale === 'iterations' ? 'Job iterations' : curve.Scale === 'nodes' ? 'Nodes' : curve.Scale
+                    }
+                }
+            }
+        }
+    });
+}
+
+// Render the estimated cost of the runs over time
+function renderCostChart(canvasId, set) {
+    const canvas = document.getElementById(canvasId);
+    if (!canvas || !set || !set.Runs) {
+        return null;
+    }
+    return new Chart(canvas.getContext('2d'), {
+        type: 'line',
+        data: {
+            labels: set.Runs.map(run => '#' + run.Seq + ' ' + new Date(run.Timestamp).toLocaleDateString()),
+            datasets: [{
+                label: 'Estimated cost (' + set.Currency + ')',
+                data: set.Runs.map(run => run.Cost),
+                borderColor: seriesColors[0],
+                backgroundColor: seriesColors[0],
+                fill: false,
+                tension: 0.1
+            }]
+        },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            plugins: {
+                tooltip: {
+                    callbacks: {
+                        label: function(context) {
+                            const run = set.Runs[context.dataIndex];
+                            return context.parsed.y.toFixed(2) + ' ' + set.Currency + ' over ' + Math.round(run.Duration / 60) + ' min';
+                        }
+                    }
+                },
+                zoom: {
+                    zoom: {
+                        drag: {
+                            enabled: true
+                        },
+                        mode: 'x'
+                    },
+                    pan: {
+                        enabled: true,
+                        mode: 'x',
+                        modifierKey: 'shift'
+                    }
+                }
+            },
+            scales: {
+                y: {
+                    beginAtZero: true,
+                    title: {
+                        display: true,
+                        text: 'Cost (' + set.Currency + ')'
+                    }
+                },
+                x: {
+                    ticks: {
+                        maxRotation: 45,
+                        minRotation: 0
+                    }
+                }
+            }
+        }
+    });
+}
+
+// Render the error budget of an SLO left after every run, violations in red
+function renderBurnDownChart(canvasId, slo) {
+    const canvas = document.getElementById(canvasId);
+    if (!canvas || !slo || !slo.Points) {
+        return null;
+    }
+    const pointColors = slo.Points.map(p => p.Violated ? seriesColors[0] : seriesColors[2]);
+    return new Chart(canvas.getContext('2d'), {
+        type: 'line',
+        data: {
+            labels: slo.Points.map(p => '#' + p.Seq + ' ' + new Date(p.Timestamp).toLocaleDateString()),
+            datasets: [{
+                label: 'Remaining budget (runs)',
+                data: slo.Points.map(p => p.Remaining),
+                borderColor: seriesColors[1],
+                backgroundColor: seriesColors[1],
+                pointBackgroundColor: pointColors,
+                pointBorderColor: pointColors,
+                pointRadius: 4,
+                stepped: true,
+                fill: false
+            }]
+        },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            plugins: {
+                tooltip: {
+                    callbacks: {
+                        label: function(context) {
+                            const point = slo.Points[context.dataIndex];
+                            return slo.Percentile + ' ' + point.Value.toFixed(0) + ' ms' + (point.Violated ? ' over ' : ' within ') +
+                                slo.Threshold + ' ms, ' + point.Remaining.toFixed(1) + ' runs of budget left';
+                        }
+                    }
+                }
+            },
+            scales: {
+                y: {
+                    suggestedMin: 0,
+                    suggestedMax: slo.Allowed,
+                    title: {
+                        display: true,
+                        text: 'Remaining budget (runs)'
+                    }
+                },
+                x: {
+                    ticks: {
+                        maxRotation: 45,
+                        minRotation: 0
+                    }
+                }
+            }
+        }
+    });
+}
+
+// Render the CDF and histogram of the latency distributions of several runs
+function renderDistributionCharts(cdfCanvasId, histogramCanvasId, set) {
+    if (!set || !set.Distributions) {
+        return;
+    }
+    const runLabel = d => d.Run + ' (n=' + d.Count + ')';
+
+    const cdfCanvas = document.getElementById(cdfCanvasId);
+    if (cdfCanvas) {
+        new Chart(cdfCanvas.getContext('2d'), {
+            type: 'scatter',
+            data: {
+                datasets: set.Distributions.map((d, i) => ({
+                    label: runLabel(d),
+                    data: d.CDF.map(p => ({ x: p.Latency, y: p.Fraction })),
+                    borderColor: seriesColors[i % seriesColors.length],
+                    backgroundColor: seriesColors[i % seriesColors.length],
+                    showLine: true,
+                    stepped: true,
+                    pointRadius: 0
+                }))
+            },
+            options: {
+                responsive: true,
+                maintainAspectRatio: false,
+                scales: {
+                    x: {
+                        title: {
+                            display: true,
+                            text: set.Field + ' (ms)'
+                        }
+                    },
+                    y: {
+                        min: 0,
+                        max: 1,
+                        title: {
+                            display: true,
+                            text: 'Fraction of samples'
+                        }
+                    }
+                }
+            }
+        });
+    }
+
+    const histogramCanvas = document.getElementById(histogramCanvasId);
+    if (histogramCanvas && set.BinEdges) {
+        const labels = set.BinEdges.slice(0, -1).map((edge, i) => Math.round(edge) + '-' + Math.round(set.BinEdges[i + 1]));
+        new Chart(histogramCanvas.getContext('2d'), {
+            type: 'bar',
+            data: {
+                labels: labels,
+                datasets: set.Distributions.map((d, i) => ({
+                    label: runLabel(d),
+                    data: d.Histogram,
+                    backgroundColor: seriesColors[i % seriesColors.length]
+                }))
+            },
+            options: {
+                responsive: true,
+                maintainAspectRatio: false,
+                scales: {
+                    x: {
+                        title: {
+                            display: true,
+                            text: set.Field + ' (ms)'
+                        }
+                    },
+                    y: {
+                        beginAtZero: true,
+                        title: {
+                            display: true,
+                            text: 'Samples'
+                        }
+                    }
+                }
+            }
+        });
+    }
+}
+
+// Render the box plot of every quantile of a metric across runs, whiskers and outliers come precomputed
+function renderBoxPlotChart(canvasId, set) {
+    const canvas = document.getElementById(canvasId);
+    if (!canvas || !set || !set.Boxes) {
+        return;
+    }
+    new Chart(canvas.getContext('2d'), {
+        type: 'boxplot',
+        data: {
+            labels: set.Boxes.map(b => b.QuantileName),
+            datasets: [{
+                label: set.MetricName + ' ' + set.Percentile,
+                data: set.Boxes.map(b => ({
+                    min: b.LowerWhisker,
+                    q1: b.Q1,
+                    median: b.Median,
+                    q3: b.Q3,
+                    max: b.UpperWhisker,
+                    outliers: (b.Outliers || []).map(o => o.Value)
+                })),
+                backgroundColor: seriesColors[0] + '40',
+                borderColor: seriesColors[0],
+                outlierBackgroundColor: seriesColors[1]
+            }]
+        },
+        options: {
+            responsive: true,
+            maintainAspectRatio: false,
+            scales: {
+                y: {
+                    beginAtZero: true,
+                    title: {
+                        display: true,
+                        text: 'Latency (ms)'
+                    }
+                }
+            }
+        }
+    });
+}
+
+// Render the run by quantile deviation matrix as a table, regressions in red and improvements in green
+function renderHeatmap(containerId, heatmap) {
+    const container = document.getElementById(containerId);
+    if (!container || !heatmap || !heatmap.Runs) {
+        return;
+    }
+    const table = document.createElement('table');
+    table.className = 'data-table heatmap-table';
+
+    const header = table.createTHead().insertRow();
+    header.appendChild(document.createElement('th')).textContent = 'Quantile';
+    header.appendChild(document.createElement('th')).textContent = 'Baseline';
+    heatmap.Runs.forEach(run => {
+        const th = document.createElement('th');
+        th.textContent = new Date(run.Timestamp).toLocaleDateString();
+        th.title = '#' + run.Seq + ' ' + run.Name + ' (' + run.UUID + ')';
+        header.appendChild(th);
+    });
+
+    const body = table.createTBody();
+    (heatmap.Rows || []).forEach(row => {
+        const tr = body.insertRow();
+        tr.insertCell().textContent = row.MetricName + ' / ' + row.QuantileName;
+        tr.insertCell().textContent = Math.round(row.BaselineValue) + ' ms';
+        row.Deviations.forEach((deviation, i) => {
+            const td = tr.insertCell();
+            td.className = 'heatmap-cell';
+            if (deviation === null) {
+                td.textContent = '-';
+                return;
+            }
+            // Saturate at 50% so small deviations remain visible
+            const intensity = Math.min(Math.abs(deviation) / 50, 1);
+            const rgb = deviation > 0 ? '238, 0, 0' : '62, 134, 53';
+            td.style.background = 'rgba(' + rgb + ', ' + intensity.toFixed(2) + ')';
+            td.textContent = (deviation > 0 ? '+' : '') + deviation.toFixed(1) + '%';
+            td.title = heatmap.Runs[i].Name;
+        });
+    });
+    container.appendChild(table);
+}
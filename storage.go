@@ -0,0 +1,196 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Remote results are polled for changes at this interval unless -refresh-interval is given
+const defaultRemoteRefreshInterval = 5 * time.Minute
+
+// resultsStorage is the backend results are read from. Paths are the ones built by joining the results root
+// returned by openResults with job, workload, run and file names
+type resultsStorage interface {
+	ReadDir(name string) ([]fs.DirEntry, error)
+	ReadFile(name string) ([]byte, error)
+}
+
+var errRemoteResults = errors.New("remote results are read-only")
+
+// refresher is implemented by the backends that need to sync their contents before the results are rescanned
+type refresher interface {
+	refresh() error
+}
+
+// storage is the backend holding the results, selected by openResults
+var storage resultsStorage = localStorage{}
+
+// openResults selects the storage backend from the scheme of the results location and returns the results root
+// within it. Locations without a scheme are local paths
+func openResults(location string) (string, error) {
+	u, err := url.Parse(location)
+	if err != nil || len(u.Scheme) <= 1 {
+		storage = localStorage{}
+		return location, nil
+	}
+	var s resultsStorage
+	switch u.Scheme {
+	case "file":
+		storage = localStorage{}
+		return u.Path, nil
+	case "oci":
+		// Artifacts are extracted to the local cache directory, parsed runs aren't worth persisting
+		s, err := newOCIStorage(strings.TrimPrefix(location, "oci://"))
+		if err != nil {
+			return "", err
+		}
+		storage = s
+		return "/", nil
+	case "http", "https":
+		s, err = newHTTPStorage(u)
+	case "sftp":
+		s, err = newSFTPStorage(u)
+	case "webdav", "webdavs":
+		s, err = newWebDAVStorage(u)
+	case "az":
+		s, err = newAzureStorage(u)
+	case "gs":
+		s, err = newGCSStorage(u)
+	default:
+		return "", fmt.Errorf("unsupported results location %s", location)
+	}
+	if err != nil {
+		return "", err
+	}
+	storage = s
+	// Persist the parsed runs, so restarts don't download unchanged runs again
+	if err := runsCache.openDiskCache(u.Redacted()); err != nil {
+		fmt.Fprintln(loadLog, "Persistent run cache disabled:", err)
+	}
+	return remoteRoot(u), nil
+}
+
+// remoteRoot returns the path of the results root of a remote location
+func remoteRoot(u *url.URL) string {
+	return path.Join("/", u.Path)
+}
+
+// localResults returns true when results are read from the local filesystem, the only backend that can be
+// watched for changes and written to
+func localResults() bool {
+	_, ok := storage.(localStorage)
+	return ok
+}
+
+// validName reports whether the job, workload or run name is a single path element, which can't escape the results
+// root once joined to it
+func validName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// resultsPath joins the job, workload and run names to the results root, failing when any of them isn't a valid name
+// or the path isn't under the root
+func resultsPath(root string, names ...string) (string, error) {
+	for _, name := range names {
+		if !validName(name) {
+			return "", fmt.Errorf("invalid name %q", name)
+		}
+	}
+	joined := filepath.Join(append([]string{root}, names...)...)
+	if rel, err := filepath.Rel(root, joined); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside of the results directory", joined)
+	}
+	return joined, nil
+}
+
+// checkPathNames rejects the requests whose job, workload or run path values aren't valid names, before the handler
+// joins them to the results directory
+func (c *Config) checkPathNames(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var names []string
+		for _, key := range []string{"job", "workload", "run"} {
+			if name := r.PathValue(key); name != "" || strings.Contains(r.Pattern, "{"+key+"}") {
+				names = append(names, name)
+			}
+		}
+		if _, err := resultsPath(c.resultsDir, names...); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// localStorage reads results from the local filesystem
+type localStorage struct{}
+
+func (localStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(name)
+}
+
+func (localStorage) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// remoteEntry is a file or directory listed by a remote backend, size, modification time and ETag are only
+// known when the backend reports them
+type remoteEntry struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+	// etag identifies the content of the file, when the backend reports it
+	etag string
+}
+
+func (e remoteEntry) Name() string               { return e.name }
+func (e remoteEntry) IsDir() bool                { return e.dir }
+func (e remoteEntry) Type() fs.FileMode          { return e.Mode().Type() }
+func (e remoteEntry) Info() (fs.FileInfo, error) { return e, nil }
+func (e remoteEntry) Size() int64                { return e.size }
+func (e remoteEntry) ModTime() time.Time         { return e.modTime }
+func (e remoteEntry) Sys() any                   { return nil }
+
+func (e remoteEntry) Mode() fs.FileMode {
+	if e.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
+
+// objectPrefix returns the prefix of the objects under the given directory in object stores, where directories
+// are just prefixes ending in a slash
+func objectPrefix(name string) string {
+	prefix := objectName(name)
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "/"
+}
+
+// objectName returns the name of the object of the given path in object stores
+func objectName(name string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+}
+
+// sortedEntries returns the entries sorted by name, like os.ReadDir does. Cached listings are sorted as copies
+func sortedEntries(entries []remoteEntry) []fs.DirEntry {
+	entries = slices.Clone(entries)
+	slices.SortFunc(entries, func(a, b remoteEntry) int {
+		return strings.Compare(a.name, b.name)
+	})
+	dirEntries := make([]fs.DirEntry, len(entries))
+	for i, e := range entries {
+		dirEntries[i] = e
+	}
+	return dirEntries
+}
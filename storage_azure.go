@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+)
+
+// azureStorage reads results from an Azure Blob Storage container
+type azureStorage struct {
+	client *container.Client
+}
+
+// newAzureStorage reads results from a location such as az://container/path. As with the az CLI, the client is
+// built from AZURE_STORAGE_CONNECTION_STRING when set, otherwise the AZURE_STORAGE_ACCOUNT account is accessed
+// with the AZURE_STORAGE_KEY access key or, without one, with the default Azure credential chain: environment
+// service principals, workload and managed identities and the az CLI login
+func newAzureStorage(u *url.URL) (*azureStorage, error) {
+	containerName := u.Host
+	var client *container.Client
+	var err error
+	if connectionString := os.Getenv("AZURE_STORAGE_CONNECTION_STRING"); connectionString != "" {
+		client, err = container.NewClientFromConnectionString(connectionString, containerName, nil)
+	} else {
+		account := os.Getenv("AZURE_STORAGE_ACCOUNT")
+		if account == "" {
+			return nil, fmt.Errorf("AZURE_STORAGE_ACCOUNT or AZURE_STORAGE_CONNECTION_STRING is required to read %s", u.Redacted())
+		}
+		containerURL := fmt.Sprintf("https://%s.blob.core.windows.net/%s", account, containerName)
+		if key := os.Getenv("AZURE_STORAGE_KEY"); key != "" {
+			var cred *container.SharedKeyCredential
+			if cred, err = container.NewSharedKeyCredential(account, key); err == nil {
+				client, err = container.NewClientWithSharedKeyCredential(containerURL, cred, nil)
+			}
+		} else {
+			var cred *azidentity.DefaultAzureCredential
+			if cred, err = azidentity.NewDefaultAzureCredential(nil); err == nil {
+				client, err = container.NewClient(containerURL, cred, nil)
+			}
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error creating Azure Blob Storage client: %v", err)
+	}
+	s := &azureStorage{client: client}
+	if _, err := s.ReadDir(remoteRoot(u)); err != nil {
+		return nil, fmt.Errorf("error listing results at %s: %v", u.Redacted(), err)
+	}
+	return s, nil
+}
+
+func (s *azureStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	prefix := objectPrefix(name)
+	pager := s.client.NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{Prefix: &prefix})
+	var entries []remoteEntry
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range page.Segment.BlobPrefixes {
+			entries = append(entries, remoteEntry{name: strings.TrimSuffix(strings.TrimPrefix(*p.Name, prefix), "/"), dir: true})
+		}
+		for _, b := range page.Segment.BlobItems {
+			entry := remoteEntry{name: strings.TrimPrefix(*b.Name, prefix)}
+			if b.Properties != nil {
+				if b.Properties.ContentLength != nil {
+					entry.size = *b.Properties.ContentLength
+				}
+				if b.Properties.LastModified != nil {
+					entry.modTime = *b.Properties.LastModified
+				}
+				if b.Properties.ETag != nil {
+					entry.etag = string(*b.Properties.ETag)
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	// Object stores have no empty directories, a prefix without objects doesn't exist
+	if len(entries) == 0 && prefix != "" {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return sortedEntries(entries), nil
+}
+
+func (s *azureStorage) ReadFile(name string) ([]byte, error) {
+	resp, err := s.client.NewBlobClient(objectName(name)).DownloadStream(context.Background(), nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
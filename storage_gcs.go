@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"strings"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// gcsStorage reads results from a Google Cloud Storage bucket
+type gcsStorage struct {
+	bucket *gcs.BucketHandle
+}
+
+// newGCSStorage reads results from a location such as gs://bucket/path, authenticating with the application
+// default credentials: GOOGLE_APPLICATION_CREDENTIALS, the gcloud login or the metadata server. Public buckets
+// are read anonymously when there are no credentials
+func newGCSStorage(u *url.URL) (*gcsStorage, error) {
+	ctx := context.Background()
+	client, err := gcs.NewClient(ctx)
+	if err != nil {
+		if client, err = gcs.NewClient(ctx, option.WithoutAuthentication()); err != nil {
+			return nil, fmt.Errorf("error creating Google Cloud Storage client: %v", err)
+		}
+	}
+	s := &gcsStorage{bucket: client.Bucket(u.Host)}
+	if _, err := s.ReadDir(remoteRoot(u)); err != nil {
+		return nil, fmt.Errorf("error listing results at %s: %v", u.Redacted(), err)
+	}
+	return s, nil
+}
+
+func (s *gcsStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	prefix := objectPrefix(name)
+	query := &gcs.Query{Prefix: prefix, Delimiter: "/"}
+	if err := query.SetAttrSelection([]string{"Name", "Size", "Updated", "Etag"}); err != nil {
+		return nil, err
+	}
+	var entries []remoteEntry
+	it := s.bucket.Objects(context.Background(), query)
+	for {
+		attrs, err := it.Next()
+		if errors.Is(err, iterator.Done) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if attrs.Prefix != "" {
+			entries = append(entries, remoteEntry{name: strings.TrimSuffix(strings.TrimPrefix(attrs.Prefix, prefix), "/"), dir: true})
+			continue
+		}
+		entries = append(entries, remoteEntry{name: strings.TrimPrefix(attrs.Name, prefix), size: attrs.Size, modTime: attrs.Updated, etag: attrs.Etag})
+	}
+	// Object stores have no empty directories, a prefix without objects doesn't exist
+	if len(entries) == 0 && prefix != "" {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return sortedEntries(entries), nil
+}
+
+func (s *gcsStorage) ReadFile(name string) ([]byte, error) {
+	r, err := s.bucket.Object(objectName(name)).NewReader(context.Background())
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
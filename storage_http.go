@@ -0,0 +1,260 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Listings are reused for this long before being revalidated against the server
+	httpListingTTL = 30 * time.Second
+	httpTimeout    = time.Minute
+	// File at the results root listing every result file, used instead of the directory listings when present
+	httpManifestFile = "manifest.json"
+)
+
+var hrefPattern = regexp.MustCompile(`(?i)href\s*=\s*"([^"]*)"`)
+
+// httpStorage reads results published as web artifacts. Directories are listed from the manifest file at the
+// results root when there's one, or from the autoindex pages generated by the web server otherwise
+type httpStorage struct {
+	base     url.URL
+	root     string
+	client   *http.Client
+	manifest bool
+	mu       sync.Mutex
+	listings map[string]cachedListing
+}
+
+// cachedListing is a parsed listing along with the validators used to revalidate it
+type cachedListing struct {
+	value        any
+	etag         string
+	lastModified string
+	fetched      time.Time
+}
+
+// manifestEntry is a file listed in the manifest, its path is relative to the results root
+type manifestEntry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// autoindexEntry is an entry of the JSON listings generated by nginx with autoindex_format json
+type autoindexEntry struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	MTime string `json:"mtime"`
+	Size  int64  `json:"size"`
+}
+
+func newHTTPStorage(u *url.URL) (*httpStorage, error) {
+	s := &httpStorage{
+		base:     *u,
+		root:     remoteRoot(u),
+		client:   &http.Client{Timeout: httpTimeout},
+		manifest: true,
+		listings: make(map[string]cachedListing),
+	}
+	if _, err := s.index(); errors.Is(err, fs.ErrNotExist) {
+		s.manifest = false
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err := s.ReadDir(s.root); err != nil {
+		return nil, fmt.Errorf("error listing results at %s: %v", u.Redacted(), err)
+	}
+	return s, nil
+}
+
+// url returns the URL of the given path, directory URLs end with a slash as web servers expect
+func (s *httpStorage) url(name string, dir bool) *url.URL {
+	u := s.base
+	u.Path = path.Join("/", filepath.ToSlash(name))
+	u.RawPath = ""
+	if dir && !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+	return &u
+}
+
+func (s *httpStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	if s.manifest {
+		index, err := s.index()
+		if err != nil {
+			return nil, err
+		}
+		rel, err := filepath.Rel(s.root, name)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+		}
+		entries, ok := index[filepath.ToSlash(rel)]
+		if !ok {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+		}
+		return sortedEntries(entries), nil
+	}
+	listing, err := s.cachedGet(s.url(name, true), parseAutoindex)
+	if err != nil {
+		return nil, err
+	}
+	return sortedEntries(listing.([]remoteEntry)), nil
+}
+
+func (s *httpStorage) ReadFile(name string) ([]byte, error) {
+	u := s.url(name, false)
+	resp, err := s.client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, statusError(u, resp)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// index returns the entries of every directory listed in the manifest, keyed by their path relative to the root
+func (s *httpStorage) index() (map[string][]remoteEntry, error) {
+	index, err := s.cachedGet(s.url(path.Join(s.root, httpManifestFile), false), parseManifest)
+	if err != nil {
+		return nil, err
+	}
+	return index.(map[string][]remoteEntry), nil
+}
+
+// cachedGet fetches and parses a listing, reusing the cached one while it's fresh or the server reports it
+// hasn't been modified
+func (s *httpStorage) cachedGet(u *url.URL, parse func(contentType string, body []byte) (any, error)) (any, error) {
+	key := u.String()
+	s.mu.Lock()
+	cached, ok := s.listings[key]
+	s.mu.Unlock()
+	if ok && time.Since(cached.fetched) < httpListingTTL {
+		return cached.value, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case ok && resp.StatusCode == http.StatusNotModified:
+		cached.fetched = time.Now()
+	case resp.StatusCode == http.StatusOK:
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		value, err := parse(resp.Header.Get("Content-Type"), body)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", u.Redacted(), err)
+		}
+		cached = cachedListing{
+			value:        value,
+			etag:         resp.Header.Get("ETag"),
+			lastModified: resp.Header.Get("Last-Modified"),
+			fetched:      time.Now(),
+		}
+	default:
+		return nil, statusError(u, resp)
+	}
+	s.mu.Lock()
+	s.listings[key] = cached
+	s.mu.Unlock()
+	return cached.value, nil
+}
+
+func statusError(u *url.URL, resp *http.Response) error {
+	if resp.StatusCode == http.StatusNotFound {
+		return &fs.PathError{Op: strings.ToLower(resp.Request.Method), Path: u.Redacted(), Err: fs.ErrNotExist}
+	}
+	return fmt.Errorf("error fetching %s: %s", u.Redacted(), resp.Status)
+}
+
+// parseManifest builds the directory tree of the files listed in the manifest
+func parseManifest(_ string, body []byte) (any, error) {
+	var files []manifestEntry
+	if err := json.Unmarshal(body, &files); err != nil {
+		return nil, err
+	}
+	index := map[string][]remoteEntry{".": nil}
+	for _, file := range files {
+		name := path.Clean(strings.TrimPrefix(file.Path, "/"))
+		if name == "." || strings.HasPrefix(name, "..") {
+			continue
+		}
+		entry := remoteEntry{name: path.Base(name), size: file.Size, modTime: file.ModTime}
+		for dir := path.Dir(name); ; dir = path.Dir(dir) {
+			_, known := index[dir]
+			index[dir] = append(index[dir], entry)
+			if known || dir == "." {
+				break
+			}
+			entry = remoteEntry{name: path.Base(dir), dir: true}
+		}
+	}
+	return index, nil
+}
+
+// parseAutoindex parses the directory listings generated by web servers, either HTML pages linking to the
+// entries of the directory, where directory links end with a slash, or nginx JSON listings
+func parseAutoindex(contentType string, body []byte) (any, error) {
+	var entries []remoteEntry
+	if strings.Contains(contentType, "json") {
+		var listing []autoindexEntry
+		if err := json.Unmarshal(body, &listing); err != nil {
+			return nil, err
+		}
+		for _, e := range listing {
+			modTime, _ := http.ParseTime(e.MTime)
+			entries = append(entries, remoteEntry{name: e.Name, dir: e.Type == "directory", size: e.Size, modTime: modTime})
+		}
+		return entries, nil
+	}
+	seen := make(map[string]bool)
+	for _, match := range hrefPattern.FindAllSubmatch(body, -1) {
+		href := html.UnescapeString(string(match[1]))
+		// Skip sorting links, absolute links such as the parent directory, and links to other sites
+		if href == "" || strings.ContainsAny(href[:1], "?#/") || strings.Contains(href, "://") {
+			continue
+		}
+		name, err := url.PathUnescape(strings.TrimPrefix(href, "./"))
+		if err != nil {
+			continue
+		}
+		dir := strings.HasSuffix(name, "/")
+		name = strings.TrimSuffix(name, "/")
+		if name == "" || name == "." || name == ".." || strings.Contains(name, "/") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		entries = append(entries, remoteEntry{name: name, dir: dir})
+	}
+	return entries, nil
+}
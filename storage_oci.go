@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/file"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+)
+
+// ociStorage reads results shipped as an OCI artifact. The files of the artifact are extracted to a cache
+// directory per manifest digest, so unchanged artifacts are pulled once, and pulled again when the tag moves
+type ociStorage struct {
+	repo     *remote.Repository
+	ref      string
+	cacheDir string
+	mu       sync.RWMutex
+	// dir holds the files of the artifact currently pointed by the reference
+	dir string
+}
+
+// newOCIStorage pulls the artifact of a reference such as registry.example.com/org/perf-results:tag, using the
+// registry credentials of the docker configuration
+func newOCIStorage(reference string) (*ociStorage, error) {
+	repo, err := remote.NewRepository(reference)
+	if err != nil {
+		return nil, err
+	}
+	credStore, err := credentials.NewStoreFromDocker(credentials.StoreOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error loading registry credentials: %v", err)
+	}
+	repo.Client = &auth.Client{
+		Client:     retry.DefaultClient,
+		Cache:      auth.NewCache(),
+		Credential: credentials.Credential(credStore),
+	}
+	host, _, err := net.SplitHostPort(repo.Reference.Registry)
+	if err != nil {
+		host = repo.Reference.Registry
+	}
+	repo.PlainHTTP = host == "localhost" || net.ParseIP(host).IsLoopback()
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	s := &ociStorage{
+		repo:     repo,
+		ref:      repo.Reference.Reference,
+		cacheDir: filepath.Join(cacheDir, "ocp-perf-dash", "oci"),
+	}
+	if s.ref == "" {
+		s.ref = "latest"
+	}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// refresh resolves the reference and pulls its artifact when it isn't cached yet
+func (s *ociStorage) refresh() error {
+	ctx := context.Background()
+	desc, err := s.repo.Resolve(ctx, s.ref)
+	if err != nil {
+		return fmt.Errorf("error resolving %s:%s: %v", s.repo.Reference.Repository, s.ref, err)
+	}
+	dir := filepath.Join(s.cacheDir, desc.Digest.Encoded())
+	if _, err := os.Stat(dir); err != nil {
+		if err := s.pull(ctx, desc.Digest.String(), dir); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	s.dir = dir
+	s.mu.Unlock()
+	return nil
+}
+
+// pull extracts the files of the artifact to a temporary directory, which is renamed to dir once complete
+func (s *ociStorage) pull(ctx context.Context, digest, dir string) error {
+	if err := os.MkdirAll(s.cacheDir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(s.cacheDir, ".pull-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	store, err := file.New(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = oras.Copy(ctx, s.repo, digest, store, "", oras.DefaultCopyOptions)
+	store.Close()
+	if err != nil {
+		return fmt.Errorf("error pulling %s@%s: %v", s.repo.Reference.Repository, digest, err)
+	}
+	return os.Rename(tmp, dir)
+}
+
+// path maps a path under the results root to the extracted files
+func (s *ociStorage) path(name string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return filepath.Join(s.dir, strings.TrimPrefix(filepath.Clean(name), string(filepath.Separator)))
+}
+
+func (s *ociStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(s.path(name))
+}
+
+func (s *ociStorage) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(s.path(name))
+}
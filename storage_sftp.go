@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	// Maximum number of SFTP sessions opened concurrently over the SSH connection
+	sftpPoolSize    = 4
+	sftpDialTimeout = 30 * time.Second
+)
+
+// sftpStorage reads results from a host reachable over SSH. SFTP sessions are pooled over a single SSH
+// connection, which is dialed again when it breaks
+type sftpStorage struct {
+	addr   string
+	config *ssh.ClientConfig
+	mu     sync.Mutex
+	conn   *ssh.Client
+	idle   chan *sftp.Client
+	// slots limits the number of sessions in use
+	slots chan struct{}
+}
+
+// newSFTPStorage connects to the host of a location such as sftp://user@host:port/path, authenticating with the
+// keys of the SSH agent, the default private keys or the password in the location, and verifying the host key
+// against the known_hosts file
+func newSFTPStorage(u *url.URL) (*sftpStorage, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	hostKeyCallback, err := knownhosts.New(filepath.Join(home, ".ssh", "known_hosts"))
+	if err != nil {
+		return nil, fmt.Errorf("error loading known hosts: %v", err)
+	}
+	username := u.User.Username()
+	if username == "" {
+		current, err := user.Current()
+		if err != nil {
+			return nil, err
+		}
+		username = current.Username
+	}
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	s := &sftpStorage{
+		addr: net.JoinHostPort(u.Hostname(), port),
+		config: &ssh.ClientConfig{
+			User:            username,
+			Auth:            sshAuthMethods(u, home),
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         sftpDialTimeout,
+		},
+		idle:  make(chan *sftp.Client, sftpPoolSize),
+		slots: make(chan struct{}, sftpPoolSize),
+	}
+	if _, err := s.ReadDir(remoteRoot(u)); err != nil {
+		return nil, fmt.Errorf("error listing results at %s: %v", u.Redacted(), err)
+	}
+	return s, nil
+}
+
+func sshAuthMethods(u *url.URL, home string) []ssh.AuthMethod {
+	var methods []ssh.AuthMethod
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		if conn, err := net.Dial("unix", socket); err == nil {
+			methods = append(methods, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	var signers []ssh.Signer
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		key, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+		if err != nil {
+			continue
+		}
+		// Keys protected by a passphrase are only usable through the agent
+		if signer, err := ssh.ParsePrivateKey(key); err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	if len(signers) > 0 {
+		methods = append(methods, ssh.PublicKeys(signers...))
+	}
+	if password, ok := u.User.Password(); ok {
+		methods = append(methods, ssh.Password(password))
+	}
+	return methods
+}
+
+// withClient runs fn with a pooled session, sessions failing with anything but a file error are discarded along
+// with their connection
+func (s *sftpStorage) withClient(fn func(*sftp.Client) error) error {
+	s.slots <- struct{}{}
+	defer func() { <-s.slots }()
+	var client *sftp.Client
+	select {
+	case client = <-s.idle:
+	default:
+		var err error
+		if client, err = s.newClient(); err != nil {
+			return err
+		}
+	}
+	err := fn(client)
+	var status *sftp.StatusError
+	if err != nil && !errors.Is(err, fs.ErrNotExist) && !errors.Is(err, fs.ErrPermission) && !errors.As(err, &status) {
+		client.Close()
+		s.closeConn()
+		return err
+	}
+	s.idle <- client
+	return err
+}
+
+func (s *sftpStorage) newClient() (*sftp.Client, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := ssh.Dial("tcp", s.addr, s.config)
+		if err != nil {
+			return nil, err
+		}
+		s.conn = conn
+	}
+	client, err := sftp.NewClient(s.conn)
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return client, err
+}
+
+// closeConn closes the SSH connection so the next session dials a new one, idle sessions over it are dropped
+func (s *sftpStorage) closeConn() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	for {
+		select {
+		case client := <-s.idle:
+			client.Close()
+		default:
+			return
+		}
+	}
+}
+
+func (s *sftpStorage) ReadDir(name string) ([]fs.DirEntry, error) {
+	var entries []fs.DirEntry
+	err := s.withClient(func(client *sftp.Client) error {
+		infos, err := client.ReadDir(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		for _, info := range infos {
+			entries = append(entries, fs.FileInfoToDirEntry(info))
+		}
+		return nil
+	})
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return entries, err
+}
+
+func (s *sftpStorage) ReadFile(name string) ([]byte, error) {
+	var buf bytes.Buffer
+	err := s.withClient(func(client *sftp.Client) error {
+		f, err := client.Open(filepath.ToSlash(name))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = f.WriteTo(&buf)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
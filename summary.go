@@ -0,0 +1,325 @@
+package main
+
+import (
+	"cmp"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// summaryChartRuns is the number of runs charted by the summaries, up to the run summed up
+	summaryChartRuns = 30
+	// summaryTopMetrics is the number of metrics charted by the summaries of comparisons, the ones changing the most
+	summaryTopMetrics = 5
+)
+
+// Summary is a run, or the comparison of two runs, laid out as a self-contained HTML file, its styles inlined and its
+// charts embedded as data URLs, to attach to emails and bugs
+type Summary struct {
+	Job      string
+	Workload string
+	Run      SummaryRun
+	// Baseline is the run compared against, nil when summing a single run up
+	Baseline *SummaryRun
+	// Metrics and SLOs are the key metrics and SLO verdicts of a single run, as the latest run endpoint has them
+	Metrics []LatestMetric
+	SLOs    []LatestSLO
+	// Measurements are every measurement of a single run, by metric, kube-burner job and quantile
+	Measurements []Measurement
+	// Comparison compares the run against the baseline, its metrics changing the most first
+	Comparison *Comparison
+	Charts     []SummaryChart
+	// URL links the summary to its page in the dashboard, when the URL of the dashboard is known
+	URL       string
+	Generated time.Time
+}
+
+// SummaryRun is the run of a summary along with its job summary fields and metadata
+type SummaryRun struct {
+	Name    string
+	UUID    string
+	Alias   string
+	Seq     int
+	Started time.Time
+	Passed  bool
+	// Elapsed is the duration of the run in seconds
+	Elapsed  float64
+	Metadata map[string]any
+	Links    []RunLink
+}
+
+// SummaryChart is a chart of the summary, rendered server side as a PNG data URL
+type SummaryChart struct {
+	Title string
+	Image template.URL
+}
+
+func (c *Config) summaryRun(run Run) *SummaryRun {
+	return &SummaryRun{
+		Name:     run.Name(),
+		UUID:     run.UUID(),
+		Alias:    c.aliases.get(run.UUID()),
+		Seq:      run.Seq,
+		Started:  run.Started(),
+		Passed:   run.Summary.Passed,
+		Elapsed:  run.Summary.ElapsedTime,
+		Metadata: run.metadataFields(),
+		Links:    run.Links(),
+	}
+}
+
+// newSummary sums the run of the UUID of the workload up, compared against the run of baselineUUID when given. Links
+// to the dashboard are only added when its URL is given
+func (c *Config) newSummary(jobName, workloadName, uuid, baselineUUID, dashboardURL string) (Summary, error) {
+	workloadPath := filepath.Join(c.resultsDir, jobName, workloadName)
+	runs, err := loadRuns(workloadPath)
+	if err != nil {
+		return Summary{}, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	runIndex := func(uuid string) int {
+		return slices.IndexFunc(runs, func(run Run) bool { return run.UUID() == uuid })
+	}
+	current := runIndex(uuid)
+	if current < 0 {
+		return Summary{}, fmt.Errorf("run %s not found", uuid)
+	}
+	summary := Summary{
+		Job:       jobName,
+		Workload:  workloadName,
+		Run:       *c.summaryRun(runs[current]),
+		Generated: time.Now(),
+	}
+	dashboardURL = strings.TrimSuffix(dashboardURL, "/")
+	if baselineUUID == "" {
+		latest := c.latestRun(jobName, workloadName, runs[:current+1], summary.Generated)
+		summary.Metrics, summary.SLOs = latest.Metrics, latest.SLOs
+		summary.Measurements = slices.Clone(runs[current].Measurements)
+		slices.SortFunc(summary.Measurements, func(a, b Measurement) int {
+			return cmp.Or(strings.Compare(a.MetricName, b.MetricName), strings.Compare(a.JobName, b.JobName),
+				strings.Compare(a.QuantileName, b.QuantileName))
+		})
+		start, last := chartRange(current, -1, summaryChartRuns)
+		for _, metric := range summary.Metrics {
+			reference := math.NaN()
+			if metric.Median != 0 {
+				reference = metric.Median
+			}
+			image, err := metricChart(runs[start:last+1], metric.MetricName, metric.JobName, metric.Quantile, reference, true)
+			if err != nil {
+				return summary, fmt.Errorf("error rendering chart: %v", err)
+			}
+			name := metric.MetricName
+			if metric.JobName != "" {
+				name += " (" + metric.JobName + ")"
+			}
+			summary.Charts = append(summary.Charts, SummaryChart{
+				Title: fmt.Sprintf("%s %s P99 over %d runs, the dashed line being the median of the runs before", name, metric.Quantile, last+1-start),
+				Image: pngDataURL(image),
+			})
+		}
+		if dashboardURL != "" {
+			summary.URL = fmt.Sprintf("%s/job/%s/%s", dashboardURL, url.PathEscape(jobName), url.PathEscape(workloadName))
+		}
+		return summary, nil
+	}
+	baseline := runIndex(baselineUUID)
+	if baseline < 0 {
+		return summary, fmt.Errorf("run %s not found", baselineUUID)
+	}
+	summary.Baseline = c.summaryRun(runs[baseline])
+	snapshot, err := loadBaseline(workloadPath)
+	if err != nil {
+		return summary, err
+	}
+	opts := c.runPairOptions(baselineUUID, uuid)
+	comparison, err := compareRuns(runs, snapshot, opts)
+	if err != nil {
+		return summary, err
+	}
+	slices.SortStableFunc(comparison.Metrics, func(a, b MetricComparison) int {
+		return cmp.Compare(math.Abs(b.Delta), math.Abs(a.Delta))
+	})
+	summary.Comparison = &comparison
+	start, last := chartRange(current, baseline, summaryChartRuns)
+	for _, mc := range comparison.Metrics[:min(len(comparison.Metrics), summaryTopMetrics)] {
+		image, err := metricChart(runs[start:last+1], mc.MetricName, "", mc.QuantileName, mc.Baseline.Mean, last == current)
+		if err != nil {
+			return summary, fmt.Errorf("error rendering chart: %v", err)
+		}
+		summary.Charts = append(summary.Charts, SummaryChart{
+			Title: fmt.Sprintf("%s %s P99 over the %d runs between both, the dashed line being the baseline", mc.MetricName, mc.QuantileName, last+1-start),
+			Image: pngDataURL(image),
+		})
+	}
+	if dashboardURL != "" {
+		summary.URL = dashboardURL + comparePermalink(baselineUUID, uuid, opts)
+	}
+	return summary, nil
+}
+
+// pngDataURL embeds the PNG image into a data URL
+func pngDataURL(image []byte) template.URL {
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(image))
+}
+
+// writeSummary renders the summary as a single HTML file, in the time zone given and the locale of the request, if any
+func (c *Config) writeSummary(w io.Writer, r *http.Request, loc *time.Location, summary Summary) error {
+	page, err := c.templates.page("summary.html")
+	if err != nil {
+		return err
+	}
+	t, err := page.Clone()
+	if err != nil {
+		return err
+	}
+	return t.Funcs(requestFuncs(c, r, loc)).ExecuteTemplate(w, "summary.html", summary)
+}
+
+// summaryHandler serves the summary of a run given by its UUID, or of the comparison of two runs given as
+// baseline...current UUIDs like the comparison permalinks
+func (c *Config) summaryHandler(w http.ResponseWriter, r *http.Request) {
+	runs := r.PathValue("runs")
+	jobs, err := c.visibleJobs(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	var location RunLocation
+	var baselineUUID string
+	if strings.Contains(runs, "...") {
+		baseline, current, status, err := findComparedRuns(jobs, runs)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		location, baselineUUID = current, baseline.Run.UUID()
+	} else {
+		var ok bool
+		if location, ok = findRun(jobs, runs); !ok {
+			http.Error(w, fmt.Sprintf("run %s not found", runs), http.StatusNotFound)
+			return
+		}
+	}
+	loc, err := c.timezone(w, r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	summary, err := c.newSummary(location.Job, location.Workload, location.Run.UUID(), baselineUUID, baseURL(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", summary.fileName()))
+	if err := c.writeSummary(w, r, loc, summary); err != nil {
+		fmt.Println("Error rendering summary:", err)
+	}
+}
+
+// fileName returns the name the summary is saved as, after the short UUIDs of its runs
+func (s Summary) fileName() string {
+	if s.Baseline != nil {
+		return fmt.Sprintf("%s-%s-vs-%s.html", s.Workload, shortUUID(s.Baseline.UUID), shortUUID(s.Run.UUID))
+	}
+	return fmt.Sprintf("%s-%s.html", s.Workload, shortUUID(s.Run.UUID))
+}
+
+// summaryCommand implements the summary subcommand, writing the summary of a run, or of its comparison against a
+// baseline run, to a single HTML file
+func summaryCommand(args []string) int {
+	flags := flag.NewFlagSet("summary", flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path or URL of the directory holding results")
+	baseline := flags.String("baseline", "", "UUID of the run to compare against, the run is summed up alone when empty")
+	output := flags.String("output", "", "Path of the HTML file to write, stdout when empty")
+	dashboardURL := flags.String("dashboard-url", "", "Base URL of the dashboard the summary links to, no links when empty")
+	aliasesFile := flags.String("aliases-file", "", "Path to the JSON file mapping run UUIDs to human names, as given to the dashboard")
+	timezone := flags.String("timezone", "", "Time zone timestamps are printed in, as recorded when empty")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: ocp-perf-dash summary [flags] <run-uuid>")
+		flags.PrintDefaults()
+	}
+	// Flags are accepted before and after the run UUID
+	var uuids []string
+	for {
+		flags.Parse(args)
+		if flags.NArg() == 0 {
+			break
+		}
+		uuids = append(uuids, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(uuids) != 1 {
+		flags.Usage()
+		return 2
+	}
+	loc, err := loadTimezone(*timezone)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	loadLog = os.Stderr
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	aliases, err := loadAliases(*aliasesFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	templates, err := loadPageTemplates("")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	c := newConfig(withResultsDir(root), withAliases(aliases), withTemplates(templates), withTimezone(loc))
+	jobs, err := loadJobs(root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading jobs:", err)
+		return 1
+	}
+	location, ok := findRun(jobs, uuids[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Run %s not found\n", uuids[0])
+		return 1
+	}
+	if *baseline != "" {
+		if _, _, _, err := findComparedRuns(jobs, *baseline+"..."+uuids[0]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	summary, err := c.newSummary(location.Job, location.Workload, location.Run.UUID(), *baseline, *dashboardURL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := c.writeSummary(w, nil, loc, summary); err != nil {
+		fmt.Fprintln(os.Stderr, "Error rendering summary:", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// viewTable is the view query parameter rendering the workload page as tables instead of charts
+const viewTable = "table"
+
+// Columns the tables can be sorted by, besides the percentiles
+const (
+	sortSeq     = "seq"
+	sortStarted = "started"
+)
+
+// TableView is the workload page rendered as plain tables of the datapoints of its charts, sorted on the server, for
+// screen readers, printing and browsers where the charts can't run
+type TableView struct {
+	Columns []TableColumn
+	Groups  []TableGroup
+	// ChartsURL is the page with its charts
+	ChartsURL template.URL
+}
+
+// TableColumn is a sortable column, URL sorting the tables by it, in the order opposite to the current one when
+// they're already sorted by it
+type TableColumn struct {
+	Label  string
+	URL    template.URL
+	Sorted bool
+	Desc   bool
+}
+
+// AriaSort returns the aria-sort attribute of the column header
+func (c TableColumn) AriaSort() string {
+	switch {
+	case !c.Sorted:
+		return "none"
+	case c.Desc:
+		return "descending"
+	default:
+		return "ascending"
+	}
+}
+
+// TableGroup holds the tables of a metric group, a table per quantile
+type TableGroup struct {
+	MetricName  string
+	JobName     string
+	Description string
+	Tables      []DatapointTable
+}
+
+// DatapointTable is the table of the datapoints of a quantile
+type DatapointTable struct {
+	QuantileName string
+	Description  string
+	Rows         []TableRow
+}
+
+// TableRow is a datapoint, its Values in the order of the percentile columns
+type TableRow struct {
+	Seq       int
+	Timestamp time.Time
+	Series    string
+	Runs      int
+	Values    []float64
+}
+
+// newTableView lays the metric groups out as tables, sorted by the column of the sort query parameter, the sequence
+// number by default, descending when order is desc
+func newTableView(groups []MetricGroup, opts ChartOptions, query url.Values) (*TableView, error) {
+	selected := opts.selectedPercentiles()
+	sortBy := strings.ToLower(cmp.Or(query.Get("sort"), sortSeq))
+	column := slices.IndexFunc(selected, func(p string) bool { return strings.EqualFold(p, sortBy) })
+	if column < 0 && sortBy != sortSeq && sortBy != sortStarted {
+		return nil, fmt.Errorf("invalid sort: must be %s, %s or one of %s", sortSeq, sortStarted, strings.Join(selected, ","))
+	}
+	if column >= 0 {
+		sortBy = selected[column]
+	}
+	var desc bool
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		desc = true
+	default:
+		return nil, fmt.Errorf("invalid order: must be asc or desc")
+	}
+
+	charts := maps.Clone(query)
+	for _, key := range []string{"view", "sort", "order"} {
+		charts.Del(key)
+	}
+	view := &TableView{ChartsURL: template.URL("?" + charts.Encode())}
+	for _, key := range append([]string{sortSeq, sortStarted}, selected...) {
+		sorted := key == sortBy
+		q := maps.Clone(query)
+		q.Set("sort", key)
+		q.Set("order", "asc")
+		if sorted && !desc {
+			q.Set("order", "desc")
+		}
+		label := key
+		switch key {
+		case sortSeq:
+			label = "Run"
+		case sortStarted:
+			label = "Started"
+		}
+		view.Columns = append(view.Columns, TableColumn{Label: label, URL: template.URL("?" + q.Encode()), Sorted: sorted, Desc: sorted && desc})
+	}
+
+	for _, group := range groups {
+		tableGroup := TableGroup{MetricName: group.MetricName, JobName: group.JobName, Description: group.Description}
+		for _, chart := range group.Charts {
+			table := DatapointTable{QuantileName: chart.QuantileName, Description: chart.Description}
+			for _, dp := range chart.Datapoints {
+				row := TableRow{Seq: dp.Seq, Timestamp: dp.Timestamp, Series: dp.Series, Runs: dp.Runs}
+				for _, percentile := range selected {
+					row.Values = append(row.Values, dp.value(percentile))
+				}
+				table.Rows = append(table.Rows, row)
+			}
+			slices.SortStableFunc(table.Rows, func(a, b TableRow) int {
+				var c int
+				switch {
+				case column >= 0:
+					c = cmp.Compare(a.Values[column], b.Values[column])
+				case sortBy == sortStarted:
+					c = a.Timestamp.Compare(b.Timestamp)
+				default:
+					c = cmp.Compare(a.Seq, b.Seq)
+				}
+				if desc {
+					return -c
+				}
+				return c
+			})
+			tableGroup.Tables = append(tableGroup.Tables, table)
+		}
+		view.Groups = append(view.Groups, tableGroup)
+	}
+	return view, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"text/template"
+	"time"
+)
+
+// shortUUIDLength is the number of characters of the UUIDs shortened by shortUUID, enough to tell the runs of a
+// workload apart
+const shortUUIDLength = 8
+
+// templateFuncs are the formatting helpers registered on every template parsed, so the templates don't print raw
+// float64s. The pages replace them with the ones of the locale of the request
+var templateFuncs = defaultLocale.funcs()
+
+// funcs returns the formatting helpers, formatting numbers in the locale
+func (l *Locale) funcs() template.FuncMap {
+	return template.FuncMap{
+		"humanizeMs":      l.humanizeMs,
+		"humanizeSeconds": l.humanizeSeconds,
+		"humanizeBytes":   l.humanizeBytes,
+		"signedPercent":   l.signedPercent,
+		"formatNumber":    l.formatNumber,
+		"deltaClass":      deltaClass,
+		"shortUUID":       shortUUID,
+		"cadenceText":     cadenceText,
+	}
+}
+
+// humanizeMs formats a duration in milliseconds with the unit fitting its magnitude, such as 850ms, 1.25s, 12.3s or
+// 4m05s
+func (l *Locale) humanizeMs(ms float64) string {
+	return l.humanizeDuration(time.Duration(math.Round(ms * float64(time.Millisecond))))
+}
+
+// humanizeSeconds formats a duration in seconds like humanizeMs
+func (l *Locale) humanizeSeconds(seconds float64) string {
+	return l.humanizeDuration(time.Duration(math.Round(seconds * float64(time.Second))))
+}
+
+func (l *Locale) humanizeDuration(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	switch {
+	case d == 0:
+		return "0ms"
+	case d < time.Millisecond:
+		return l.sprintf("%s%.2fms", sign, float64(d)/float64(time.Millisecond))
+	case d < time.Second:
+		return l.sprintf("%s%.0fms", sign, float64(d)/float64(time.Millisecond))
+	case d < 10*time.Second:
+		return l.sprintf("%s%.2fs", sign, d.Seconds())
+	case d < time.Minute:
+		return l.sprintf("%s%.1fs", sign, d.Seconds())
+	case d < time.Hour:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%s%dm%02ds", sign, int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%s%dh%02dm", sign, int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// humanizeBytes formats a size in bytes with binary units, such as 512 B or 1.5 MiB
+func (l *Locale) humanizeBytes(size int64) string {
+	const unit = 1024
+	if size < unit && size > -unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exp := float64(size), 0
+	for math.Abs(value) >= unit && exp < 6 {
+		value /= unit
+		exp++
+	}
+	return l.sprintf("%.1f %ciB", value, "KMGTPE"[exp-1])
+}
+
+// signedPercent formats a percent delta with its sign, such as +3.2% or -0.5%
+func (l *Locale) signedPercent(delta float64) string {
+	if math.Round(delta*10) == 0 {
+		delta = 0
+	}
+	return l.sprintf("%+.1f%%", delta)
+}
+
+// formatNumber formats the number with the given decimals, and the separators of the locale
+func (l *Locale) formatNumber(value float64, decimals int) string {
+	return l.sprintf("%.*f", decimals, value)
+}
+
+// deltaClass returns the class coloring a percent delta of a latency, delta-worse when it rose, delta-better when it
+// fell and delta-flat when it rounds to zero
+func deltaClass(delta float64) string {
+	switch {
+	case math.Round(delta*10) > 0:
+		return "delta-worse"
+	case math.Round(delta*10) < 0:
+		return "delta-better"
+	default:
+		return "delta-flat"
+	}
+}
+
+// shortUUID returns the first characters of a UUID, the full UUID being left to titles
+func shortUUID(uuid string) string {
+	if len(uuid) <= shortUUIDLength {
+		return uuid
+	}
+	return uuid[:shortUUIDLength]
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"time"
+)
+
+// layoutTemplate is the template the pages are rendered with, laying out the blocks they define: title, head,
+// heading, back, content and scripts
+const layoutTemplate = "layout"
+
+// pageTemplates are the templates of the pages, each parsed along the layout and the partials
+type pageTemplates struct {
+	// dir is the directory the templates are read from on every render in development, empty when they're parsed
+	// once from the embedded ones
+	dir    string
+	parsed *parsedTemplates
+}
+
+type parsedTemplates struct {
+	// partials are the layout and the partials, which the fragments are rendered from
+	partials *template.Template
+	pages    map[string]*template.Template
+}
+
+// loadPageTemplates parses the embedded templates, or checks the ones of dir parse when it's set, since they're
+// parsed again on every render so edits show without restarting the server
+func loadPageTemplates(dir string) (*pageTemplates, error) {
+	templates := &pageTemplates{dir: dir}
+	parsed, err := templates.parse()
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		templates.parsed = parsed
+	}
+	return templates, nil
+}
+
+// parse parses every page of the templates directory over its own copy of the layout and partials, so the pages
+// define the blocks of the layout independently
+func (p *pageTemplates) parse() (*parsedTemplates, error) {
+	var fsys fs.FS = os.DirFS(p.dir)
+	if p.dir == "" {
+		var err error
+		if fsys, err = fs.Sub(templateFiles, "templates"); err != nil {
+			return nil, err
+		}
+	}
+	partials, err := template.New(layoutTemplate).Funcs(requestFuncs(nil, nil, time.UTC)).ParseFS(fsys, "layout.html", "partials/*.html")
+	if err != nil {
+		return nil, err
+	}
+	names, err := fs.Glob(fsys, "*.html")
+	if err != nil {
+		return nil, err
+	}
+	parsed := &parsedTemplates{partials: partials, pages: make(map[string]*template.Template)}
+	for _, name := range names {
+		if name == "layout.html" {
+			continue
+		}
+		page, err := partials.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if parsed.pages[name], err = page.ParseFS(fsys, name); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+// templates returns the parsed templates, parsing them again from the directory in development
+func (p *pageTemplates) templates() (*parsedTemplates, error) {
+	if p.dir != "" {
+		return p.parse()
+	}
+	return p.parsed, nil
+}
+
+// page returns the template of the page
+func (p *pageTemplates) page(name string) (*template.Template, error) {
+	parsed, err := p.templates()
+	if err != nil {
+		return nil, err
+	}
+	t, ok := parsed.pages[name]
+	if !ok {
+		return nil, fmt.Errorf("template %s not found", name)
+	}
+	return t, nil
+}
+
+// fragment returns the templates holding the partial, rendered alone as a fragment of a page
+func (p *pageTemplates) fragment(name string) (*template.Template, error) {
+	parsed, err := p.templates()
+	if err != nil {
+		return nil, err
+	}
+	if parsed.partials.Lookup(name) == nil {
+		return nil, fmt.Errorf("partial %s not found", name)
+	}
+	return parsed.partials, nil
+}
+
+// requestFuncs returns the helpers of the templates bound to the request: formatTime formats timestamps in the time
+// zone and locale selected for it, the formatting helpers format numbers in that locale, shared hides the actions a
+// share link doesn't allow and theme picks the theme of the user. The templates are parsed with placeholders of
+// them, replaced on every render
+func requestFuncs(c *Config, r *http.Request, loc *time.Location) template.FuncMap {
+	locale := c.locale(r)
+	funcs := template.FuncMap{
+		"formatTime": func(t time.Time, layout string) string {
+			return locale.formatTime(t, loc, layout)
+		},
+		"shared": func() bool {
+			return sharedRequest(r)
+		},
+		"theme": func() string {
+			return c.preferences(r).Theme
+		},
+		"runAlias": func(uuid string) string {
+			return c.aliases.get(uuid)
+		},
+	}
+	maps.Copy(funcs, locale.funcs())
+	return funcs
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Policy maps users and groups to the jobs they're allowed to access. Identities are taken from the
+// headers set by the authenticating proxy sitting in front of the dashboard, or by the dashboard itself for the users
+// logged in through LDAP and the clients presenting certificates
+type Policy struct {
+	UserHeader   string            `yaml:"userHeader"`
+	GroupsHeader string            `yaml:"groupsHeader"`
+	Tenants      []Tenant          `yaml:"tenants"`
+	Admins       Admins            `yaml:"admins"`
+	LDAP         *LDAPConfig       `yaml:"ldap"`
+	ClientCerts  *ClientCertConfig `yaml:"clientCerts"`
+}
+
+// Admins are the users and groups allowed to access the admin page
+type Admins struct {
+	Users  []string `yaml:"users"`
+	Groups []string `yaml:"groups"`
+}
+
+// Tenant grants its users and groups access to the jobs matching any of its patterns
+type Tenant struct {
+	Name   string   `yaml:"name"`
+	Users  []string `yaml:"users"`
+	Groups []string `yaml:"groups"`
+	Jobs   []string `yaml:"jobs"`
+}
+
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+func loadPolicy(policyPath string) (*Policy, error) {
+	data, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	policy := Policy{
+		UserHeader:   "X-Forwarded-User",
+		GroupsHeader: "X-Forwarded-Groups",
+	}
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("error parsing policy file %s: %v", policyPath, err)
+	}
+	for _, tenant := range policy.Tenants {
+		for _, pattern := range tenant.Jobs {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("invalid job pattern %q in tenant %s: %v", pattern, tenant.Name, err)
+			}
+		}
+	}
+	if policy.LDAP != nil {
+		if err := policy.LDAP.validate(); err != nil {
+			return nil, fmt.Errorf("error in policy file %s: %v", policyPath, err)
+		}
+	}
+	if policy.ClientCerts != nil {
+		if err := policy.ClientCerts.validate(); err != nil {
+			return nil, fmt.Errorf("error in policy file %s: %v", policyPath, err)
+		}
+	}
+	return &policy, nil
+}
+
+func (p *Policy) identity(r *http.Request) Identity {
+	id := Identity{
+		User: r.Header.Get(p.UserHeader),
+	}
+	for _, header := range r.Header.Values(p.GroupsHeader) {
+		id.Groups = append(id.Groups, splitList(header)...)
+	}
+	return id
+}
+
+// jobAllowed returns true when any of the tenants the identity belongs to matches the job. A user
+// pattern of "*" matches any authenticated user
+func (p *Policy) jobAllowed(id Identity, job string) bool {
+	if id.User == "" {
+		return false
+	}
+	for _, tenant := range p.Tenants {
+		member := slices.Contains(tenant.Users, id.User) || slices.Contains(tenant.Users, "*") ||
+			slices.ContainsFunc(id.Groups, func(group string) bool { return slices.Contains(tenant.Groups, group) })
+		if !member {
+			continue
+		}
+		for _, pattern := range tenant.Jobs {
+			if matched, _ := path.Match(pattern, job); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// jobAllowed returns true when the client of the request can access the given job, every job is
+// accessible when no policy is configured, and through share links
+func (c *Config) jobAllowed(r *http.Request, job string) bool {
+	if c.policy == nil || sharedRequest(r) {
+		return true
+	}
+	return c.policy.jobAllowed(c.policy.identity(r), job)
+}
+
+// visibleJobs loads the jobs the client of the request is allowed to access, all of them through share links
+func (c *Config) visibleJobs(r *http.Request) ([]Job, error) {
+	jobs, err := loadJobs(c.resultsDir)
+	if err != nil || c.policy == nil || sharedRequest(r) {
+		return jobs, err
+	}
+	id := c.policy.identity(r)
+	return slices.DeleteFunc(jobs, func(job Job) bool {
+		return !c.policy.jobAllowed(id, job.Name)
+	}), nil
+}
+
+func jobForbidden(w http.ResponseWriter, job string) {
+	http.Error(w, fmt.Sprintf("access to job %s denied", job), http.StatusForbidden)
+}
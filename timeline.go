@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/kube-burner/kube-burner/v2/pkg/burner"
+)
+
+// Kinds of the spans of a run timeline
+const (
+	// spanPhase is the execution of a kube-burner job
+	spanPhase = "phase"
+	// spanChurn is the churn of a kube-burner job, within its phase
+	spanChurn = "churn"
+	// spanGap is the time between two phases, spent e.g. waiting for the garbage collection or running the
+	// measurements
+	spanGap = "gap"
+)
+
+// TimelineSpan is a bar of the timeline, its offset and duration are in seconds from the start of the run
+type TimelineSpan struct {
+	Name     string
+	Kind     string
+	Start    time.Time
+	End      time.Time
+	Offset   float64
+	Duration float64
+	Passed   bool
+	// Left and Width place the bar on the timeline, as percentages of the run duration
+	Left  float64
+	Width float64
+}
+
+// Timeline breaks down the wall-clock time of a run into the phases it executed
+type Timeline struct {
+	UUID     string
+	Seq      int
+	Start    time.Time
+	End      time.Time
+	Duration float64
+	Spans    []TimelineSpan
+	// Links of the run into related systems
+	Links []RunLink `json:",omitempty"`
+}
+
+// summaryEnd returns the end of the kube-burner job, computed from its elapsed time when the end timestamp is missing
+func summaryEnd(summary burner.JobSummary) time.Time {
+	if !summary.EndTimestamp.IsZero() {
+		return summary.EndTimestamp
+	}
+	return summary.Timestamp.Add(time.Duration(summary.ElapsedTime * float64(time.Second)))
+}
+
+// runTimeline lays the kube-burner jobs of the run out on a timeline, along with their churn and the gaps between them
+func runTimeline(run Run) (Timeline, error) {
+	timeline := Timeline{
+		UUID:  run.UUID(),
+		Seq:   run.Seq,
+		Links: run.Links(),
+	}
+	var summaries []burner.JobSummary
+	for _, summary := range run.Summaries {
+		if !summary.Timestamp.IsZero() {
+			summaries = append(summaries, summary)
+		}
+	}
+	if len(summaries) == 0 {
+		return timeline, fmt.Errorf("run %s has no job summary timestamps", run.Name())
+	}
+	slices.SortStableFunc(summaries, func(a, b burner.JobSummary) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	timeline.Start = summaries[0].Timestamp
+	var spans []TimelineSpan
+	for i, summary := range summaries {
+		end := summaryEnd(summary)
+		if i > 0 {
+			if previous := summaryEnd(summaries[i-1]); summary.Timestamp.After(previous) {
+				spans = append(spans, TimelineSpan{Name: "idle", Kind: spanGap, Start: previous, End: summary.Timestamp})
+			}
+		}
+		spans = append(spans, TimelineSpan{
+			Name:   summary.JobConfig.Name,
+			Kind:   spanPhase,
+			Start:  summary.Timestamp,
+			End:    end,
+			Passed: summary.Passed,
+		})
+		if summary.ChurnStartTimestamp != nil && summary.ChurnEndTimestamp != nil {
+			spans = append(spans, TimelineSpan{
+				Name:   summary.JobConfig.Name + " churn",
+				Kind:   spanChurn,
+				Start:  *summary.ChurnStartTimestamp,
+				End:    *summary.ChurnEndTimestamp,
+				Passed: summary.Passed,
+			})
+		}
+		if end.After(timeline.End) {
+			timeline.End = end
+		}
+	}
+	timeline.Duration = timeline.End.Sub(timeline.Start).Seconds()
+	for i := range spans {
+		spans[i].Offset = spans[i].Start.Sub(timeline.Start).Seconds()
+		spans[i].Duration = spans[i].End.Sub(spans[i].Start).Seconds()
+		if timeline.Duration > 0 {
+			spans[i].Left = 100 * spans[i].Offset / timeline.Duration
+			spans[i].Width = 100 * spans[i].Duration / timeline.Duration
+		}
+	}
+	timeline.Spans = spans
+	return timeline, nil
+}
+
+// loadTimeline builds the timeline of the run of the uuid query parameter, the most recent run of the workload when
+// not given. The runs are returned as well, for the run selector
+func (c *Config) loadTimeline(r *http.Request, jobName, workloadName string) (Timeline, []Run, int, error) {
+	runs, err := loadRuns(filepath.Join(c.resultsDir, jobName, workloadName))
+	if err != nil {
+		return Timeline{}, nil, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, workloadName)
+	}
+	runs = ChartOptions{}.filterRuns(runs)
+	if len(runs) == 0 {
+		return Timeline{}, runs, http.StatusNotFound, fmt.Errorf("workload %s/%s has no runs", jobName, workloadName)
+	}
+	run, err := selectRun(runs, r.URL.Query().Get("uuid"))
+	if err != nil {
+		return Timeline{}, runs, http.StatusNotFound, err
+	}
+	timeline, err := runTimeline(run)
+	if err != nil {
+		return timeline, runs, http.StatusNotFound, err
+	}
+	return timeline, runs, http.StatusOK, nil
+}
+
+func (c *Config) apiTimelineHandler(w http.ResponseWriter, r *http.Request) {
+	jobName, workloadName := r.PathValue("job"), r.PathValue("workload")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	timeline, _, status, err := c.loadTimeline(r, jobName, workloadName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, timeline)
+}
+
+func (c *Config) timelineHandler(w http.ResponseWriter, r *http.Request, jobName, workloadName string) {
+	timeline, runs, _, err := c.loadTimeline(r, jobName, workloadName)
+	type TemplateData struct {
+		JobName      string
+		WorkloadName string
+		Timeline     Timeline
+		Runs         []Run
+		Error        string
+	}
+	data := TemplateData{
+		JobName:      jobName,
+		WorkloadName: workloadName,
+		Timeline:     timeline,
+		Runs:         runs,
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "timeline.html", data)
+}
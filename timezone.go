@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+	// Embed the time zone database, container images don't necessarily ship one
+	_ "time/tzdata"
+)
+
+// timezoneCookie remembers the time zone picked with the tz query parameter, so it applies to every page
+const timezoneCookie = "tz"
+
+// loadTimezone loads the given IANA time zone, such as Europe/Madrid, an empty name is nil so timestamps are
+// shown as recorded
+func loadTimezone(name string) (*time.Location, error) {
+	if name == "" {
+		return nil, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone %s", name)
+	}
+	return loc, nil
+}
+
+// timezone returns the time zone requested with the tz query parameter, remembering it in a cookie, or the one
+// previously picked by the user, falling back to the one configured with -timezone
+func (c *Config) timezone(w http.ResponseWriter, r *http.Request) (*time.Location, error) {
+	if name, ok := r.URL.Query()["tz"]; ok {
+		loc, err := loadTimezone(name[0])
+		if err != nil {
+			return nil, err
+		}
+		// An empty tz goes back to the configured time zone
+		cookie := &http.Cookie{Name: timezoneCookie, Value: name[0], Path: "/", SameSite: http.SameSiteLaxMode}
+		if name[0] == "" {
+			cookie.MaxAge = -1
+			loc = c.location
+		}
+		http.SetCookie(w, cookie)
+		return loc, nil
+	}
+	if cookie, err := r.Cookie(timezoneCookie); err == nil {
+		if loc, err := loadTimezone(cookie.Value); err == nil && loc != nil {
+			return loc, nil
+		}
+	}
+	return c.location, nil
+}
+
+// formatTime formats the timestamp in the given time zone, or as recorded when nil
+func formatTime(t time.Time, loc *time.Location, layout string) string {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(layout)
+}
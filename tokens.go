@@ -0,0 +1,315 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// tokenPrefix starts the API tokens, so they're told apart from the bearer tokens meant for the authenticating proxy
+const tokenPrefix = "opd_"
+
+// Scopes of the API tokens
+const (
+	// scopeReadOnly tokens browse the API like read-only users
+	scopeReadOnly = "read-only"
+	// scopeUpload tokens can only send data to the API, they can't read anything
+	scopeUpload = "upload"
+)
+
+var tokenScopes = []string{scopeReadOnly, scopeUpload}
+
+// APIToken is a token granting its scope to API clients, such as CI pipelines, each getting their own
+type APIToken struct {
+	Name  string `json:"name"`
+	Scope string `json:"scope"`
+	// Hash is the SHA-256 of the token, the token itself is only shown when created
+	Hash      string    `json:"hash"`
+	CreatedBy string    `json:"createdBy,omitempty"`
+	Created   time.Time `json:"created"`
+	// Expires is when the token stops being accepted, never when zero
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+// User is the identity of the token in the policy
+func (t APIToken) User() string {
+	return "token:" + t.Name
+}
+
+// tokenStore keeps the API tokens, persisted to a JSON file when configured and kept in memory otherwise. The file is
+// read again when it changes, so the tokens managed with the token subcommand apply without restarting
+type tokenStore struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	tokens  map[string]APIToken
+}
+
+func loadTokens(path string) (*tokenStore, error) {
+	store := &tokenStore{path: path, tokens: make(map[string]APIToken)}
+	if err := store.reload(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// reload reads the tokens file again when it changed since it was last read
+func (s *tokenStore) reload() error {
+	if s.path == "" {
+		return nil
+	}
+	info, err := os.Stat(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	tokens := make(map[string]APIToken)
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return fmt.Errorf("error parsing tokens file %s: %v", s.path, err)
+	}
+	s.tokens, s.modTime = tokens, info.ModTime()
+	return nil
+}
+
+// save replaces the tokens file atomically
+func (s *tokenStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return err
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime = info.ModTime()
+	}
+	return nil
+}
+
+// list returns the tokens sorted by name
+func (s *tokenStore) list() ([]APIToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	return slices.SortedFunc(maps.Values(s.tokens), func(a, b APIToken) int { return strings.Compare(a.Name, b.Name) }), nil
+}
+
+// create generates a token of the scope under the name, returning the token, which isn't stored. It expires after the
+// expiry, never when zero
+func (s *tokenStore) create(name, scope, createdBy string, expiry time.Duration) (string, error) {
+	if !viewNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid name %q: only letters, digits, dots, dashes and underscores are allowed", name)
+	}
+	if !slices.Contains(tokenScopes, scope) {
+		return "", fmt.Errorf("invalid scope %q, must be %s", scope, strings.Join(tokenScopes, " or "))
+	}
+	if expiry < 0 {
+		return "", fmt.Errorf("invalid expiry %s", expiry)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	token := tokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return "", err
+	}
+	if _, ok := s.tokens[name]; ok {
+		return "", fmt.Errorf("token %s already exists, revoke it first", name)
+	}
+	t := APIToken{Name: name, Scope: scope, Hash: hashToken(token), CreatedBy: createdBy, Created: time.Now().UTC()}
+	if expiry > 0 {
+		t.Expires = t.Created.Add(expiry)
+	}
+	s.tokens[name] = t
+	if err := s.save(); err != nil {
+		delete(s.tokens, name)
+		return "", err
+	}
+	return token, nil
+}
+
+// revoke deletes the token of the name
+func (s *tokenStore) revoke(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		return err
+	}
+	t, ok := s.tokens[name]
+	if !ok {
+		return fmt.Errorf("token %s not found", name)
+	}
+	delete(s.tokens, name)
+	if err := s.save(); err != nil {
+		s.tokens[name] = t
+		return err
+	}
+	return nil
+}
+
+// lookup returns the unexpired token matching the one presented by a client
+func (s *tokenStore) lookup(token string) (APIToken, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.reload(); err != nil {
+		fmt.Println("Error reading tokens:", err)
+	}
+	hash := hashToken(token)
+	for _, t := range s.tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Hash), []byte(hash)) == 1 {
+			return t, t.Expires.IsZero() || time.Now().Before(t.Expires)
+		}
+	}
+	return APIToken{}, false
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// tokenSession returns the session of the API token the request carries as a bearer token, if any. Invalid and
+// expired tokens fail, other bearer tokens being left to the authenticating proxy
+func (c *Config) tokenSession(r *http.Request) (Session, bool, error) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || !strings.HasPrefix(token, tokenPrefix) {
+		return Session{}, false, nil
+	}
+	t, ok := c.tokens.lookup(token)
+	if !ok {
+		return Session{}, false, errors.New("invalid or expired API token")
+	}
+	session := Session{User: t.User(), Role: roleReadOnly}
+	if t.Scope == scopeUpload {
+		session.Role = roleUpload
+	}
+	return session, true, nil
+}
+
+// uploadAllowed reports whether the request only sends data to the API, the requests upload tokens are allowed
+func uploadAllowed(r *http.Request) bool {
+	return (r.Method == http.MethodPost || r.Method == http.MethodPut) && strings.HasPrefix(r.URL.Path, "/api/")
+}
+
+// adminTokenCreateHandler creates the token of the form, and shows it on the admin page, the only time it's shown
+func (c *Config) adminTokenCreateHandler(w http.ResponseWriter, r *http.Request) {
+	var expiry time.Duration
+	if e := r.FormValue("expires"); e != "" {
+		var err error
+		if expiry, err = time.ParseDuration(e); err != nil || expiry < 0 {
+			http.Error(w, "invalid expires: must be a duration such as 720h", http.StatusBadRequest)
+			return
+		}
+	}
+	name := r.FormValue("name")
+	token, err := c.tokens.create(name, r.FormValue("scope"), c.user(r), expiry)
+	if err != nil {
+		adminRedirect(w, r, fmt.Sprintf("Error creating token: %v", err))
+		return
+	}
+	fmt.Printf("Token %s created by %s\n", name, c.user(r))
+	c.renderAdmin(w, r, fmt.Sprintf("Token %s created, copy it now as it won't be shown again", name), token)
+}
+
+// adminTokenRevokeHandler revokes the token of the path
+func (c *Config) adminTokenRevokeHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if err := c.tokens.revoke(name); err != nil {
+		adminRedirect(w, r, fmt.Sprintf("Error revoking token: %v", err))
+		return
+	}
+	fmt.Printf("Token %s revoked by %s\n", name, c.user(r))
+	adminRedirect(w, r, fmt.Sprintf("Token %s revoked", name))
+}
+
+// tokenCommand manages the API tokens of the tokens file, the server picks the changes up without restarting
+func tokenCommand(args []string) int {
+	if len(args) == 0 || !slices.Contains([]string{"create", "list", "revoke"}, args[0]) {
+		fmt.Fprintln(os.Stderr, "Usage: ocp-perf-dash token create|list|revoke -tokens-file <file> [flags]")
+		return 2
+	}
+	action := args[0]
+	flags := flag.NewFlagSet("token "+action, flag.ExitOnError)
+	tokensFile := flags.String("tokens-file", "", "Path to the JSON file persisting the API tokens, the -tokens-file of the dashboard")
+	name := flags.String("name", "", "Name of the token, such as the pipeline using it")
+	scope := flags.String("scope", scopeReadOnly, "Scope of the token created, read-only or upload")
+	expires := flags.Duration("expires", 0, "Expiry of the token created, such as 720h, never when zero")
+	flags.Parse(args[1:])
+	if *tokensFile == "" {
+		fmt.Fprintln(os.Stderr, "-tokens-file is required")
+		return 2
+	}
+	if action != "list" && *name == "" {
+		fmt.Fprintln(os.Stderr, "-name is required")
+		return 2
+	}
+	store, err := loadTokens(*tokensFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	switch action {
+	case "create":
+		token, err := store.create(*name, *scope, os.Getenv("USER"), *expires)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Println(token)
+	case "list":
+		tokens, err := store.list()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tSCOPE\tCREATED\tCREATED BY\tEXPIRES")
+		for _, t := range tokens {
+			expires := "never"
+			if !t.Expires.IsZero() {
+				expires = t.Expires.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", t.Name, t.Scope, t.Created.Format(time.RFC3339), t.CreatedBy, expires)
+		}
+		tw.Flush()
+	case "revoke":
+		if err := store.revoke(*name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Token %s revoked\n", *name)
+	}
+	return 0
+}
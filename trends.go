@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+)
+
+// Quantile trended across workloads when none is requested
+const defaultTrendQuantile = "Ready"
+
+// MetricTrends holds the trend of a metric quantile in several workloads of a job, such as the same workload run
+// at different scales, to build scalability curves
+type MetricTrends struct {
+	JobName      string
+	MetricName   string
+	QuantileName string
+	Workloads    []WorkloadTrend
+}
+
+type WorkloadTrend struct {
+	Workload string
+	// Metadata of the latest run of the workload, holding the scale it ran at, such as the job iterations
+	Metadata   map[string]any
+	Datapoints []DataPoint
+}
+
+// loadTrends loads the trend of the requested quantile of a metric in the workloads of a job, every workload
+// holding the metric unless a list of workloads is requested
+func (c *Config) loadTrends(r *http.Request, jobName, metricName string) (MetricTrends, int, error) {
+	trends := MetricTrends{
+		JobName:      jobName,
+		MetricName:   metricName,
+		QuantileName: r.URL.Query().Get("quantile"),
+	}
+	if trends.QuantileName == "" {
+		trends.QuantileName = defaultTrendQuantile
+	}
+	opts, err := chartOptionsFromRequest(r)
+	if err != nil {
+		return trends, http.StatusBadRequest, err
+	}
+	workloads, err := loadWorkloads(filepath.Join(c.resultsDir, jobName), jobName)
+	if err != nil {
+		return trends, http.StatusNotFound, fmt.Errorf("job %s not found", jobName)
+	}
+	selected := splitList(r.URL.Query().Get("workloads"))
+	for _, name := range selected {
+		if !slices.ContainsFunc(workloads, func(w Workload) bool { return w.Name == name }) {
+			return trends, http.StatusNotFound, fmt.Errorf("workload %s/%s not found", jobName, name)
+		}
+	}
+	for _, workload := range workloads {
+		if len(selected) > 0 && !slices.Contains(selected, workload.Name) {
+			continue
+		}
+		runs, err := loadRuns(workload.Path)
+		if err != nil {
+			return trends, http.StatusInternalServerError, fmt.Errorf("error loading workload %s/%s: %v", jobName, workload.Name, err)
+		}
+		job := Job{Name: jobName, Runs: runs}
+		trend := WorkloadTrend{Workload: workload.Name}
+		for _, mg := range prepareChartData(&job, opts) {
+			if mg.MetricName != metricName {
+				continue
+			}
+			for _, chart := range mg.Charts {
+				if chart.QuantileName == trends.QuantileName {
+					trend.Datapoints = chart.Datapoints
+				}
+			}
+		}
+		// Workloads not holding the quantile are left out, unless explicitly requested
+		if len(trend.Datapoints) == 0 && len(selected) == 0 {
+			continue
+		}
+		if filtered := opts.filterRuns(runs); len(filtered) > 0 {
+			trend.Metadata = filtered[len(filtered)-1].Metadata()
+		}
+		trends.Workloads = append(trends.Workloads, trend)
+	}
+	if len(trends.Workloads) == 0 {
+		return trends, http.StatusNotFound, fmt.Errorf("no workload of job %s holds %s %s", jobName, metricName, trends.QuantileName)
+	}
+	return trends, http.StatusOK, nil
+}
+
+func (c *Config) apiTrendsHandler(w http.ResponseWriter, r *http.Request) {
+	jobName := r.PathValue("job")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	trends, status, err := c.loadTrends(r, jobName, r.PathValue("metric"))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	if wantsNDJSON(r) {
+		// A line per datapoint, labeled with its workload
+		enc := newNDJSONEncoder(w)
+		for _, trend := range trends.Workloads {
+			for _, dp := range trend.Datapoints {
+				labels := map[string]string{"MetricName": trends.MetricName, "QuantileName": trends.QuantileName, "Workload": trend.Workload}
+				if err := enc.encodeDatapoint(labels, dp); err != nil {
+					return
+				}
+			}
+		}
+		return
+	}
+	writeJSON(w, trends)
+}
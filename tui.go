@@ -0,0 +1,267 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// Runs shown in the sparklines of the workload view, older ones are left out to fit the terminal
+const tuiTrendRuns = 30
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// browser is the terminal UI navigating jobs, workloads and runs. Every view is pushed as a page, so escape
+// returns to the previous one
+type browser struct {
+	app        *tview.Application
+	pages      *tview.Pages
+	stack      []string
+	resultsDir string
+	// location is the results directory as given in the command line, which may be a URL
+	location   string
+	percentile string
+}
+
+// tuiCommand implements the tui subcommand, browsing the results directory from the terminal
+func tuiCommand(args []string) int {
+	flags := flag.NewFlagSet("tui", flag.ExitOnError)
+	resultsDir := flags.String("results-dir", "results", "Path or URL of the directory holding results")
+	flags.Parse(args)
+	// Loader diagnostics would draw over the screen
+	loadLog = io.Discard
+	root, err := openResults(*resultsDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	jobs, err := loadJobs(root)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error loading jobs:", err)
+		return 1
+	}
+	b := &browser{
+		app:        tview.NewApplication(),
+		pages:      tview.NewPages(),
+		resultsDir: root,
+		location:   *resultsDir,
+		percentile: "P99",
+	}
+	b.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyEscape:
+			b.back()
+			return nil
+		case event.Rune() == 'q':
+			b.app.Stop()
+			return nil
+		}
+		return event
+	})
+	b.showJobs(jobs)
+	if err := b.app.SetRoot(b.pages, true).Run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func (b *browser) push(name string, p tview.Primitive) {
+	b.stack = append(b.stack, name)
+	b.pages.AddAndSwitchToPage(name, p, true)
+}
+
+func (b *browser) back() {
+	if len(b.stack) < 2 {
+		return
+	}
+	b.pages.RemovePage(b.stack[len(b.stack)-1])
+	b.stack = b.stack[:len(b.stack)-1]
+	b.pages.SwitchToPage(b.stack[len(b.stack)-1])
+}
+
+// replace swaps the current page, used to redraw a view with different settings
+func (b *browser) replace(name string, p tview.Primitive) {
+	b.pages.RemovePage(b.stack[len(b.stack)-1])
+	b.stack = b.stack[:len(b.stack)-1]
+	b.push(name, p)
+}
+
+func (b *browser) frame(p tview.Primitive, title, help string) tview.Primitive {
+	footer := tview.NewTextView().SetDynamicColors(true).SetText("[gray]" + help + "  esc: back  q: quit")
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(p, 0, 1, true).
+		AddItem(footer, 1, 0, false)
+	layout.SetBorder(true).SetTitle(" " + title + " ")
+	return layout
+}
+
+func (b *browser) showJobs(jobs []Job) {
+	list := tview.NewList()
+	for _, job := range jobs {
+		list.AddItem(job.Name, fmt.Sprintf("%d workloads", len(job.Workloads)), 0, func() {
+			b.showWorkloads(job)
+		})
+	}
+	b.push("jobs", b.frame(list, "Jobs - "+b.location, "enter: open"))
+}
+
+func (b *browser) showWorkloads(job Job) {
+	list := tview.NewList()
+	for _, workload := range job.Workloads {
+		list.AddItem(workload.Name, fmt.Sprintf("%d runs", workload.RunCount), 0, func() {
+			b.showWorkload(job.Name, workload.Name)
+		})
+	}
+	b.push("workloads", b.frame(list, job.Name, "enter: open"))
+}
+
+// showWorkload shows the trend of every metric quantile along with the list of runs
+func (b *browser) showWorkload(jobName, workloadName string) {
+	b.push("workload", b.workloadView(jobName, workloadName))
+}
+
+func (b *browser) workloadView(jobName, workloadName string) tview.Primitive {
+	runs, err := loadRuns(filepath.Join(b.resultsDir, jobName, workloadName))
+	if err != nil {
+		return b.frame(tview.NewTextView().SetText(err.Error()), workloadName, "")
+	}
+	runs = ChartOptions{Last: tuiTrendRuns}.filterRuns(runs)
+	job := Job{
+		Name: jobName,
+		Runs: runs,
+	}
+
+	trends := tview.NewTable().SetFixed(1, 0).SetSelectable(true, false)
+	for col, header := range []string{"METRIC", "QUANTILE", "TREND", "LATEST", "MIN", "MAX", "CHANGE"} {
+		trends.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+	row := 1
+	for _, mg := range prepareChartData(&job, ChartOptions{}) {
+		for _, chart := range mg.Charts {
+			values := make([]float64, len(chart.Datapoints))
+			for i, dp := range chart.Datapoints {
+				values[i] = dp.value(b.percentile)
+			}
+			latest := values[len(values)-1]
+			change := "-"
+			if len(values) > 1 && values[len(values)-2] != 0 {
+				change = fmt.Sprintf("%+.1f%%", (latest-values[len(values)-2])/values[len(values)-2]*100)
+			}
+			trends.SetCell(row, 0, tview.NewTableCell(mg.MetricName))
+			trends.SetCell(row, 1, tview.NewTableCell(chart.QuantileName))
+			trends.SetCell(row, 2, tview.NewTableCell(sparkline(values)).SetTextColor(tcell.ColorAqua))
+			trends.SetCell(row, 3, tview.NewTableCell(fmt.Sprintf("%.0f", latest)).SetAlign(tview.AlignRight))
+			trends.SetCell(row, 4, tview.NewTableCell(fmt.Sprintf("%.0f", slices.Min(values))).SetAlign(tview.AlignRight))
+			trends.SetCell(row, 5, tview.NewTableCell(fmt.Sprintf("%.0f", slices.Max(values))).SetAlign(tview.AlignRight))
+			trends.SetCell(row, 6, tview.NewTableCell(change).SetAlign(tview.AlignRight))
+			row++
+		}
+	}
+	trends.SetBorder(true).SetTitle(fmt.Sprintf(" %s trend over the last %d runs ", b.percentile, len(runs)))
+
+	runTable := tview.NewTable().SetFixed(1, 0).SetSelectable(true, false)
+	for col, header := range []string{"#", "STARTED", "UUID", "PASSED", "ELAPSED", "VERSION"} {
+		runTable.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+	// Most recent runs first
+	for i, run := range slices.Backward(runs) {
+		row := len(runs) - i
+		runTable.SetCell(row, 0, tview.NewTableCell(fmt.Sprint(run.Seq)).SetAlign(tview.AlignRight))
+		runTable.SetCell(row, 1, tview.NewTableCell(run.Started().Format("2006-01-02 15:04")))
+		runTable.SetCell(row, 2, tview.NewTableCell(run.Summary.UUID))
+		runTable.SetCell(row, 3, tview.NewTableCell(fmt.Sprint(run.Summary.Passed)))
+		runTable.SetCell(row, 4, tview.NewTableCell(fmt.Sprintf("%.0fs", run.Summary.ElapsedTime)).SetAlign(tview.AlignRight))
+		runTable.SetCell(row, 5, tview.NewTableCell(run.Summary.Version))
+	}
+	runTable.SetSelectedFunc(func(row, _ int) {
+		if row > 0 {
+			b.showRun(runs[len(runs)-row])
+		}
+	})
+	runTable.SetBorder(true).SetTitle(" Runs ")
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(trends, 0, 1, true).
+		AddItem(runTable, 0, 1, false)
+	layout.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch {
+		case event.Key() == tcell.KeyTab:
+			if trends.HasFocus() {
+				b.app.SetFocus(runTable)
+			} else {
+				b.app.SetFocus(trends)
+			}
+			return nil
+		case event.Rune() == 'p':
+			b.percentile = percentiles[(slices.Index(percentiles, b.percentile)+1)%len(percentiles)]
+			b.replace("workload", b.workloadView(jobName, workloadName))
+			return nil
+		}
+		return event
+	})
+	return b.frame(layout, jobName+" / "+workloadName, "tab: switch table  p: next percentile  enter: open run")
+}
+
+// showRun shows every percentile of the measurements of a run along with its metadata
+func (b *browser) showRun(run Run) {
+	table := tview.NewTable().SetFixed(1, 0).SetSelectable(true, false)
+	headers := append([]string{"METRIC", "QUANTILE"}, percentiles...)
+	for col, header := range headers {
+		table.SetCell(0, col, tview.NewTableCell(header).SetTextColor(tcell.ColorYellow).SetSelectable(false))
+	}
+	job := Job{
+		Runs: []Run{run},
+	}
+	row := 1
+	for _, mg := range prepareChartData(&job, ChartOptions{}) {
+		for _, chart := range mg.Charts {
+			table.SetCell(row, 0, tview.NewTableCell(mg.MetricName))
+			table.SetCell(row, 1, tview.NewTableCell(chart.QuantileName))
+			for i, p := range percentiles {
+				table.SetCell(row, i+2, tview.NewTableCell(fmt.Sprintf("%.0f", chart.Datapoints[0].value(p))).SetAlign(tview.AlignRight))
+			}
+			row++
+		}
+	}
+
+	var details strings.Builder
+	fmt.Fprintf(&details, "[yellow]Run[white] #%d  [yellow]UUID[white] %s  [yellow]Started[white] %s  [yellow]Elapsed[white] %.0fs  [yellow]Passed[white] %t\n",
+		run.Seq, run.Summary.UUID, run.Started().Format("2006-01-02 15:04:05"), run.Summary.ElapsedTime, run.Summary.Passed)
+	metadata := run.Metadata()
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		fmt.Fprintf(&details, "[yellow]%s[white] %v  ", key, metadata[key])
+	}
+	header := tview.NewTextView().SetDynamicColors(true).SetWrap(true).SetText(details.String())
+
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(header, 3, 0, false).
+		AddItem(table, 0, 1, true)
+	b.push("run", b.frame(layout, run.Name(), ""))
+}
+
+// sparkline draws the values as a line of block characters scaled between their minimum and maximum
+func sparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := slices.Min(values), slices.Max(values)
+	var sb strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int(math.Round((v - lo) / (hi - lo) * float64(len(sparkBlocks)-1)))
+		}
+		sb.WriteRune(sparkBlocks[i])
+	}
+	return sb.String()
+}
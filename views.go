@@ -0,0 +1,379 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// viewNamePattern restricts the names of the views to characters that are safe in URLs
+var viewNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// View is a custom dashboard saved under a name, overlaying quantiles of metrics of possibly several workloads
+type View struct {
+	Name string `json:"name"`
+	// Owner is the user who saved the view, only they and the admins can replace or delete it. Empty when no policy
+	// is configured
+	Owner string `json:"owner,omitempty"`
+	// Run filters applied to every chart of the view
+	From  time.Time `json:"from,omitzero"`
+	To    time.Time `json:"to,omitzero"`
+	Last  int       `json:"last,omitempty"`
+	Phase string    `json:"phase,omitempty"`
+	// Charts are rendered in order
+	Charts []ViewChart `json:"charts"`
+}
+
+// ViewChart is a chart of a view, the selected percentile of some quantiles of a metric of a workload
+type ViewChart struct {
+	Job      string `json:"job"`
+	Workload string `json:"workload"`
+	Metric   string `json:"metric"`
+	// Quantiles overlaid on the chart, the pod lifecycle phases or every quantile of the metric when empty
+	Quantiles  []string `json:"quantiles,omitempty"`
+	Percentile string   `json:"percentile"`
+}
+
+// String formats the chart as a line of the view form, the inverse of parseViewChart
+func (vc ViewChart) String() string {
+	line := fmt.Sprintf("%s/%s %s", vc.Job, vc.Workload, vc.Metric)
+	if len(vc.Quantiles) > 0 {
+		line += " quantiles=" + strings.Join(vc.Quantiles, ",")
+	}
+	return line + " percentile=" + vc.Percentile
+}
+
+// parseViewChart parses a line of the view form, formatted as job/workload metric [quantiles=q1,q2] [percentile=p]
+func parseViewChart(line string) (ViewChart, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return ViewChart{}, fmt.Errorf("invalid chart %q: must be job/workload metric [quantiles=q1,q2] [percentile=p]", line)
+	}
+	job, workload, ok := strings.Cut(fields[0], "/")
+	if !ok || job == "" || workload == "" || strings.Contains(workload, "/") {
+		return ViewChart{}, fmt.Errorf("invalid chart %q: %s isn't a job/workload", line, fields[0])
+	}
+	chart := ViewChart{
+		Job:        job,
+		Workload:   workload,
+		Metric:     fields[1],
+		Percentile: "P99",
+	}
+	for _, field := range fields[2:] {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "quantiles":
+			chart.Quantiles = splitList(value)
+		case "percentile":
+			percentile, err := parsePercentile(value)
+			if err != nil {
+				return ViewChart{}, fmt.Errorf("invalid chart %q: %v", line, err)
+			}
+			chart.Percentile = percentile
+		default:
+			return ViewChart{}, fmt.Errorf("invalid chart %q: unknown option %s", line, key)
+		}
+	}
+	return chart, nil
+}
+
+// ChartsText returns the charts of the view as the lines of the view form
+func (v View) ChartsText() string {
+	var lines []string
+	for _, chart := range v.Charts {
+		lines = append(lines, chart.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// chartOptions returns the run filters of the view
+func (v View) chartOptions() ChartOptions {
+	return ChartOptions{From: v.From, To: v.To, Last: v.Last, Phase: v.Phase}
+}
+
+// query returns the run filters of the view as query parameters, the way the view form submits them
+func (v View) query() url.Values {
+	values := url.Values{}
+	if !v.From.IsZero() {
+		values.Set("from", v.From.Format(time.RFC3339))
+	}
+	if !v.To.IsZero() {
+		values.Set("to", v.To.Format(time.RFC3339))
+	}
+	if v.Last > 0 {
+		values.Set("last", fmt.Sprint(v.Last))
+	}
+	if v.Phase != "" {
+		values.Set("phase", v.Phase)
+	}
+	return values
+}
+
+// viewsStore keeps the saved views, persisted to a JSON file when configured, and in memory otherwise
+type viewsStore struct {
+	mu    sync.Mutex
+	path  string
+	views map[string]View
+}
+
+func loadViews(path string) (*viewsStore, error) {
+	store := &viewsStore{
+		path:  path,
+		views: make(map[string]View),
+	}
+	if path == "" {
+		return store, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &store.views); err != nil {
+		return nil, fmt.Errorf("error parsing views file %s: %v", path, err)
+	}
+	return store, nil
+}
+
+func (s *viewsStore) get(name string) (View, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	view, ok := s.views[name]
+	return view, ok
+}
+
+// list returns the views sorted by name
+func (s *viewsStore) list() []View {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.SortedFunc(maps.Values(s.views), func(a, b View) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+}
+
+// set saves the view, or deletes it when it has no charts
+func (s *viewsStore) set(view View) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(view.Charts) == 0 {
+		delete(s.views, view.Name)
+	} else {
+		s.views[view.Name] = view
+	}
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.views, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, data)
+}
+
+// canEditView reports whether the client can replace or delete the view, anyone can when no policy is configured
+func (c *Config) canEditView(r *http.Request, view View) bool {
+	return view.Owner == "" || view.Owner == c.user(r) || c.isAdmin(r)
+}
+
+// ViewChartData is a chart of a view along with its data, or the reason it couldn't be rendered
+type ViewChartData struct {
+	ViewChart
+	Overlay OverlayChart `json:"overlay"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// prepareView builds the charts of the view, the runs of each workload are loaded once however many charts use them
+func (c *Config) prepareView(r *http.Request, view View) []ViewChartData {
+	opts := view.chartOptions()
+	workloads := make(map[string][]MetricGroup)
+	var charts []ViewChartData
+	for _, chart := range view.Charts {
+		data := ViewChartData{ViewChart: chart}
+		if !c.jobAllowed(r, chart.Job) {
+			data.Error = fmt.Sprintf("access to job %s denied", chart.Job)
+			charts = append(charts, data)
+			continue
+		}
+		key := chart.Job + "/" + chart.Workload
+		metricGroups, ok := workloads[key]
+		if !ok {
+			job := Job{
+				Name: chart.Job,
+			}
+			runs, err := loadRuns(filepath.Join(c.resultsDir, chart.Job, chart.Workload))
+			if err != nil {
+				data.Error = fmt.Sprintf("workload %s not found", key)
+				charts = append(charts, data)
+				continue
+			}
+			job.Runs = runs
+			metricGroups = prepareChartData(&job, opts)
+			workloads[key] = metricGroups
+		}
+		overlay, err := prepareOverlayChart(metricGroups, overlayParams{
+			metric:     chart.Metric,
+			percentile: chart.Percentile,
+			quantiles:  chart.Quantiles,
+		})
+		if err != nil {
+			data.Error = err.Error()
+		}
+		data.Overlay = overlay
+		charts = append(charts, data)
+	}
+	return charts
+}
+
+// viewFromForm parses the view submitted by the view form
+func viewFromForm(r *http.Request) (View, error) {
+	if err := r.ParseForm(); err != nil {
+		return View{}, err
+	}
+	view := View{
+		Name: strings.TrimSpace(r.PostForm.Get("name")),
+	}
+	if !viewNamePattern.MatchString(view.Name) {
+		return view, fmt.Errorf("invalid view name %q: must only contain letters, digits, dots, dashes and underscores", view.Name)
+	}
+	opts, err := chartOptionsFromValues(r.PostForm)
+	if err != nil {
+		return view, err
+	}
+	view.From, view.To, view.Last, view.Phase = opts.From, opts.To, opts.Last, opts.Phase
+	for line := range strings.Lines(r.PostForm.Get("charts")) {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		chart, err := parseViewChart(line)
+		if err != nil {
+			return view, err
+		}
+		view.Charts = append(view.Charts, chart)
+	}
+	if len(view.Charts) == 0 {
+		return view, fmt.Errorf("view %s has no charts", view.Name)
+	}
+	return view, nil
+}
+
+// saveViewHandler saves the view submitted by the view form, replacing the view of the same name, and goes to its page
+func (c *Config) saveViewHandler(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	view, err := viewFromForm(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for _, chart := range view.Charts {
+		if !c.jobAllowed(r, chart.Job) {
+			jobForbidden(w, chart.Job)
+			return
+		}
+	}
+	if existing, ok := c.views.get(view.Name); ok && !c.canEditView(r, existing) {
+		http.Error(w, fmt.Sprintf("view %s belongs to %s", view.Name, existing.Owner), http.StatusForbidden)
+		return
+	}
+	view.Owner = c.user(r)
+	if err := c.views.set(view); err != nil {
+		http.Error(w, fmt.Sprintf("error saving view: %v", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/views/"+url.PathEscape(view.Name), http.StatusSeeOther)
+}
+
+func (c *Config) deleteViewHandler(w http.ResponseWriter, r *http.Request) {
+	if !sameOrigin(r) {
+		http.Error(w, "cross-origin request rejected", http.StatusForbidden)
+		return
+	}
+	name := r.PathValue("name")
+	view, ok := c.views.get(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("view %s not found", name), http.StatusNotFound)
+		return
+	}
+	if !c.canEditView(r, view) {
+		http.Error(w, fmt.Sprintf("view %s belongs to %s", name, view.Owner), http.StatusForbidden)
+		return
+	}
+	if err := c.views.set(View{Name: name}); err != nil {
+		http.Error(w, fmt.Sprintf("error deleting view: %v", err), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/views", http.StatusSeeOther)
+}
+
+// viewsHandler lists the saved views along with the form saving them, filled in with the view to edit if any
+func (c *Config) viewsHandler(w http.ResponseWriter, r *http.Request) {
+	type TemplateData struct {
+		Views []View
+		Edit  View
+		Query url.Values
+	}
+	data := TemplateData{
+		Views: c.views.list(),
+	}
+	if name := r.URL.Query().Get("edit"); name != "" {
+		data.Edit, _ = c.views.get(name)
+	}
+	data.Query = data.Edit.query()
+	c.renderTemplate(w, r, "views.html", data)
+}
+
+func (c *Config) viewHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	view, ok := c.views.get(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("view %s not found", name), http.StatusNotFound)
+		return
+	}
+	type TemplateData struct {
+		View       View
+		Charts     []ViewChartData
+		ChartsJSON template.JS
+		CanEdit    bool
+	}
+	charts := c.prepareView(r, view)
+	chartsJSON, _ := json.Marshal(charts)
+	c.renderTemplate(w, r, "view.html", TemplateData{
+		View:       view,
+		Charts:     charts,
+		ChartsJSON: template.JS(chartsJSON),
+		CanEdit:    c.canEditView(r, view),
+	})
+}
+
+func (c *Config) apiViewsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, c.views.list())
+}
+
+func (c *Config) apiViewHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	view, ok := c.views.get(name)
+	if !ok {
+		http.Error(w, fmt.Sprintf("view %s not found", name), http.StatusNotFound)
+		return
+	}
+	writeJSON(w, struct {
+		View
+		Data []ViewChartData `json:"data"`
+	}{view, c.prepareView(r, view)})
+}
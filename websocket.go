@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// Changes within a workload are collapsed into a single notification
+	eventDebounce = 2 * time.Second
+	wsPingPeriod  = 30 * time.Second
+	wsWriteWait   = 10 * time.Second
+)
+
+// Event is the notification pushed to websocket clients when the results tree changes
+type Event struct {
+	Type         string        `json:"type"`
+	Job          string        `json:"job"`
+	Workload     string        `json:"workload,omitempty"`
+	Run          string        `json:"run,omitempty"`
+	MetricGroups []MetricGroup `json:"metricGroups,omitempty"`
+}
+
+type wsClient struct {
+	conn     *websocket.Conn
+	job      string
+	workload string
+	send     chan Event
+	// allowed reports whether the client can be notified about the given job
+	allowed func(job string) bool
+}
+
+// wsHub keeps track of the connected websocket clients and fans out events to them
+type wsHub struct {
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+func newHub() *wsHub {
+	return &wsHub{
+		clients: make(map[*wsClient]struct{}),
+	}
+}
+
+func (h *wsHub) register(client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.clients[client] = struct{}{}
+}
+
+func (h *wsHub) len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.clients)
+}
+
+func (h *wsHub) unregister(client *wsClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.clients[client]; ok {
+		delete(h.clients, client)
+		close(client.send)
+	}
+}
+
+// subscribed returns true when at least one client is watching the given workload
+func (h *wsHub) subscribed(job, workload string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		if client.job == job && client.workload == workload {
+			return true
+		}
+	}
+	return false
+}
+
+// broadcast sends the event to every client interested in it. Clients without a job filter get every event,
+// chart payloads are only delivered to the clients watching that workload
+func (h *wsHub) broadcast(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		if (client.job != "" && client.job != event.Job) || !client.allowed(event.Job) {
+			continue
+		}
+		e := event
+		if client.workload != event.Workload {
+			e.MetricGroups = nil
+		}
+		select {
+		case client.send <- e:
+		default:
+			// Slow client, drop it rather than blocking the rest
+			delete(h.clients, client)
+			close(client.send)
+		}
+	}
+}
+
+func (c *Config) wsHandler(w http.ResponseWriter, r *http.Request) {
+	job := r.URL.Query().Get("job")
+	if job != "" && !c.jobAllowed(r, job) {
+		jobForbidden(w, job)
+		return
+	}
+	allowed := func(string) bool { return true }
+	if c.policy != nil {
+		id := c.policy.identity(r)
+		allowed = func(job string) bool { return c.policy.jobAllowed(id, job) }
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		fmt.Println("Error upgrading websocket connection:", err)
+		return
+	}
+	client := &wsClient{
+		conn:     conn,
+		job:      job,
+		workload: r.URL.Query().Get("workload"),
+		send:     make(chan Event, 16),
+		allowed:  allowed,
+	}
+	c.hub.register(client)
+	go client.writePump()
+	client.readPump(c.hub)
+}
+
+// readPump discards incoming messages and detects closed connections
+func (client *wsClient) readPump(h *wsHub) {
+	defer func() {
+		h.unregister(client)
+		client.conn.Close()
+	}()
+	client.conn.SetReadDeadline(time.Now().Add(2 * wsPingPeriod))
+	client.conn.SetPongHandler(func(string) error {
+		client.conn.SetReadDeadline(time.Now().Add(2 * wsPingPeriod))
+		return nil
+	})
+	for {
+		if _, _, err := client.conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func (client *wsClient) writePump() {
+	ticker := time.NewTicker(wsPingPeriod)
+	defer func() {
+		ticker.Stop()
+		client.conn.Close()
+	}()
+	for {
+		select {
+		case event, ok := <-client.send:
+			client.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if !ok {
+				client.conn.WriteMessage(websocket.CloseMessage, nil)
+				return
+			}
+			if err := client.conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			client.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := client.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// watchResults watches the results directory tree and publishes an event to the hub for every job,
+// workload or run that changes
+func (c *Config) watchResults() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	c.watcher = watcher
+	if err := c.addWatches(); err != nil {
+		watcher.Close()
+		c.watcher = nil
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		pending := make(map[string]Event)
+		timer := time.NewTimer(eventDebounce)
+		timer.Stop()
+		for {
+			select {
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if ev.Has(fsnotify.Create) {
+					if info, err := os.Stat(ev.Name); err == nil && info.IsDir() && depth(c.resultsDir, ev.Name) <= 3 {
+						watcher.Add(ev.Name)
+					}
+				}
+				if event, ok := c.eventFromPath(ev.Name); ok {
+					pending[event.Type+"/"+event.Job+"/"+event.Workload+"/"+event.Run] = event
+					timer.Reset(eventDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Println("Error watching results directory:", err)
+			case <-timer.C:
+				for _, event := range pending {
+					c.publish(event)
+				}
+				clear(pending)
+			}
+		}
+	}()
+	return nil
+}
+
+// addWatches watches every directory down to the run level, files landing inside a run are reported as run changes.
+// Directories already being watched are left untouched
+func (c *Config) addWatches() error {
+	if c.watcher == nil {
+		return fmt.Errorf("results directory is not being watched")
+	}
+	return filepath.WalkDir(c.resultsDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		if depth(c.resultsDir, path) > 3 {
+			return filepath.SkipDir
+		}
+		return c.watcher.Add(path)
+	})
+}
+
+func (c *Config) watchedDirs() int {
+	if c.watcher == nil {
+		return 0
+	}
+	return len(c.watcher.WatchList())
+}
+
+// eventFromPath maps a changed path to the job, workload and run it belongs to
+func (c *Config) eventFromPath(path string) (Event, bool) {
+	rel, err := filepath.Rel(c.resultsDir, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return Event{}, false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	event := Event{Job: parts[0]}
+	switch {
+	case len(parts) == 1:
+		event.Type = "job"
+	case len(parts) == 2:
+		event.Type = "workload"
+		event.Workload = parts[1]
+	default:
+		event.Type = "run"
+		event.Workload = parts[1]
+		event.Run = parts[2]
+	}
+	return event, true
+}
+
+// loadEventRun loads the run of a run event. Runs removed since aren't loaded, nor reported as unreadable
+func loadEventRun(runPath string) (Run, bool) {
+	entries, err := storage.ReadDir(runPath)
+	if err != nil {
+		return Run{}, false
+	}
+	run, err := loadRunFiles(runPath, entries)
+	return run, err == nil
+}
+
+// publish hands a change of the results to the features reacting to it, and broadcasts it to the websocket clients
+// along with the refreshed charts of its workload when someone is watching it
+func (c *Config) publish(event Event) {
+	// The databases of the SQL queries are loaded again with the change
+	if c.analytics != nil {
+		c.analytics.invalidate()
+	}
+	// Added runs are checked for anomalies against the runs before them
+	if event.Type == "run" {
+		go c.checkAnomalies(event)
+	}
+	// Added runs are analyzed by the external analyzers
+	if event.Type == "run" && c.analyzers != nil {
+		go c.analyzers.analyze(c.resultsDir, event.Job, event.Workload)
+	}
+	// Added runs are notified, and checked for sustained regressions and against the alert rules
+	if event.Type == "run" && c.notify != nil {
+		go c.checkRegressions(event.Job, event.Workload)
+		go c.notifyRun(event)
+		go c.checkRules(event)
+	}
+	// The quantiles of added runs are written to the remote-write endpoint
+	if event.Type == "run" && c.remoteWrite != nil {
+		go c.remoteWrite.writeRun(filepath.Join(c.resultsDir, event.Job, event.Workload, event.Run), event.Job, event.Workload)
+	}
+	if !c.features.enabled(featureLiveUpdates) {
+		return
+	}
+	if event.Type == "run" && c.hub.subscribed(event.Job, event.Workload) {
+		job := Job{Name: event.Job}
+		runs, err := loadRuns(filepath.Join(c.resultsDir, event.Job, event.Workload))
+		if err != nil {
+			fmt.Printf("Error loading runs for %s/%s: %v\n", event.Job, event.Workload, err)
+		}
+		job.Runs = runs
+		event.MetricGroups = prepareChartData(&job, ChartOptions{GroupByJob: true})
+	}
+	c.hub.broadcast(event)
+}
+
+func depth(root, path string) int {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return 0
+	}
+	return len(strings.Split(filepath.ToSlash(rel), "/"))
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+)
+
+// Wins lists the significant improvements of the latest runs of every workload of a job over their baseline, as
+// evidence for the optimizations they shipped
+type Wins struct {
+	Job          string
+	Percentile   string
+	Test         string
+	Significance float64
+	Workloads    []WorkloadWins
+}
+
+// WorkloadWins holds the metrics of a workload that decreased significantly, largest decrease first
+type WorkloadWins struct {
+	Workload     string
+	Baseline     string
+	BaselineRuns []string
+	CurrentRuns  []string
+	Improvements []MetricComparison
+	// Error is set when the workload couldn't be compared, e.g. when it lacks baseline runs
+	Error string `json:",omitempty"`
+}
+
+// Count returns the number of improvements across the workloads
+func (w Wins) Count() int {
+	count := 0
+	for _, ww := range w.Workloads {
+		count += len(ww.Improvements)
+	}
+	return count
+}
+
+// loadWins compares the workloads of the job with the comparison parameters of the request. Improvements are only
+// claimed when significant, so Welch's t-test is run unless another test is requested
+func (c *Config) loadWins(r *http.Request, jobName string) (Wins, int, error) {
+	opts, err := c.compareOptionsFromRequest(r)
+	if err != nil {
+		return Wins{Job: jobName}, http.StatusBadRequest, err
+	}
+	if opts.Test == "" {
+		opts.Test = testWelch
+	}
+	wins := Wins{Job: jobName, Percentile: opts.Percentile, Test: opts.Test, Significance: opts.Significance, Workloads: []WorkloadWins{}}
+	workloads, err := loadWorkloads(filepath.Join(c.resultsDir, jobName), jobName)
+	if err != nil {
+		return wins, http.StatusNotFound, fmt.Errorf("job %s not found", jobName)
+	}
+	for _, workload := range workloads {
+		if workload.Retired && r.URL.Query().Get("retired") != "true" {
+			continue
+		}
+		ww := WorkloadWins{Workload: workload.Name, Improvements: []MetricComparison{}}
+		runs, err := loadRuns(workload.Path)
+		if err == nil {
+			var snapshot *BaselineSnapshot
+			if snapshot, err = loadBaseline(workload.Path); err == nil {
+				var comparison Comparison
+				if comparison, err = compareRuns(runs, snapshot, opts); err == nil {
+					ww.Baseline, ww.BaselineRuns, ww.CurrentRuns = comparison.Baseline, comparison.BaselineRuns, comparison.CurrentRuns
+					ww.Improvements = append(ww.Improvements, comparison.Improvements()...)
+				}
+			}
+		}
+		if err != nil {
+			ww.Error = err.Error()
+		}
+		wins.Workloads = append(wins.Workloads, ww)
+	}
+	return wins, http.StatusOK, nil
+}
+
+func (c *Config) apiWinsHandler(w http.ResponseWriter, r *http.Request) {
+	jobName := r.PathValue("job")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	wins, status, err := c.loadWins(r, jobName)
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	writeJSON(w, wins)
+}
+
+func (c *Config) winsHandler(w http.ResponseWriter, r *http.Request) {
+	jobName := r.PathValue("job")
+	if !c.jobAllowed(r, jobName) {
+		jobForbidden(w, jobName)
+		return
+	}
+	wins, status, err := c.loadWins(r, jobName)
+	if err != nil && status != http.StatusBadRequest {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	type TemplateData struct {
+		JobName     string
+		Wins        Wins
+		Percentiles []string
+		Error       string
+	}
+	data := TemplateData{
+		JobName:     jobName,
+		Wins:        wins,
+		Percentiles: percentiles,
+	}
+	if err != nil {
+		data.Error = err.Error()
+	}
+	c.renderTemplate(w, r, "wins.html", data)
+}